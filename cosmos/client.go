@@ -0,0 +1,311 @@
+package cosmos
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/owlto-dao/utils-go/network"
+)
+
+// Client queries a Cosmos SDK chain through its LCD (REST) endpoint and, when set,
+// its Tendermint RPC endpoint.
+type Client struct {
+	LcdURL        string
+	TendermintURL string // Optional, heights and txs are read from the LCD when empty
+	Bech32Prefix  string // Optional, queried from the LCD when empty
+
+	prefixMutex sync.Mutex
+}
+
+// NewClient parses an endpoint of the form "lcd_url[,tendermint_url[,bech32_prefix]]".
+func NewClient(endpoint string) *Client {
+	parts := strings.Split(endpoint, ",")
+	for i := range parts {
+		parts[i] = strings.TrimRight(strings.TrimSpace(parts[i]), "/")
+	}
+	client := &Client{LcdURL: parts[0]}
+	if len(parts) > 1 {
+		client.TendermintURL = parts[1]
+	}
+	if len(parts) > 2 {
+		client.Bech32Prefix = parts[2]
+	}
+	return client
+}
+
+// Coin is an amount of a bank denom, native like uatom or IBC like ibc/27394FB0...
+type Coin struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+// DenomMetadata is the bank metadata of a denom.
+type DenomMetadata struct {
+	Base       string `json:"base"`
+	Display    string `json:"display"`
+	Name       string `json:"name"`
+	Symbol     string `json:"symbol"`
+	DenomUnits []struct {
+		Denom    string `json:"denom"`
+		Exponent int32  `json:"exponent"`
+	} `json:"denom_units"`
+}
+
+// Decimals returns the exponent of the display unit.
+func (m *DenomMetadata) Decimals() int32 {
+	for _, unit := range m.DenomUnits {
+		if unit.Denom == m.Display {
+			return unit.Exponent
+		}
+	}
+	return 0
+}
+
+// Cw20TokenInfo is the token_info of a CW20 contract.
+type Cw20TokenInfo struct {
+	Name        string `json:"name"`
+	Symbol      string `json:"symbol"`
+	Decimals    int32  `json:"decimals"`
+	TotalSupply string `json:"total_supply"`
+}
+
+// TxResult is the execution result of a committed tx.
+type TxResult struct {
+	Hash   string
+	Height int64
+	Code   uint32 // 0 on success
+	Log    string
+}
+
+func (c *Client) lcd(ctx context.Context, path string, height int64, result interface{}) error {
+	var headers map[string]string
+	if height > 0 {
+		headers = map[string]string{"x-cosmos-block-height": strconv.FormatInt(height, 10)}
+	}
+	return network.DoRequestWithContext(ctx, c.LcdURL+path, headers, nil, result)
+}
+
+// Prefix returns the bech32 account prefix of the chain, e.g. cosmos or osmo.
+func (c *Client) Prefix(ctx context.Context) (string, error) {
+	c.prefixMutex.Lock()
+	defer c.prefixMutex.Unlock()
+	if c.Bech32Prefix != "" {
+		return c.Bech32Prefix, nil
+	}
+	var rsp struct {
+		Bech32Prefix string `json:"bech32_prefix"`
+	}
+	if err := c.lcd(ctx, "/cosmos/auth/v1beta1/bech32", 0, &rsp); err != nil {
+		return "", err
+	}
+	if rsp.Bech32Prefix == "" {
+		return "", fmt.Errorf("empty bech32 prefix")
+	}
+	c.Bech32Prefix = rsp.Bech32Prefix
+	return c.Bech32Prefix, nil
+}
+
+// Balance returns the bank balance of a denom, at height when it is positive.
+func (c *Client) Balance(ctx context.Context, addr string, denom string, height int64) (*big.Int, error) {
+	var rsp struct {
+		Balance Coin `json:"balance"`
+	}
+	path := fmt.Sprintf("/cosmos/bank/v1beta1/balances/%s/by_denom?denom=%s", url.PathEscape(addr), url.QueryEscape(denom))
+	if err := c.lcd(ctx, path, height, &rsp); err != nil {
+		return nil, err
+	}
+	return parseAmount(rsp.Balance.Amount)
+}
+
+// DenomMetadata returns the bank metadata of a denom.
+func (c *Client) DenomMetadata(ctx context.Context, denom string) (*DenomMetadata, error) {
+	var rsp struct {
+		Metadata DenomMetadata `json:"metadata"`
+	}
+	path := "/cosmos/bank/v1beta1/denoms_metadata_by_query_string?denom=" + url.QueryEscape(denom)
+	if err := c.lcd(ctx, path, 0, &rsp); err != nil {
+		return nil, err
+	}
+	return &rsp.Metadata, nil
+}
+
+// SmartQuery runs a CosmWasm smart query on contract and decodes its data into result.
+func (c *Client) SmartQuery(ctx context.Context, contract string, query interface{}, height int64, result interface{}) error {
+	queryBytes, err := json.Marshal(query)
+	if err != nil {
+		return err
+	}
+	var rsp struct {
+		Data json.RawMessage `json:"data"`
+	}
+	path := fmt.Sprintf("/cosmwasm/wasm/v1/contract/%s/smart/%s", url.PathEscape(contract), base64.URLEncoding.EncodeToString(queryBytes))
+	if err := c.lcd(ctx, path, height, &rsp); err != nil {
+		return err
+	}
+	return json.Unmarshal(rsp.Data, result)
+}
+
+// Cw20Balance returns the balance of addr in a CW20 contract, at height when it is positive.
+func (c *Client) Cw20Balance(ctx context.Context, contract string, addr string, height int64) (*big.Int, error) {
+	var data struct {
+		Balance string `json:"balance"`
+	}
+	query := map[string]interface{}{"balance": map[string]string{"address": addr}}
+	if err := c.SmartQuery(ctx, contract, query, height, &data); err != nil {
+		return nil, err
+	}
+	return parseAmount(data.Balance)
+}
+
+// Cw20Allowance returns the amount spender may transfer from owner in a CW20 contract.
+func (c *Client) Cw20Allowance(ctx context.Context, contract string, owner string, spender string) (*big.Int, error) {
+	var data struct {
+		Allowance string `json:"allowance"`
+	}
+	query := map[string]interface{}{"allowance": map[string]string{"owner": owner, "spender": spender}}
+	if err := c.SmartQuery(ctx, contract, query, 0, &data); err != nil {
+		return nil, err
+	}
+	return parseAmount(data.Allowance)
+}
+
+// Cw20TokenInfo returns the token_info of a CW20 contract.
+func (c *Client) Cw20TokenInfo(ctx context.Context, contract string) (*Cw20TokenInfo, error) {
+	var data Cw20TokenInfo
+	if err := c.SmartQuery(ctx, contract, map[string]interface{}{"token_info": struct{}{}}, 0, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+type tendermintResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    string `json:"data"`
+	} `json:"error"`
+}
+
+func (c *Client) tendermint(ctx context.Context, path string, result interface{}) error {
+	var rsp tendermintResponse
+	if err := network.DoRequestWithContext(ctx, c.TendermintURL+path, nil, nil, &rsp); err != nil {
+		return err
+	}
+	if rsp.Error != nil {
+		return fmt.Errorf("tendermint error %d: %s %s", rsp.Error.Code, rsp.Error.Message, rsp.Error.Data)
+	}
+	return json.Unmarshal(rsp.Result, result)
+}
+
+// LatestHeight returns the height of the latest block.
+func (c *Client) LatestHeight(ctx context.Context) (int64, error) {
+	if c.TendermintURL != "" {
+		var status struct {
+			SyncInfo struct {
+				LatestBlockHeight string `json:"latest_block_height"`
+			} `json:"sync_info"`
+		}
+		if err := c.tendermint(ctx, "/status", &status); err != nil {
+			return 0, err
+		}
+		return strconv.ParseInt(status.SyncInfo.LatestBlockHeight, 10, 64)
+	}
+
+	var rsp struct {
+		Block struct {
+			Header struct {
+				Height string `json:"height"`
+			} `json:"header"`
+		} `json:"block"`
+	}
+	if err := c.lcd(ctx, "/cosmos/base/tendermint/v1beta1/blocks/latest", 0, &rsp); err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(rsp.Block.Header.Height, 10, 64)
+}
+
+// Tx returns the result of a committed tx by its hex hash, with or without 0x.
+func (c *Client) Tx(ctx context.Context, hash string) (*TxResult, error) {
+	hash = strings.ToUpper(strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(hash), "0x"), "0X"))
+
+	if c.TendermintURL != "" {
+		var tx struct {
+			Hash     string `json:"hash"`
+			Height   string `json:"height"`
+			TxResult struct {
+				Code uint32 `json:"code"`
+				Log  string `json:"log"`
+			} `json:"tx_result"`
+		}
+		if err := c.tendermint(ctx, "/tx?hash=0x"+hash, &tx); err != nil {
+			return nil, err
+		}
+		height, err := strconv.ParseInt(tx.Height, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("parse height of tx %s: %w", hash, err)
+		}
+		return &TxResult{Hash: hash, Height: height, Code: tx.TxResult.Code, Log: tx.TxResult.Log}, nil
+	}
+
+	var rsp struct {
+		TxResponse struct {
+			TxHash string `json:"txhash"`
+			Height string `json:"height"`
+			Code   uint32 `json:"code"`
+			RawLog string `json:"raw_log"`
+		} `json:"tx_response"`
+	}
+	if err := c.lcd(ctx, "/cosmos/tx/v1beta1/txs/"+hash, 0, &rsp); err != nil {
+		return nil, err
+	}
+	height, err := strconv.ParseInt(rsp.TxResponse.Height, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("parse height of tx %s: %w", hash, err)
+	}
+	return &TxResult{Hash: hash, Height: height, Code: rsp.TxResponse.Code, Log: rsp.TxResponse.RawLog}, nil
+}
+
+// ValidateAddress checks that addr is a bech32 account or contract address with the given prefix.
+func ValidateAddress(addr string, prefix string) error {
+	hrp, data, err := bech32.Decode(addr)
+	if err != nil {
+		return err
+	}
+	if hrp != prefix {
+		return fmt.Errorf("address prefix %s, want %s", hrp, prefix)
+	}
+	decoded, err := bech32.ConvertBits(data, 5, 8, false)
+	if err != nil {
+		return err
+	}
+	if len(decoded) != 20 && len(decoded) != 32 {
+		return fmt.Errorf("address length %d, want 20 or 32", len(decoded))
+	}
+	return nil
+}
+
+// IsBech32 reports whether s decodes as bech32, which tells CW20 contracts from bank denoms.
+func IsBech32(s string) bool {
+	_, _, err := bech32.Decode(s)
+	return err == nil
+}
+
+func parseAmount(amount string) (*big.Int, error) {
+	if amount == "" {
+		return big.NewInt(0), nil
+	}
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %s", amount)
+	}
+	return value, nil
+}
@@ -8,6 +8,7 @@ import (
 )
 
 var UnauthorizedErr = NewBizError(1004, "unauthorized")
+var ForbiddenErr = NewBizError(1005, "forbidden")
 
 type BizError struct {
 	Code int64                  `json:"code"`
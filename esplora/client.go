@@ -0,0 +1,183 @@
+package esplora
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/owlto-dao/utils-go/network"
+)
+
+// Client queries an Esplora compatible API, such as blockstream.info/api or mempool.space/api.
+type Client struct {
+	BaseURL string
+}
+
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimRight(strings.TrimSpace(baseURL), "/")}
+}
+
+// TxStatus is the confirmation status of a tx.
+type TxStatus struct {
+	Confirmed   bool   `json:"confirmed"`
+	BlockHeight int64  `json:"block_height"`
+	BlockHash   string `json:"block_hash"`
+	BlockTime   int64  `json:"block_time"`
+}
+
+// Utxo is an unspent output of an address.
+type Utxo struct {
+	TxId   string   `json:"txid"`
+	Vout   uint32   `json:"vout"`
+	Value  int64    `json:"value"` // Satoshi
+	Status TxStatus `json:"status"`
+}
+
+// AddressStats sums the outputs funded to and spent by an address.
+type AddressStats struct {
+	FundedTxoSum int64 `json:"funded_txo_sum"`
+	SpentTxoSum  int64 `json:"spent_txo_sum"`
+	TxCount      int64 `json:"tx_count"`
+}
+
+// AddressInfo is the confirmed and mempool activity of an address.
+type AddressInfo struct {
+	Address      string       `json:"address"`
+	ChainStats   AddressStats `json:"chain_stats"`
+	MempoolStats AddressStats `json:"mempool_stats"`
+}
+
+// ConfirmedBalance returns the confirmed balance in satoshi.
+func (a *AddressInfo) ConfirmedBalance() int64 {
+	return a.ChainStats.FundedTxoSum - a.ChainStats.SpentTxoSum
+}
+
+// Balance returns the balance including the mempool, in satoshi.
+func (a *AddressInfo) Balance() int64 {
+	return a.ConfirmedBalance() + a.MempoolStats.FundedTxoSum - a.MempoolStats.SpentTxoSum
+}
+
+// RecommendedFees are fee rates in sat/vB, named after the mempool.space recommendations.
+type RecommendedFees struct {
+	FastestFee  float64 `json:"fastestFee"`  // Next block
+	HalfHourFee float64 `json:"halfHourFee"` // Within 3 blocks
+	HourFee     float64 `json:"hourFee"`     // Within 6 blocks
+	EconomyFee  float64 `json:"economyFee"`  // Within a day
+	MinimumFee  float64 `json:"minimumFee"`
+}
+
+func (c *Client) get(ctx context.Context, path string, result interface{}) error {
+	return network.DoRequestWithContext(ctx, c.BaseURL+path, nil, nil, result)
+}
+
+// TipHeight returns the height of the last block.
+func (c *Client) TipHeight(ctx context.Context) (int64, error) {
+	var height int64
+	if err := c.get(ctx, "/blocks/tip/height", &height); err != nil {
+		return 0, err
+	}
+	return height, nil
+}
+
+// TxStatus returns the confirmation status of a tx.
+func (c *Client) TxStatus(ctx context.Context, txId string) (*TxStatus, error) {
+	var status TxStatus
+	if err := c.get(ctx, "/tx/"+strings.TrimSpace(txId)+"/status", &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Confirmations returns the number of confirmations of a tx, 0 while it is in the mempool.
+func (c *Client) Confirmations(ctx context.Context, txId string) (int64, *TxStatus, error) {
+	status, err := c.TxStatus(ctx, txId)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !status.Confirmed {
+		return 0, status, nil
+	}
+	tip, err := c.TipHeight(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	return tip - status.BlockHeight + 1, status, nil
+}
+
+// Address returns the stats of an address.
+func (c *Client) Address(ctx context.Context, addr string) (*AddressInfo, error) {
+	var info AddressInfo
+	if err := c.get(ctx, "/address/"+strings.TrimSpace(addr), &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Utxos returns the unspent outputs of an address, confirmed and in the mempool.
+func (c *Client) Utxos(ctx context.Context, addr string) ([]Utxo, error) {
+	utxos := make([]Utxo, 0)
+	if err := c.get(ctx, "/address/"+strings.TrimSpace(addr)+"/utxo", &utxos); err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
+
+// FeeEstimates returns the fee rates in sat/vB by confirmation target in blocks.
+func (c *Client) FeeEstimates(ctx context.Context) (map[int]float64, error) {
+	var raw map[string]float64
+	if err := c.get(ctx, "/fee-estimates", &raw); err != nil {
+		return nil, err
+	}
+	estimates := make(map[int]float64, len(raw))
+	for target, rate := range raw {
+		blocks, err := strconv.Atoi(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee estimate target %s", target)
+		}
+		estimates[blocks] = rate
+	}
+	return estimates, nil
+}
+
+// RecommendedFees returns the mempool.space recommendations, or derives them from FeeEstimates
+// on Esplora servers without the /v1/fees/recommended endpoint.
+func (c *Client) RecommendedFees(ctx context.Context) (*RecommendedFees, error) {
+	var fees RecommendedFees
+	if err := c.get(ctx, "/v1/fees/recommended", &fees); err == nil && fees.FastestFee > 0 {
+		return &fees, nil
+	}
+
+	estimates, err := c.FeeEstimates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(estimates) == 0 {
+		return nil, fmt.Errorf("no fee estimates")
+	}
+	return &RecommendedFees{
+		FastestFee:  estimateFor(estimates, 1),
+		HalfHourFee: estimateFor(estimates, 3),
+		HourFee:     estimateFor(estimates, 6),
+		EconomyFee:  estimateFor(estimates, 144),
+		MinimumFee:  estimateFor(estimates, 1008),
+	}, nil
+}
+
+// estimateFor returns the rate of the largest target not above blocks, or of the smallest target.
+func estimateFor(estimates map[int]float64, blocks int) float64 {
+	targets := make([]int, 0, len(estimates))
+	for target := range estimates {
+		targets = append(targets, target)
+	}
+	sort.Ints(targets)
+	rate := estimates[targets[0]]
+	for _, target := range targets {
+		if target > blocks {
+			break
+		}
+		rate = estimates[target]
+	}
+	return rate
+}
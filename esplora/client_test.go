@@ -0,0 +1,72 @@
+package esplora
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestServer(t *testing.T, recommended bool) *Client {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blocks/tip/height", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("850010"))
+	})
+	mux.HandleFunc("/tx/confirmed/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"confirmed":true,"block_height":850000,"block_hash":"00ab","block_time":1718000000}`))
+	})
+	mux.HandleFunc("/tx/pending/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"confirmed":false}`))
+	})
+	mux.HandleFunc("/address/bc1qtest/utxo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"txid":"aa","vout":1,"value":5000,"status":{"confirmed":true,"block_height":849000}},{"txid":"bb","vout":0,"value":700,"status":{"confirmed":false}}]`))
+	})
+	mux.HandleFunc("/fee-estimates", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"1":25.5,"2":20,"3":18,"6":12,"144":3,"1008":1.1}`))
+	})
+	if recommended {
+		mux.HandleFunc("/v1/fees/recommended", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"fastestFee":30,"halfHourFee":22,"hourFee":15,"economyFee":5,"minimumFee":2}`))
+		})
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return NewClient(server.URL + "/")
+}
+
+func TestClient(t *testing.T) {
+	ctx := context.Background()
+	client := newTestServer(t, true)
+
+	if height, err := client.TipHeight(ctx); err != nil || height != 850010 {
+		t.Fatalf("tip height: %d %v", height, err)
+	}
+	if confirmations, status, err := client.Confirmations(ctx, "confirmed"); err != nil || confirmations != 11 || status.BlockHash != "00ab" {
+		t.Fatalf("confirmations: %d %+v %v", confirmations, status, err)
+	}
+	if confirmations, status, err := client.Confirmations(ctx, "pending"); err != nil || confirmations != 0 || status.Confirmed {
+		t.Fatalf("pending confirmations: %d %+v %v", confirmations, status, err)
+	}
+	if _, err := client.TxStatus(ctx, "missing"); err == nil {
+		t.Fatal("expected missing tx error")
+	}
+
+	utxos, err := client.Utxos(ctx, "bc1qtest")
+	if err != nil || len(utxos) != 2 || utxos[0].Value != 5000 || utxos[0].Vout != 1 || utxos[1].Status.Confirmed {
+		t.Fatalf("utxos: %+v %v", utxos, err)
+	}
+
+	if fees, err := client.RecommendedFees(ctx); err != nil || fees.FastestFee != 30 || fees.MinimumFee != 2 {
+		t.Fatalf("recommended fees: %+v %v", fees, err)
+	}
+}
+
+func TestRecommendedFeesFromEstimates(t *testing.T) {
+	fees, err := newTestServer(t, false).RecommendedFees(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fees.FastestFee != 25.5 || fees.HalfHourFee != 18 || fees.HourFee != 12 || fees.EconomyFee != 3 || fees.MinimumFee != 1.1 {
+		t.Fatalf("unexpected fees %+v", fees)
+	}
+}
@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -18,6 +20,8 @@ type AccountManager struct {
 	idAccounts         map[int64]*Account
 	addressCidAccounts map[string]map[int64]*Account
 	cidAddressAccounts map[int64]map[string]*Account
+	cidBackends        map[int64]Backend
+	chainInfoMgr       *ChainInfoManager
 	db                 *sql.DB
 	alerter            alert.Alerter
 	mutex              *sync.RWMutex
@@ -28,6 +32,7 @@ func NewAccountManager(db *sql.DB, alerter alert.Alerter) *AccountManager {
 		idAccounts:         make(map[int64]*Account),
 		addressCidAccounts: make(map[string]map[int64]*Account),
 		cidAddressAccounts: make(map[int64]map[string]*Account),
+		cidBackends:        make(map[int64]Backend),
 		db:                 db,
 		alerter:            alerter,
 		mutex:              &sync.RWMutex{},
@@ -41,11 +46,24 @@ func (mgr *AccountManager) GetAccountById(id int64) (*Account, bool) {
 	return acc, ok
 }
 
+// SetChainInfoManager sets the chains whose backends canonicalize account addresses, from the next load on.
+// Without it addresses are keyed by their detected canonical form.
+func (mgr *AccountManager) SetChainInfoManager(chainInfoMgr *ChainInfoManager) {
+	mgr.mutex.Lock()
+	mgr.chainInfoMgr = chainInfoMgr
+	mgr.mutex.Unlock()
+}
+
+// HasAddress reports whether address is an account on any chain.
 func (mgr *AccountManager) HasAddress(address string) bool {
 	mgr.mutex.RLock()
-	_, ok := mgr.addressCidAccounts[strings.ToLower(strings.TrimSpace(address))]
-	mgr.mutex.RUnlock()
-	return ok
+	defer mgr.mutex.RUnlock()
+	for cid, backend := range mgr.cidBackends {
+		if _, ok := mgr.cidAddressAccounts[cid][CanonicalAddress(backend, address)]; ok {
+			return true
+		}
+	}
+	return false
 }
 
 func (mgr *AccountManager) GetAddresses(cid int64) []string {
@@ -64,7 +82,7 @@ func (mgr *AccountManager) GetAddresses(cid int64) []string {
 func (mgr *AccountManager) GetAccountByAddressCid(address string, cid int64) (*Account, bool) {
 	mgr.mutex.RLock()
 	defer mgr.mutex.RUnlock()
-	accs, ok := mgr.addressCidAccounts[strings.ToLower(strings.TrimSpace(address))]
+	accs, ok := mgr.addressCidAccounts[CanonicalAddress(mgr.cidBackends[cid], address)]
 	if ok {
 		acc, ok := accs[cid]
 		return acc, ok
@@ -73,20 +91,23 @@ func (mgr *AccountManager) GetAccountByAddressCid(address string, cid int64) (*A
 }
 
 func (mgr *AccountManager) LoadAllAccounts() {
+	mgr.Load()
+}
+
+// Load reloads t_account and returns the number of accounts loaded.
+func (mgr *AccountManager) Load() (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT id, chain_id, address FROM t_account")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_account error", err)
-		return
+		return 0, fmt.Errorf("select t_account: %w", err)
 	}
 
 	defer rows.Close()
 
-	idAccounts := make(map[int64]*Account)
-	addressCidAccounts := make(map[string]map[int64]*Account)
-	cidAddressAccounts := make(map[int64]map[string]*Account)
-	counter := 0
+	accounts := make([]*Account, 0)
 
 	// Iterate over the result set
 	for rows.Next() {
@@ -95,37 +116,82 @@ func (mgr *AccountManager) LoadAllAccounts() {
 			mgr.alerter.AlertText("scan t_account row error", err)
 		} else {
 			acc.Address = strings.TrimSpace(acc.Address)
-
-			idAccounts[acc.Id] = &acc
-			lowerAddr := strings.ToLower(acc.Address)
-
-			accs, ok := addressCidAccounts[lowerAddr]
-			if !ok {
-				accs = make(map[int64]*Account)
-				addressCidAccounts[lowerAddr] = accs
-			}
-			accs[acc.ChainInfoId] = &acc
-
-			addraccs, ok := cidAddressAccounts[acc.ChainInfoId]
-			if !ok {
-				addraccs = make(map[string]*Account)
-				cidAddressAccounts[acc.ChainInfoId] = addraccs
-			}
-			addraccs[lowerAddr] = &acc
-
-			counter++
+			accounts = append(accounts, &acc)
 		}
 	}
 
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_account row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_account: %w", err)
+	}
+
+	mgr.setAccounts(accounts)
+	return len(accounts), nil
+}
+
+// setAccounts swaps the account indexes. Addresses are keyed by the canonical form of the backend of their chain,
+// which is kept by chain to look them up the same way.
+func (mgr *AccountManager) setAccounts(accounts []*Account) {
+	mgr.mutex.RLock()
+	chainInfoMgr := mgr.chainInfoMgr
+	mgr.mutex.RUnlock()
+
+	idAccounts := make(map[int64]*Account)
+	addressCidAccounts := make(map[string]map[int64]*Account)
+	cidAddressAccounts := make(map[int64]map[string]*Account)
+	cidBackends := make(map[int64]Backend)
+	for _, acc := range accounts {
+		idAccounts[acc.Id] = acc
+		backend, ok := cidBackends[acc.ChainInfoId]
+		if !ok {
+			backend = backendById(chainInfoMgr, acc.ChainInfoId)
+			cidBackends[acc.ChainInfoId] = backend
+		}
+		canonicalAddr := CanonicalAddress(backend, acc.Address)
+
+		accs, ok := addressCidAccounts[canonicalAddr]
+		if !ok {
+			accs = make(map[int64]*Account)
+			addressCidAccounts[canonicalAddr] = accs
+		}
+		accs[acc.ChainInfoId] = acc
+
+		addraccs, ok := cidAddressAccounts[acc.ChainInfoId]
+		if !ok {
+			addraccs = make(map[string]*Account)
+			cidAddressAccounts[acc.ChainInfoId] = addraccs
+		}
+		addraccs[canonicalAddr] = acc
 	}
 
 	mgr.mutex.Lock()
 	mgr.idAccounts = idAccounts
 	mgr.addressCidAccounts = addressCidAccounts
 	mgr.cidAddressAccounts = cidAddressAccounts
+	mgr.cidBackends = cidBackends
 	mgr.mutex.Unlock()
 }
+
+func (mgr *AccountManager) SnapshotName() string {
+	return "account"
+}
+
+func (mgr *AccountManager) ExportSnapshot() (json.RawMessage, error) {
+	mgr.mutex.RLock()
+	accounts := make([]*Account, 0, len(mgr.idAccounts))
+	for _, acc := range mgr.idAccounts {
+		accounts = append(accounts, acc)
+	}
+	mgr.mutex.RUnlock()
+	return exportRows(accounts)
+}
+
+func (mgr *AccountManager) ImportSnapshot(data json.RawMessage) error {
+	accounts, err := importRows[*Account](data)
+	if err != nil {
+		return err
+	}
+	mgr.setAccounts(accounts)
+	return nil
+}
@@ -0,0 +1,134 @@
+package loader
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/xssnick/tonutils-go/address"
+)
+
+// CanonicalAddress returns the form two spellings of the same address share, to key maps by.
+//
+//   - Hex addresses (EVM, zkSync Lite, Fuel) are lower case, Starknet and Sui ones are also zero padded
+//     to 32 bytes, and so is the address of Sui coin types such as 0x2::sui::SUI.
+//   - Bech32 addresses (Bitcoin segwit, Cosmos) are lower case.
+//   - Base58 addresses (Solana, Bitcoin legacy) are case-sensitive and kept as is.
+//   - TON addresses, user-friendly or raw, become the raw workchain:hex form.
+//
+// When backend is 0 the format is detected from the address itself. A 20 bytes hex address is then
+// taken as EVM, any other hex address is zero padded to 32 bytes. Addresses that cannot be parsed
+// are only trimmed, and lower cased for hex backends.
+func CanonicalAddress(backend Backend, addr string) string {
+	addr = strings.TrimSpace(addr)
+	if addr == "" {
+		return addr
+	}
+
+	switch backend {
+	case EthereumBackend, ZksliteBackend, NetworkTypeBfc:
+		return strings.ToLower(addr)
+	case StarknetBackend, SuiBackend, FuelBackend:
+		return canonicalHex(addr, true)
+	case SolanaBackend:
+		return addr
+	case BitcoinBackend, CosmosBackend:
+		return canonicalBech32(addr)
+	case TonBackend:
+		return canonicalTon(addr)
+	}
+
+	switch {
+	case strings.HasPrefix(addr, "0x") || strings.HasPrefix(addr, "0X"):
+		return canonicalHex(addr, len(addr) != 42)
+	case strings.HasPrefix(addr, "0:") || strings.HasPrefix(addr, "-1:") || len(addr) == 48:
+		// User-friendly TON addresses are 48 characters long, longer than base58 keys, and checksummed
+		return canonicalTon(addr)
+	default:
+		return canonicalBech32(addr)
+	}
+}
+
+// AddressKeys returns the keys addr may be indexed under when the backend of the indexed rows is not
+// known: its canonical form for backend, then the detected one when it differs.
+func AddressKeys(backend Backend, addr string) []string {
+	keys := []string{CanonicalAddress(backend, addr)}
+	if backend != 0 {
+		if detected := CanonicalAddress(0, addr); detected != keys[0] {
+			keys = append(keys, detected)
+		}
+	}
+	return keys
+}
+
+// backendById returns the backend of the chain with auto id id, 0 when chainInfoMgr is nil or does not know it.
+func backendById(chainInfoMgr *ChainInfoManager, id int64) Backend {
+	if chainInfoMgr == nil {
+		return 0
+	}
+	if chain, ok := chainInfoMgr.GetChainInfoById(id); ok {
+		return chain.Backend
+	}
+	return 0
+}
+
+// backendByName is backendById for chains referenced by name.
+func backendByName(chainInfoMgr *ChainInfoManager, name string) Backend {
+	if chainInfoMgr == nil {
+		return 0
+	}
+	if chain, ok := chainInfoMgr.GetChainInfoByName(name); ok {
+		return chain.Backend
+	}
+	return 0
+}
+
+// canonicalHex lower cases a 0x hex address and, when pad is set, zero pads it to 32 bytes.
+// The address part of Sui coin types, before the first "::", is canonicalized the same way.
+func canonicalHex(addr string, pad bool) string {
+	rest := ""
+	if i := strings.Index(addr, "::"); i >= 0 {
+		addr, rest = addr[:i], addr[i:]
+	}
+	lower := strings.ToLower(addr)
+	digits := strings.TrimPrefix(lower, "0x")
+	if !pad || len(digits) > 64 || !isHex(digits) {
+		return lower + rest
+	}
+	return "0x" + strings.Repeat("0", 64-len(digits)) + digits + rest
+}
+
+func isHex(s string) bool {
+	if s == "" {
+		return false
+	}
+	_, err := hex.DecodeString(strings.Repeat("0", len(s)%2) + s)
+	return err == nil
+}
+
+// canonicalBech32 lower cases valid bech32 addresses, which are single case, and keeps any other address as is.
+func canonicalBech32(addr string) string {
+	lower := strings.ToLower(addr)
+	if addr != lower && addr != strings.ToUpper(addr) {
+		return addr
+	}
+	if _, _, err := bech32.DecodeNoLimit(addr); err != nil {
+		return addr
+	}
+	return lower
+}
+
+func canonicalTon(addr string) string {
+	var parsed *address.Address
+	var err error
+	if strings.Contains(addr, ":") {
+		parsed, err = address.ParseRawAddr(addr)
+	} else {
+		parsed, err = address.ParseAddr(addr)
+	}
+	if err != nil {
+		return addr
+	}
+	return fmt.Sprintf("%d:%s", parsed.Workchain(), hex.EncodeToString(parsed.Data()))
+}
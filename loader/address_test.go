@@ -0,0 +1,114 @@
+package loader
+
+import (
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+)
+
+func TestCanonicalAddress(t *testing.T) {
+	tonData := make([]byte, 32)
+	tonData[31] = 0xab
+	tonAddr := address.NewAddress(0, 0, tonData)
+	tonRaw := "0:" + "00000000000000000000000000000000000000000000000000000000000000ab"
+
+	same := []struct {
+		backend Backend
+		a, b    string
+	}{
+		{EthereumBackend, "0xAbCd000000000000000000000000000000000001", " 0xabcd000000000000000000000000000000000001"},
+		{ZksliteBackend, "0xABCD000000000000000000000000000000000001", "0xabcd000000000000000000000000000000000001"},
+		{StarknetBackend, "0x49D36570D4E46F48E99674BD3FCC84644DDD6B96F7C741B1562B82F9E004DC7", "0x049d36570d4e46f48e99674bd3fcc84644ddd6b96f7c741b1562b82f9e004dc7"},
+		{SuiBackend, "0x2", "0x0000000000000000000000000000000000000000000000000000000000000002"},
+		{SuiBackend, "0x2::sui::SUI", "0x0000000000000000000000000000000000000000000000000000000000000002::sui::SUI"},
+		{FuelBackend, "0xABCDEF", "0x0000000000000000000000000000000000000000000000000000000000abcdef"},
+		{BitcoinBackend, "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+		{TonBackend, tonAddr.String(), tonRaw},
+		{TonBackend, tonAddr.Bounce(false).String(), tonRaw},
+		{0, "0xAbCd000000000000000000000000000000000001", "0xabcd000000000000000000000000000000000001"},
+		{0, "0x49d36570d4e46f48e99674bd3fcc84644ddd6b96f7c741b1562b82f9e004dc7", "0x049D36570D4E46F48E99674BD3FCC84644DDD6B96F7C741B1562B82F9E004DC7"},
+		{0, tonAddr.String(), tonRaw},
+		{0, "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"},
+	}
+	for _, c := range same {
+		if ca, cb := CanonicalAddress(c.backend, c.a), CanonicalAddress(c.backend, c.b); ca != cb {
+			t.Errorf("backend %d: %q and %q should match, got %q and %q", c.backend, c.a, c.b, ca, cb)
+		}
+	}
+
+	different := []struct {
+		backend Backend
+		a, b    string
+	}{
+		{SolanaBackend, "So11111111111111111111111111111111111111112", "so11111111111111111111111111111111111111112"},
+		{0, "So11111111111111111111111111111111111111112", "so11111111111111111111111111111111111111112"},
+		{BitcoinBackend, "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", "1bvbmseystwetqtfn5au4m4gfg7xjanvn2"},
+	}
+	for _, c := range different {
+		if ca, cb := CanonicalAddress(c.backend, c.a), CanonicalAddress(c.backend, c.b); ca == cb {
+			t.Errorf("backend %d: %q and %q must not collide on %q", c.backend, c.a, c.b, ca)
+		}
+	}
+}
+
+func TestManagersIndexCanonicalAddresses(t *testing.T) {
+	blacklistMgr := NewBlacklistAddressManager(nil, nil)
+	blacklistMgr.setBlacklists([]*BlacklistAddress{
+		{Id: 1, Address: "So11111111111111111111111111111111111111112", Status: 1},
+		{Id: 2, Address: "0x49d36570d4e46f48e99674bd3fcc84644ddd6b96f7c741b1562b82f9e004dc7", Status: 1},
+	})
+	if blacklistMgr.IsBlacklisted("so11111111111111111111111111111111111111112") {
+		t.Fatal("solana keys differing in case must not match")
+	}
+	if !blacklistMgr.IsBlacklisted("0x049D36570D4E46F48E99674BD3FCC84644DDD6B96F7C741B1562B82F9E004DC7") {
+		t.Fatal("padded starknet address should match")
+	}
+
+	accountMgr := NewAccountManager(nil, nil)
+	accountMgr.setAccounts([]*Account{{Id: 1, ChainInfoId: 3, Address: "0xAbCd000000000000000000000000000000000001"}})
+	if _, ok := accountMgr.GetAccountByAddressCid("0xabcd000000000000000000000000000000000001", 3); !ok {
+		t.Fatal("evm account lookup should ignore case")
+	}
+
+	tokenInfoMgr := NewTokenInfoManager(nil, nil)
+	tokenInfoMgr.setTokens([]*TokenInfo{{Id: 1, TokenName: "SUI", ChainName: "Sui", ChainId: 101, TokenAddress: "0x2::sui::SUI"}})
+	if _, ok := tokenInfoMgr.GetByChainIdTokenAddr(101, "0x0000000000000000000000000000000000000000000000000000000000000002::sui::SUI"); !ok {
+		t.Fatal("padded sui coin type should match")
+	}
+}
+
+func TestManagersKeyAddressesByChainBackend(t *testing.T) {
+	chainInfoMgr := NewChainInfoManager(nil, nil)
+	chainInfoMgr.withoutClients = true
+	chainInfoMgr.setChains([]*ChainInfo{{Id: 5, ChainId: "SN_MAIN", Name: "Starknet", Backend: StarknetBackend}})
+	// 20 bytes, which is detected as an evm address and left unpadded without the chain backend.
+	short, padded := "0x49d36570d4e46f48e99674bd3fcc84644ddd6b96", "0x00000000000000000000000049d36570d4e46f48e99674bd3fcc84644ddd6b96"
+
+	accountMgr := NewAccountManager(nil, nil)
+	accountMgr.SetChainInfoManager(chainInfoMgr)
+	accountMgr.setAccounts([]*Account{{Id: 1, ChainInfoId: 5, Address: short}})
+	if _, ok := accountMgr.GetAccountByAddressCid(padded, 5); !ok || !accountMgr.HasAddress(padded) {
+		t.Fatal("starknet account lookup should pad the address")
+	}
+
+	tokenInfoMgr := NewTokenInfoManager(nil, nil)
+	tokenInfoMgr.chainInfoMgr = chainInfoMgr
+	tokenInfoMgr.setTokens([]*TokenInfo{{Id: 1, TokenName: "USDC", ChainName: "Starknet", ChainId: 5, TokenAddress: short}})
+	if _, ok := tokenInfoMgr.GetByChainNameTokenAddr("starknet", padded); !ok {
+		t.Fatal("starknet token lookup by chain name should pad the address")
+	}
+	if _, ok := tokenInfoMgr.GetByChainIdTokenAddr(5, padded); !ok {
+		t.Fatal("starknet token lookup by chain id should pad the address")
+	}
+
+	blacklistMgr := NewBlacklistAddressManager(nil, nil)
+	blacklistMgr.setBlacklists([]*BlacklistAddress{{Id: 1, Address: padded, Status: 1}})
+	if !blacklistMgr.IsBlacklistedByBackend(StarknetBackend, short) || blacklistMgr.IsBlacklisted(short) {
+		t.Fatal("only the starknet backend should pad the short address")
+	}
+
+	makers := map[Backend]map[string]bool{StarknetBackend: {padded: true}}
+	if !hasMakerAddress(makers, short) || hasMakerAddress(makers, "0x49d36570d4e46f48e99674bd3fcc84644ddd6b97") {
+		t.Fatal("maker addresses should be compared in the form of their backend")
+	}
+}
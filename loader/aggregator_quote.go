@@ -0,0 +1,306 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/owlto-dao/utils-go/network"
+)
+
+// AggregatorQuoteRequest asks an aggregator the price of moving Amount over Route.
+type AggregatorQuoteRequest struct {
+	Route      *RouteConfig
+	Amount     *big.Int // Smallest units of the source token
+	Decimals   int32    // Decimals of the source token
+	ToDecimals int32    // Decimals of the destination token
+}
+
+// AggregatorQuote is the live price of an aggregator.
+type AggregatorQuote struct {
+	// ChannelFee is everything the aggregator keeps, in smallest units of the source token. It is nil when the
+	// route changes token and the aggregator does not price its fee, the output alone does not tell it.
+	ChannelFee   *big.Int
+	OutputAmount *big.Int // Received on the destination chain, in smallest units of the destination token
+	ETA          time.Duration
+}
+
+// AggregatorQuoteClient fetches live quotes from an aggregator API rooted at baseURL,
+// the AggregatorConfig.APIBaseURL of the aggregator.
+type AggregatorQuoteClient interface {
+	Quote(ctx context.Context, baseURL string, req *AggregatorQuoteRequest) (*AggregatorQuote, error)
+}
+
+// DefaultQuoteClients returns the quote clients of the aggregators with a public quote API.
+func DefaultQuoteClients() map[AggregatorID]AggregatorQuoteClient {
+	return map[AggregatorID]AggregatorQuoteClient{
+		AggregatorAcross:   &AcrossQuoteClient{},
+		AggregatorRelay:    &RelayQuoteClient{},
+		AggregatorDebridge: &DebridgeQuoteClient{},
+	}
+}
+
+// AcrossQuoteClient quotes with the Across suggested-fees API.
+type AcrossQuoteClient struct{}
+
+type acrossSuggestedFees struct {
+	TotalRelayFee struct {
+		Total string `json:"total"`
+	} `json:"totalRelayFee"`
+	OutputAmount         string `json:"outputAmount"`
+	EstimatedFillTimeSec int64  `json:"estimatedFillTimeSec"`
+}
+
+func (c *AcrossQuoteClient) Quote(ctx context.Context, baseURL string, req *AggregatorQuoteRequest) (*AggregatorQuote, error) {
+	query := url.Values{}
+	query.Set("inputToken", req.Route.FromTokenAddress)
+	query.Set("outputToken", req.Route.ToTokenAddress)
+	query.Set("originChainId", strconv.FormatInt(req.Route.FromChainID, 10))
+	query.Set("destinationChainId", strconv.FormatInt(req.Route.ToChainID, 10))
+	query.Set("amount", req.Amount.String())
+
+	var resp acrossSuggestedFees
+	if err := network.DoRequestWithContext(ctx, joinURL(baseURL, "/api/suggested-fees")+"?"+query.Encode(), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	fee, ok := new(big.Int).SetString(resp.TotalRelayFee.Total, 10)
+	if !ok {
+		return nil, fmt.Errorf("across total relay fee %q is not an integer", resp.TotalRelayFee.Total)
+	}
+	var output *big.Int
+	switch {
+	case resp.OutputAmount != "":
+		if output, ok = new(big.Int).SetString(resp.OutputAmount, 10); !ok {
+			return nil, fmt.Errorf("across output amount %q is not an integer", resp.OutputAmount)
+		}
+	case isSameTokenRoute(req.Route):
+		output = convertDecimals(new(big.Int).Sub(req.Amount, fee), req.Decimals, req.ToDecimals, false)
+	default:
+		return nil, fmt.Errorf("across quote without output amount for route %d", req.Route.ID)
+	}
+	return &AggregatorQuote{ChannelFee: fee, OutputAmount: output, ETA: time.Duration(resp.EstimatedFillTimeSec) * time.Second}, nil
+}
+
+// RelayQuoteClient quotes with the Relay quote API.
+type RelayQuoteClient struct {
+	User string // Address the quotes are requested for, the zero address by default
+}
+
+type relayQuoteRequest struct {
+	User                string `json:"user"`
+	OriginChainId       int64  `json:"originChainId"`
+	DestinationChainId  int64  `json:"destinationChainId"`
+	OriginCurrency      string `json:"originCurrency"`
+	DestinationCurrency string `json:"destinationCurrency"`
+	Amount              string `json:"amount"`
+	TradeType           string `json:"tradeType"`
+}
+
+type relayAmount struct {
+	Currency struct {
+		Decimals int32 `json:"decimals"`
+	} `json:"currency"`
+	Amount string `json:"amount"`
+}
+
+type relayQuote struct {
+	Details struct {
+		CurrencyIn   relayAmount `json:"currencyIn"`
+		CurrencyOut  relayAmount `json:"currencyOut"`
+		TimeEstimate int64       `json:"timeEstimate"`
+	} `json:"details"`
+}
+
+func (c *RelayQuoteClient) Quote(ctx context.Context, baseURL string, req *AggregatorQuoteRequest) (*AggregatorQuote, error) {
+	user := c.User
+	if user == "" {
+		user = "0x0000000000000000000000000000000000000000"
+	}
+	body := relayQuoteRequest{
+		User:                user,
+		OriginChainId:       req.Route.FromChainID,
+		DestinationChainId:  req.Route.ToChainID,
+		OriginCurrency:      req.Route.FromTokenAddress,
+		DestinationCurrency: req.Route.ToTokenAddress,
+		Amount:              req.Amount.String(),
+		TradeType:           "EXACT_INPUT",
+	}
+
+	var resp relayQuote
+	if err := network.DoRequestWithContext(ctx, joinURL(baseURL, "/quote"), nil, body, &resp); err != nil {
+		return nil, err
+	}
+	output, ok := new(big.Int).SetString(resp.Details.CurrencyOut.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("relay output amount %q is not an integer", resp.Details.CurrencyOut.Amount)
+	}
+	output = convertDecimals(output, resp.Details.CurrencyOut.Currency.Decimals, req.ToDecimals, false)
+	return &AggregatorQuote{
+		ChannelFee:   channelFeeOf(req, output),
+		OutputAmount: output,
+		ETA:          time.Duration(resp.Details.TimeEstimate) * time.Second,
+	}, nil
+}
+
+// DebridgeQuoteClient quotes with the deBridge DLN order quote API.
+type DebridgeQuoteClient struct{}
+
+type debridgeQuote struct {
+	Estimation struct {
+		DstChainTokenOut struct {
+			Decimals int32  `json:"decimals"`
+			Amount   string `json:"amount"`
+		} `json:"dstChainTokenOut"`
+	} `json:"estimation"`
+	Order struct {
+		ApproximateFulfillmentDelay int64 `json:"approximateFulfillmentDelay"`
+	} `json:"order"`
+}
+
+func (c *DebridgeQuoteClient) Quote(ctx context.Context, baseURL string, req *AggregatorQuoteRequest) (*AggregatorQuote, error) {
+	query := url.Values{}
+	query.Set("srcChainId", strconv.FormatInt(req.Route.FromChainID, 10))
+	query.Set("srcChainTokenIn", req.Route.FromTokenAddress)
+	query.Set("srcChainTokenInAmount", req.Amount.String())
+	query.Set("dstChainId", strconv.FormatInt(req.Route.ToChainID, 10))
+	query.Set("dstChainTokenOut", req.Route.ToTokenAddress)
+	query.Set("prependOperatingExpenses", "false")
+
+	var resp debridgeQuote
+	if err := network.DoRequestWithContext(ctx, joinURL(baseURL, "/v1.0/dln/order/quote")+"?"+query.Encode(), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	output, ok := new(big.Int).SetString(resp.Estimation.DstChainTokenOut.Amount, 10)
+	if !ok {
+		return nil, fmt.Errorf("debridge output amount %q is not an integer", resp.Estimation.DstChainTokenOut.Amount)
+	}
+	output = convertDecimals(output, resp.Estimation.DstChainTokenOut.Decimals, req.ToDecimals, false)
+	return &AggregatorQuote{
+		ChannelFee:   channelFeeOf(req, output),
+		OutputAmount: output,
+		ETA:          time.Duration(resp.Order.ApproximateFulfillmentDelay) * time.Second,
+	}, nil
+}
+
+// channelFeeOf returns what the aggregator keeps when it delivers output for req, nil when the route changes token.
+func channelFeeOf(req *AggregatorQuoteRequest, output *big.Int) *big.Int {
+	if !isSameTokenRoute(req.Route) {
+		return nil
+	}
+	return new(big.Int).Sub(req.Amount, convertDecimals(output, req.ToDecimals, req.Decimals, false))
+}
+
+func isSameTokenRoute(route *RouteConfig) bool {
+	edge := routeEdge(route)
+	return edge.From.TokenSymbol == edge.To.TokenSymbol
+}
+
+func joinURL(baseURL string, path string) string {
+	return strings.TrimRight(baseURL, "/") + path
+}
+
+type aggregatorQuoteKey struct {
+	aggregator AggregatorID
+	routeID    int64
+	amount     string
+	toDecimals int32 // The output amount is in these decimals
+}
+
+type cachedAggregatorQuote struct {
+	quote     *AggregatorQuote
+	err       error // Set for failed quotes, cached for a shorter time
+	expiresAt time.Time
+}
+
+// maxCachedAggregatorQuotes bounds the quote cache, expired quotes are purged when it is reached.
+const maxCachedAggregatorQuotes = 1024
+
+// SetQuoteClient sets the live quote client of an aggregator, nil removes it.
+// Routes of aggregators without client are priced from their fee segments only.
+func (mgr *AggregatorManager) SetQuoteClient(aggregateID AggregatorID, client AggregatorQuoteClient) {
+	mgr.quoteMutex.Lock()
+	defer mgr.quoteMutex.Unlock()
+	if client == nil {
+		delete(mgr.quoteClients, aggregateID)
+	} else {
+		mgr.quoteClients[aggregateID] = client
+	}
+}
+
+// SetQuoteOptions sets the timeout of a live quote, 3s by default, and how long quotes are cached, 15s by default.
+// Failed quotes are cached for a third of cacheTTL, not to query a failing aggregator on every request.
+func (mgr *AggregatorManager) SetQuoteOptions(timeout time.Duration, cacheTTL time.Duration) {
+	mgr.quoteMutex.Lock()
+	defer mgr.quoteMutex.Unlock()
+	mgr.quoteTimeout = timeout
+	mgr.quoteCacheTTL = cacheTTL
+}
+
+// liveQuote returns the live quote of route, from the cache when fresh, failed quotes included. It returns nil
+// without error when the aggregator has no client or no API base url.
+func (mgr *AggregatorManager) liveQuote(ctx context.Context, route *RouteConfig, amount *big.Int, decimals int32, toDecimals int32) (*AggregatorQuote, error) {
+	mgr.mutex.RLock()
+	config := mgr.aggregatorConfigs[route.AggregateID]
+	mgr.mutex.RUnlock()
+	if config == nil || !config.IsEnabled || config.APIBaseURL == "" {
+		return nil, nil
+	}
+
+	key := aggregatorQuoteKey{aggregator: route.AggregateID, routeID: route.ID, amount: amount.String(), toDecimals: toDecimals}
+	mgr.quoteMutex.Lock()
+	client := mgr.quoteClients[route.AggregateID]
+	timeout, cacheTTL := mgr.quoteTimeout, mgr.quoteCacheTTL
+	cached, ok := mgr.quoteCache[key]
+	mgr.quoteMutex.Unlock()
+	if client == nil {
+		return nil, nil
+	}
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.quote, cached.err
+	}
+
+	quoteCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	quote, err := client.Quote(quoteCtx, config.APIBaseURL, &AggregatorQuoteRequest{Route: route, Amount: amount, Decimals: decimals, ToDecimals: toDecimals})
+	switch {
+	case err != nil:
+		err = fmt.Errorf("%s quote of route %d: %w", route.AggregateID, route.ID, err)
+	case quote.ChannelFee != nil && quote.ChannelFee.Sign() < 0:
+		err = fmt.Errorf("%s quote of route %d: invalid channel fee %v", route.AggregateID, route.ID, quote.ChannelFee)
+	case quote.OutputAmount == nil || quote.OutputAmount.Sign() < 0:
+		err = fmt.Errorf("%s quote of route %d: invalid output amount %v", route.AggregateID, route.ID, quote.OutputAmount)
+	}
+	if err != nil {
+		if ctx.Err() == nil {
+			// A cancelled request says nothing of the aggregator
+			mgr.cacheQuote(key, cachedAggregatorQuote{err: err, expiresAt: time.Now().Add(cacheTTL / 3)})
+		}
+		return nil, err
+	}
+	mgr.cacheQuote(key, cachedAggregatorQuote{quote: quote, expiresAt: time.Now().Add(cacheTTL)})
+	return quote, nil
+}
+
+// cacheQuote caches a quote until it expires, purging the expired quotes when the cache is full.
+func (mgr *AggregatorManager) cacheQuote(key aggregatorQuoteKey, cached cachedAggregatorQuote) {
+	now := time.Now()
+	if !now.Before(cached.expiresAt) {
+		return
+	}
+	mgr.quoteMutex.Lock()
+	defer mgr.quoteMutex.Unlock()
+	if len(mgr.quoteCache) >= maxCachedAggregatorQuotes {
+		for k, v := range mgr.quoteCache {
+			if now.After(v.expiresAt) {
+				delete(mgr.quoteCache, k)
+			}
+		}
+	}
+	if len(mgr.quoteCache) < maxCachedAggregatorQuotes {
+		mgr.quoteCache[key] = cached
+	}
+}
@@ -0,0 +1,109 @@
+package loader
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+func TestFindBestRouteWithLiveQuotes(t *testing.T) {
+	var acrossHits, debridgeHits int32
+	across := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&acrossHits, 1)
+		if r.URL.Path != "/api/suggested-fees" || r.URL.Query().Get("amount") != "100000000" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"totalRelayFee": {"total": "100000"}, "estimatedFillTimeSec": 4}`))
+	}))
+	defer across.Close()
+	relay := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"details": {
+			"currencyIn": {"currency": {"decimals": 6}, "amount": "100000000"},
+			"currencyOut": {"currency": {"decimals": 6}, "amount": "99000000"},
+			"timeEstimate": 2}}`))
+	}))
+	defer relay.Close()
+	debridge := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&debridgeHits, 1)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer debridge.Close()
+
+	snapshot := aggregatorSnapshot{
+		AggregatorConfigs: []*AggregatorConfig{
+			{ID: AggregatorAcross, Name: "across", IsEnabled: true, APIBaseURL: across.URL},
+			{ID: AggregatorRelay, Name: "relay", IsEnabled: true, APIBaseURL: relay.URL},
+			{ID: AggregatorDebridge, Name: "debridge", IsEnabled: true, APIBaseURL: debridge.URL},
+		},
+		Routes: []*RouteConfig{
+			{ID: 1, AggregateID: AggregatorAcross, FromChainID: 42161, ToChainID: 8453, FromTokenSymbol: "USDC", IsEnabled: true},
+			{ID: 2, AggregateID: AggregatorRelay, FromChainID: 42161, ToChainID: 8453, FromTokenSymbol: "USDC", IsEnabled: true},
+			{ID: 3, AggregateID: AggregatorDebridge, FromChainID: 42161, ToChainID: 8453, FromTokenSymbol: "USDC", IsEnabled: true},
+		},
+		FeeSegments: []*FeeSegment{
+			{ID: 1, RouteID: 1, MinAmountUI: "0", MaxAmountUI: "0", OwltoFeeFixedUI: "0.5", IsEnabled: true},
+			{ID: 2, RouteID: 2, MinAmountUI: "0", MaxAmountUI: "0", OwltoFeeFixedUI: "0.5", IsEnabled: true},
+			{ID: 3, RouteID: 3, MinAmountUI: "0", MaxAmountUI: "0", OwltoFeeFixedUI: "0.4", IsEnabled: true},
+		},
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := NewAggregatorManager(nil, alert.NewCommonAlerter(0, 0))
+	if err := mgr.ImportSnapshot(data); err != nil {
+		t.Fatal(err)
+	}
+	for id, client := range DefaultQuoteClients() {
+		mgr.SetQuoteClient(id, client)
+	}
+	mgr.SetQuoteOptions(time.Second, time.Minute)
+
+	// Across: 0.1 live + 0.5 owlto, relay: 1 live + 0.5 owlto, debridge falls back to 0.4 + 0.4 and ranks last.
+	for i := 0; i < 2; i++ {
+		best, err := mgr.FindBestRoute(42161, 8453, "USDC", "100", 6)
+		if err != nil {
+			t.Fatalf("FindBestRoute: %v", err)
+		}
+		if best.Aggregator != AggregatorAcross || !best.FeeResult.IsLiveQuote || best.FeeResult.TotalGasFeeUI != "0.600000" || best.FeeResult.ETA != 4*time.Second {
+			t.Fatalf("unexpected best route: %s %+v", best.Aggregator, best.FeeResult)
+		}
+		if last := best.Alternatives[len(best.Alternatives)-1]; last.Aggregator != AggregatorDebridge || last.FeeResult.LiveQuoteErr == nil {
+			t.Fatalf("the failed debridge quote should be marked and ranked last: %s %+v", last.Aggregator, last.FeeResult)
+		}
+	}
+	if hits := atomic.LoadInt32(&acrossHits); hits != 1 {
+		t.Fatalf("across quoted %d times, want 1 with caching", hits)
+	}
+	if hits := atomic.LoadInt32(&debridgeHits); hits != 1 {
+		t.Fatalf("debridge quoted %d times, want 1 with the failure cached", hits)
+	}
+
+	mgr.SetQuoteClient(AggregatorAcross, nil)
+	best, err := mgr.FindBestRoute(42161, 8453, "USDC", "100", 6)
+	if err != nil {
+		t.Fatalf("FindBestRoute: %v", err)
+	}
+	// Across without client is priced from its 0.5 fee segment placeholder, the failed debridge quote still ranks last.
+	if best.Aggregator != AggregatorAcross || best.FeeResult.TotalGasFeeUI != "1.000000" || len(best.Alternatives) != 2 ||
+		best.Alternatives[0].Aggregator != AggregatorRelay || best.Alternatives[1].Aggregator != AggregatorDebridge {
+		t.Fatalf("the live relay quote should beat the failed debridge one: %s %+v", best.Aggregator, best.FeeResult)
+	}
+
+	// Without any live quote the fee segments decide.
+	mgr.SetQuoteClient(AggregatorRelay, nil)
+	mgr.SetQuoteClient(AggregatorDebridge, nil)
+	best, err = mgr.FindBestRoute(42161, 8453, "USDC", "100", 6)
+	if err != nil {
+		t.Fatalf("FindBestRoute: %v", err)
+	}
+	if best.Aggregator != AggregatorDebridge || best.FeeResult.IsLiveQuote || best.FeeResult.LiveQuoteErr != nil || best.FeeResult.TotalGasFeeUI != "0.800000" {
+		t.Fatalf("unexpected fallback route: %s %+v", best.Aggregator, best.FeeResult)
+	}
+}
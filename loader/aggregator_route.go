@@ -1,7 +1,9 @@
 package loader
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"sort"
@@ -10,6 +12,9 @@ import (
 	"time"
 
 	"github.com/owlto-dao/utils-go/alert"
+	"github.com/owlto-dao/utils-go/log"
+	"github.com/owlto-dao/utils-go/task"
+	"github.com/owlto-dao/utils-go/telemetry"
 )
 
 // AggregatorID identifies an aggregator.
@@ -92,15 +97,19 @@ type FeeSegment struct {
 
 // FeeResult stores the computed fee result.
 type FeeResult struct {
-	BestAggregator     AggregatorID // Best aggregator
-	ChannelFee         *big.Int     // Protocol fee in smallest unit
-	OwltoFee           *big.Int     // Owlto surcharge in smallest unit
-	TotalGasFee        *big.Int     // Total gas fee = ChannelFee + OwltoFee
-	ProtocolFee        *big.Int     // Protocol fee amount, for example Across 0.1%
-	ChannelFeeUI       string       // Protocol fee in UI amount
-	OwltoFeeUI         string       // Owlto surcharge in UI amount
-	TotalGasFeeUI      string       // Total fee in UI amount
-	ProtocolFeeRateBps int          // Protocol fee rate in basis points
+	BestAggregator     AggregatorID  // Best aggregator
+	ChannelFee         *big.Int      // Protocol fee in smallest unit
+	OwltoFee           *big.Int      // Owlto surcharge in smallest unit
+	TotalGasFee        *big.Int      // Total gas fee = ChannelFee + OwltoFee
+	ProtocolFee        *big.Int      // Protocol fee amount, for example Across 0.1%
+	ChannelFeeUI       string        // Protocol fee in UI amount
+	OwltoFeeUI         string        // Owlto surcharge in UI amount
+	TotalGasFeeUI      string        // Total fee in UI amount
+	ProtocolFeeRateBps int           // Protocol fee rate in basis points
+	ETA                time.Duration // Estimated fill time of the aggregator, 0 when unknown
+	IsLiveQuote        bool          // Whether ChannelFee comes from the aggregator API instead of the fee segment
+	LiveQuoteErr       error         // Why the live quote failed, the fees are then the fee segment placeholder
+	OutputAmount       *big.Int      // Received amount of the live quote in smallest units of the destination token
 }
 
 // RouteQueryResult stores the route query result.
@@ -122,7 +131,10 @@ type BestRouteResult struct {
 	Route        *RouteConfig
 	FeeSegment   *FeeSegment
 	FeeResult    *FeeResult
-	IsAggregator bool // Whether the route uses an aggregator instead of Owlto Native
+	IsAggregator bool   // Whether the route uses an aggregator instead of Owlto Native
+	NativeQuote  *Quote // Quote of the Owlto Native route, nil for aggregator routes
+	// Alternatives are the other priced routes, cheapest first. Only set on the best route.
+	Alternatives []*BestRouteResult
 }
 
 // AggregatorManager manages all aggregator-related configs in memory.
@@ -142,12 +154,24 @@ type AggregatorManager struct {
 
 	// Infrastructure
 	db      *sql.DB
+	dialect Dialect
 	alerter alert.Alerter
 	mutex   *sync.RWMutex
 
 	// Periodic refresh
 	refreshInterval time.Duration
 	stopCh          chan struct{}
+	doneCh          chan struct{} // Closed when the refresh loop of Start has exited
+
+	// Owlto Native routes compared against the aggregator routes
+	native *nativeRoutes
+
+	// Live aggregator quotes
+	quoteClients  map[AggregatorID]AggregatorQuoteClient
+	quoteTimeout  time.Duration
+	quoteCacheTTL time.Duration
+	quoteCache    map[aggregatorQuoteKey]cachedAggregatorQuote
+	quoteMutex    *sync.Mutex
 }
 
 func NewAggregatorManager(db *sql.DB, alerter alert.Alerter) *AggregatorManager {
@@ -160,42 +184,149 @@ func NewAggregatorManager(db *sql.DB, alerter alert.Alerter) *AggregatorManager
 		routesByID:           make(map[int64]*RouteConfig),
 		feeSegmentsByRouteID: make(map[int64][]*FeeSegment),
 		db:                   db,
+		dialect:              MySQLDialect,
 		alerter:              alerter,
 		mutex:                new(sync.RWMutex),
 		refreshInterval:      5 * time.Minute,
+		quoteClients:         make(map[AggregatorID]AggregatorQuoteClient),
+		quoteTimeout:         3 * time.Second,
+		quoteCacheTTL:        15 * time.Second,
+		quoteCache:           make(map[aggregatorQuoteKey]cachedAggregatorQuote),
+		quoteMutex:           new(sync.Mutex),
 	}
 }
 
-// LoadAll loads all configs into memory.
+// SetDialect sets the SQL dialect of db, MySQL by default.
+func (mgr *AggregatorManager) SetDialect(dialect Dialect) {
+	mgr.mutex.Lock()
+	mgr.dialect = dialect
+	mgr.mutex.Unlock()
+}
+
+func (mgr *AggregatorManager) getDialect() Dialect {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	return mgr.dialect
+}
+
+// LoadAll loads all configs into memory. The four tables are read first and swapped in at once,
+// so on any error the previous configs are kept untouched. The loaded counts and failures are recorded in telemetry.
 func (mgr *AggregatorManager) LoadAll() error {
-	if err := mgr.loadAggregatorConfigs(); err != nil {
-		return fmt.Errorf("load aggregator configs: %w", err)
+	var state aggregatorSnapshot
+	var err error
+	if state.AggregatorConfigs, err = mgr.queryAggregatorConfigs(); err != nil {
+		err = fmt.Errorf("load aggregator configs: %w", err)
+	} else if state.ChainConfigs, err = mgr.queryAggregateChainConfigs(); err != nil {
+		err = fmt.Errorf("load aggregate chain configs: %w", err)
+	} else if state.Routes, err = mgr.queryRouteConfigs(); err != nil {
+		err = fmt.Errorf("load route configs: %w", err)
+	} else if state.FeeSegments, err = mgr.queryFeeSegments(); err != nil {
+		err = fmt.Errorf("load fee segments: %w", err)
 	}
-	if err := mgr.loadAggregateChainConfigs(); err != nil {
-		return fmt.Errorf("load aggregate chain configs: %w", err)
+	if err != nil {
+		telemetry.IncrCounter(1, "loader", "aggregator", "failures")
+		mgr.alerter.AlertText("load aggregator routes error, keeping the previous configs", err)
+		return err
 	}
-	if err := mgr.loadRouteConfigs(); err != nil {
-		return fmt.Errorf("load route configs: %w", err)
+
+	mgr.setState(&state)
+	telemetry.SetGauge(float32(len(state.AggregatorConfigs)), "loader", "aggregator", "configs")
+	telemetry.SetGauge(float32(len(state.ChainConfigs)), "loader", "aggregator", "chain_configs")
+	telemetry.SetGauge(float32(len(state.Routes)), "loader", "aggregator", "routes")
+	telemetry.SetGauge(float32(len(state.FeeSegments)), "loader", "aggregator", "fee_segments")
+	telemetry.IncrCounter(1, "loader", "aggregator", "loads")
+	return nil
+}
+
+// Load loads all configs and returns the number of routes loaded.
+func (mgr *AggregatorManager) Load() (int, error) {
+	if err := mgr.LoadAll(); err != nil {
+		return 0, err
 	}
-	if err := mgr.loadFeeSegments(); err != nil {
-		return fmt.Errorf("load fee segments: %w", err)
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	return len(mgr.routesByID), nil
+}
+
+// SetRefreshInterval sets the reload interval of Start, 5 minutes by default.
+func (mgr *AggregatorManager) SetRefreshInterval(interval time.Duration) {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+	mgr.refreshInterval = interval
+}
+
+// Start loads all configs at once, then reloads them every refresh interval until ctx is done or Stop is called.
+// Start does nothing when already started.
+func (mgr *AggregatorManager) Start(ctx context.Context) {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+	if mgr.stopCh != nil {
+		return
+	}
+	stopCh, doneCh := make(chan struct{}), make(chan struct{})
+	mgr.stopCh, mgr.doneCh = stopCh, doneCh
+	interval := mgr.refreshInterval
+
+	task.RunTask(func() {
+		defer close(doneCh)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			mgr.LoadAll()
+			select {
+			case <-ctx.Done():
+				return
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// Stop stops the reloads of Start and waits for an in-flight reload to end. It can be started again afterwards.
+func (mgr *AggregatorManager) Stop() {
+	mgr.mutex.Lock()
+	stopCh, doneCh := mgr.stopCh, mgr.doneCh
+	mgr.stopCh, mgr.doneCh = nil, nil
+	mgr.mutex.Unlock()
+	if stopCh != nil {
+		close(stopCh)
+		<-doneCh
 	}
-	return nil
 }
 
-// loadAggregatorConfigs loads aggregator configs from t_aggregate_config.
-func (mgr *AggregatorManager) loadAggregatorConfigs() error {
-	rows, err := mgr.db.Query(`
+// setState indexes state and swaps every index at once.
+func (mgr *AggregatorManager) setState(state *aggregatorSnapshot) {
+	aggregatorConfigs := indexAggregatorConfigs(state.AggregatorConfigs)
+	chainConfigs, chainConfigsByName := indexAggregateChainConfigs(state.ChainConfigs)
+	routesByChainPair, routesBySymbol, routesByID := indexRouteConfigs(state.Routes)
+	feeSegmentsByRouteID := indexFeeSegments(state.FeeSegments)
+
+	mgr.mutex.Lock()
+	mgr.aggregatorConfigs = aggregatorConfigs
+	mgr.chainConfigs = chainConfigs
+	mgr.chainConfigsByName = chainConfigsByName
+	mgr.routesByChainPair = routesByChainPair
+	mgr.routesBySymbol = routesBySymbol
+	mgr.routesByID = routesByID
+	mgr.feeSegmentsByRouteID = feeSegmentsByRouteID
+	mgr.mutex.Unlock()
+}
+
+// queryAggregatorConfigs reads the aggregator configs of t_aggregate_config.
+func (mgr *AggregatorManager) queryAggregatorConfigs() ([]*AggregatorConfig, error) {
+	rows, err := mgr.db.Query(fmt.Sprintf(`
         SELECT id, name, is_enabled, priority, api_base_url
         FROM t_aggregate_config
-        WHERE is_enabled = 1
-    `)
+        WHERE is_enabled = %s
+    `, mgr.getDialect().True()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
-	newConfigs := make(map[AggregatorID]*AggregatorConfig)
+	configs := make([]*AggregatorConfig, 0)
 	for rows.Next() {
 		var cfg AggregatorConfig
 		var id int
@@ -204,32 +335,29 @@ func (mgr *AggregatorManager) loadAggregatorConfigs() error {
 			continue
 		}
 		cfg.ID = AggregatorID(id)
-		newConfigs[cfg.ID] = &cfg
+		configs = append(configs, &cfg)
 	}
-
-	mgr.mutex.Lock()
-	mgr.aggregatorConfigs = newConfigs
-	mgr.mutex.Unlock()
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return configs, nil
 }
 
-// loadAggregateChainConfigs loads aggregator chain configs from t_aggregate_chain_config.
-func (mgr *AggregatorManager) loadAggregateChainConfigs() error {
-	rows, err := mgr.db.Query(`
+// queryAggregateChainConfigs reads the aggregator chain configs of t_aggregate_chain_config.
+func (mgr *AggregatorManager) queryAggregateChainConfigs() ([]*AggregateChainConfig, error) {
+	rows, err := mgr.db.Query(fmt.Sprintf(`
         SELECT id, aggregate_id, chain_name, chain_id, deposit_contract_address,
                bridge_fee_rate_bps, fill_deadline_seconds, swapper_address,
                is_enabled, priority
         FROM t_aggregate_chain_config
-        WHERE is_enabled = 1
-    `)
+        WHERE is_enabled = %s
+    `, mgr.getDialect().True()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
-	newByAggID := make(map[AggregatorID]map[int64]*AggregateChainConfig)
-	newByAggName := make(map[AggregatorID]map[string]*AggregateChainConfig)
-
+	configs := make([]*AggregateChainConfig, 0)
 	for rows.Next() {
 		var cfg AggregateChainConfig
 		var aggID int
@@ -261,43 +389,30 @@ func (mgr *AggregatorManager) loadAggregateChainConfigs() error {
 			cfg.SwapperAddress = swapperAddress.String
 		}
 
-		if _, ok := newByAggID[cfg.AggregateID]; !ok {
-			newByAggID[cfg.AggregateID] = make(map[int64]*AggregateChainConfig)
-		}
-		newByAggID[cfg.AggregateID][cfg.ChainID] = &cfg
-
-		if _, ok := newByAggName[cfg.AggregateID]; !ok {
-			newByAggName[cfg.AggregateID] = make(map[string]*AggregateChainConfig)
-		}
-		chainNameKey := strings.ToLower(strings.TrimSpace(cfg.ChainName))
-		newByAggName[cfg.AggregateID][chainNameKey] = &cfg
+		configs = append(configs, &cfg)
 	}
 
-	mgr.mutex.Lock()
-	mgr.chainConfigs = newByAggID
-	mgr.chainConfigsByName = newByAggName
-	mgr.mutex.Unlock()
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return configs, nil
 }
 
-// loadRouteConfigs loads routes from t_aggregator_route_config.
-func (mgr *AggregatorManager) loadRouteConfigs() error {
-	rows, err := mgr.db.Query(`
+// queryRouteConfigs reads the routes of t_aggregate_route_config.
+func (mgr *AggregatorManager) queryRouteConfigs() ([]*RouteConfig, error) {
+	rows, err := mgr.db.Query(fmt.Sprintf(`
         SELECT id, aggregate_id, from_chain_id, from_chain_name, from_token_address, from_token_symbol,
                to_chain_id, to_chain_name, to_token_address, to_token_symbol,
                is_native, min_amount, max_amount, is_enabled, priority
         FROM t_aggregate_route_config
-        WHERE is_enabled = 1
-    `)
+        WHERE is_enabled = %s
+    `, mgr.getDialect().True()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
-	newByChainPair := make(map[int64]map[int64][]*RouteConfig)
-	newBySymbol := make(map[string][]*RouteConfig)
-	newByID := make(map[int64]*RouteConfig)
-
+	routes := make([]*RouteConfig, 0)
 	for rows.Next() {
 		var r RouteConfig
 		var aggID int
@@ -310,45 +425,31 @@ func (mgr *AggregatorManager) loadRouteConfigs() error {
 			continue
 		}
 		r.AggregateID = AggregatorID(aggID)
-
-		// Index by chain pair.
-		if _, ok := newByChainPair[r.FromChainID]; !ok {
-			newByChainPair[r.FromChainID] = make(map[int64][]*RouteConfig)
-		}
-		newByChainPair[r.FromChainID][r.ToChainID] = append(newByChainPair[r.FromChainID][r.ToChainID], &r)
-
-		// Index by token symbol.
-		sym := strings.ToLower(r.FromTokenSymbol)
-		newBySymbol[sym] = append(newBySymbol[sym], &r)
-
-		// Index by route ID.
-		newByID[r.ID] = &r
+		routes = append(routes, &r)
 	}
 
-	mgr.mutex.Lock()
-	mgr.routesByChainPair = newByChainPair
-	mgr.routesBySymbol = newBySymbol
-	mgr.routesByID = newByID
-	mgr.mutex.Unlock()
-	return nil
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return routes, nil
 }
 
-// loadFeeSegments loads fee segments from t_aggregator_route_fee_segment.
-func (mgr *AggregatorManager) loadFeeSegments() error {
-	rows, err := mgr.db.Query(`
+// queryFeeSegments reads the fee segments of t_aggregate_route_fee_segment, sorted by route then min amount.
+func (mgr *AggregatorManager) queryFeeSegments() ([]*FeeSegment, error) {
+	rows, err := mgr.db.Query(fmt.Sprintf(`
         SELECT id, route_id, min_amount_ui, max_amount_ui,
                owlto_fee_fixed_ui, owlto_fee_rate_bps, protocol_fee_rate_bps,
                is_enabled, priority
         FROM t_aggregate_route_fee_segment
-        WHERE is_enabled = 1
-        ORDER BY route_id, CAST(min_amount_ui AS DECIMAL(65,18)) ASC
-    `)
+        WHERE is_enabled = %s
+        ORDER BY route_id, %s ASC
+    `, mgr.getDialect().True(), mgr.getDialect().CastDecimal("min_amount_ui")))
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer rows.Close()
 
-	newByRouteID := make(map[int64][]*FeeSegment)
+	segments := make([]*FeeSegment, 0)
 	for rows.Next() {
 		var seg FeeSegment
 		if err := rows.Scan(
@@ -359,12 +460,122 @@ func (mgr *AggregatorManager) loadFeeSegments() error {
 			mgr.alerter.AlertText("scan fee segment error", err)
 			continue
 		}
-		newByRouteID[seg.RouteID] = append(newByRouteID[seg.RouteID], &seg)
+		segments = append(segments, &seg)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
 
-	mgr.mutex.Lock()
-	mgr.feeSegmentsByRouteID = newByRouteID
-	mgr.mutex.Unlock()
+func indexAggregatorConfigs(configs []*AggregatorConfig) map[AggregatorID]*AggregatorConfig {
+	byID := make(map[AggregatorID]*AggregatorConfig)
+	for _, cfg := range configs {
+		byID[cfg.ID] = cfg
+	}
+	return byID
+}
+
+func indexAggregateChainConfigs(configs []*AggregateChainConfig) (map[AggregatorID]map[int64]*AggregateChainConfig, map[AggregatorID]map[string]*AggregateChainConfig) {
+	byAggID := make(map[AggregatorID]map[int64]*AggregateChainConfig)
+	byAggName := make(map[AggregatorID]map[string]*AggregateChainConfig)
+	for _, cfg := range configs {
+		if _, ok := byAggID[cfg.AggregateID]; !ok {
+			byAggID[cfg.AggregateID] = make(map[int64]*AggregateChainConfig)
+		}
+		byAggID[cfg.AggregateID][cfg.ChainID] = cfg
+
+		if _, ok := byAggName[cfg.AggregateID]; !ok {
+			byAggName[cfg.AggregateID] = make(map[string]*AggregateChainConfig)
+		}
+		chainNameKey := strings.ToLower(strings.TrimSpace(cfg.ChainName))
+		byAggName[cfg.AggregateID][chainNameKey] = cfg
+	}
+	return byAggID, byAggName
+}
+
+func indexRouteConfigs(routes []*RouteConfig) (map[int64]map[int64][]*RouteConfig, map[string][]*RouteConfig, map[int64]*RouteConfig) {
+	byChainPair := make(map[int64]map[int64][]*RouteConfig)
+	bySymbol := make(map[string][]*RouteConfig)
+	byID := make(map[int64]*RouteConfig)
+	for _, r := range routes {
+		// Index by chain pair.
+		if _, ok := byChainPair[r.FromChainID]; !ok {
+			byChainPair[r.FromChainID] = make(map[int64][]*RouteConfig)
+		}
+		byChainPair[r.FromChainID][r.ToChainID] = append(byChainPair[r.FromChainID][r.ToChainID], r)
+
+		// Index by token symbol.
+		sym := strings.ToLower(r.FromTokenSymbol)
+		bySymbol[sym] = append(bySymbol[sym], r)
+
+		// Index by route ID.
+		byID[r.ID] = r
+	}
+	return byChainPair, bySymbol, byID
+}
+
+// indexFeeSegments groups segments by route, keeping their order.
+func indexFeeSegments(segments []*FeeSegment) map[int64][]*FeeSegment {
+	byRouteID := make(map[int64][]*FeeSegment)
+	for _, seg := range segments {
+		byRouteID[seg.RouteID] = append(byRouteID[seg.RouteID], seg)
+	}
+	return byRouteID
+}
+
+// aggregatorSnapshot is the snapshot content of AggregatorManager.
+type aggregatorSnapshot struct {
+	AggregatorConfigs []*AggregatorConfig
+	ChainConfigs      []*AggregateChainConfig
+	Routes            []*RouteConfig
+	FeeSegments       []*FeeSegment // Sorted by route, then by min amount
+}
+
+func (mgr *AggregatorManager) SnapshotName() string {
+	return "aggregator"
+}
+
+func (mgr *AggregatorManager) ExportSnapshot() (json.RawMessage, error) {
+	mgr.mutex.RLock()
+	snapshot := aggregatorSnapshot{
+		AggregatorConfigs: make([]*AggregatorConfig, 0, len(mgr.aggregatorConfigs)),
+		ChainConfigs:      make([]*AggregateChainConfig, 0),
+		Routes:            make([]*RouteConfig, 0, len(mgr.routesByID)),
+		FeeSegments:       make([]*FeeSegment, 0),
+	}
+	for _, cfg := range mgr.aggregatorConfigs {
+		snapshot.AggregatorConfigs = append(snapshot.AggregatorConfigs, cfg)
+	}
+	for _, chainMap := range mgr.chainConfigs {
+		for _, cfg := range chainMap {
+			snapshot.ChainConfigs = append(snapshot.ChainConfigs, cfg)
+		}
+	}
+	for _, r := range mgr.routesByID {
+		snapshot.Routes = append(snapshot.Routes, r)
+	}
+	routeIDs := make([]int64, 0, len(mgr.feeSegmentsByRouteID))
+	for routeID := range mgr.feeSegmentsByRouteID {
+		routeIDs = append(routeIDs, routeID)
+	}
+	sort.Slice(routeIDs, func(i, j int) bool { return routeIDs[i] < routeIDs[j] })
+	for _, routeID := range routeIDs {
+		snapshot.FeeSegments = append(snapshot.FeeSegments, mgr.feeSegmentsByRouteID[routeID]...)
+	}
+	mgr.mutex.RUnlock()
+
+	return json.Marshal(snapshot)
+}
+
+// ImportSnapshot replaces every aggregator index at once.
+func (mgr *AggregatorManager) ImportSnapshot(data json.RawMessage) error {
+	var snapshot aggregatorSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	mgr.setState(&snapshot)
 	return nil
 }
 
@@ -488,9 +699,28 @@ func (mgr *AggregatorManager) FindBestRoute(
 	amountUI string,
 	decimals int32,
 ) (*BestRouteResult, error) {
-	// 1. Query all routes for the chain pair and token.
-	routes := mgr.GetRoutesByChainPairAndSymbol(fromChainID, toChainID, tokenSymbol)
-	if len(routes) == 0 {
+	return mgr.FindBestRouteWithContext(context.Background(), fromChainID, toChainID, tokenSymbol, amountUI, decimals)
+}
+
+// FindBestRouteWithContext is FindBestRoute with live quotes: routes of aggregators with a quote client
+// are priced with the channel fee of the aggregator API, queried concurrently. A failed or timed out
+// quote falls back to the fee segment and sets FeeResult.LiveQuoteErr. The Owlto Native routes set by
+// SetNativeRoutes compete too. Routes are ranked on total fee, in smallest units of the token, then on ETA,
+// after the routes priced without a failed live quote.
+func (mgr *AggregatorManager) FindBestRouteWithContext(
+	ctx context.Context,
+	fromChainID, toChainID int64,
+	tokenSymbol string,
+	amountUI string,
+	decimals int32,
+) (*BestRouteResult, error) {
+	// 1. Query the routes of the chain pair keeping the token, their fees are all in its units.
+	// Routes changing token are left to RouteGraph.FindPaths.
+	routes := mgr.GetRoutesByChainPairAndSymbols(fromChainID, toChainID, tokenSymbol, tokenSymbol)
+	mgr.mutex.RLock()
+	native := mgr.native
+	mgr.mutex.RUnlock()
+	if len(routes) == 0 && native == nil {
 		return nil, fmt.Errorf("no routes found for %d->%d %s", fromChainID, toChainID, tokenSymbol)
 	}
 
@@ -500,48 +730,112 @@ func (mgr *AggregatorManager) FindBestRoute(
 		return nil, fmt.Errorf("invalid amount: %s", amountUI)
 	}
 
-	// 3. Iterate through routes and find a matching fee segment.
-	var bestResult *BestRouteResult
-	var lowestFee *big.Rat
-
-	for _, route := range routes {
-		// Check whether the amount falls within the route min/max range.
-		if !mgr.isAmountInRouteRange(amtRat, route) {
-			continue
+	// 3. Price every route concurrently, live quotes included.
+	results := make([]*BestRouteResult, len(routes))
+	var wg sync.WaitGroup
+	for i, route := range routes {
+		wg.Add(1)
+		go func(i int, route *RouteConfig) {
+			defer wg.Done()
+			results[i] = mgr.priceRoute(ctx, route, amtRat, decimals, tokenDecimals(native, routeEdge(route).To, decimals))
+		}(i, route)
+	}
+	wg.Wait()
+	candidates := make([]*BestRouteResult, 0, len(routes))
+	for _, result := range results {
+		if result != nil {
+			candidates = append(candidates, result)
 		}
+	}
 
-		// Find the matching fee segment for this route.
-		segment := mgr.findMatchingSegment(route.ID, amtRat)
-		if segment == nil {
-			continue // No matching fee segment.
+	// 4. Add the Owlto Native routes.
+	if native != nil {
+		candidates = append(candidates, native.candidates(fromChainID, toChainID, tokenSymbol, amtRat, decimals)...)
+	}
+	if len(candidates) == 0 {
+		if len(routes) == 0 {
+			return nil, fmt.Errorf("no routes found for %d->%d %s", fromChainID, toChainID, tokenSymbol)
 		}
+		return nil, fmt.Errorf("no matching fee segment for amount %s", amountUI)
+	}
 
-		// Calculate fees for the matched segment.
-		feeResult := mgr.calculateFee(amtRat, segment, decimals)
-		if feeResult == nil {
-			continue
+	// 5. Rank the routes on fee, then on speed, the first one wins. The placeholder fee of a failed live quote
+	// says little of the actual price, so these routes come last.
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i].FeeResult, candidates[j].FeeResult
+		if stale := a.LiveQuoteErr != nil; stale != (b.LiveQuoteErr != nil) {
+			return !stale
 		}
-		feeResult.BestAggregator = route.AggregateID
-
-		// Keep the route with the lowest fee.
-		feeRat := new(big.Rat).SetInt(feeResult.TotalGasFee)
-		if lowestFee == nil || feeRat.Cmp(lowestFee) < 0 {
-			lowestFee = feeRat
-			bestResult = &BestRouteResult{
-				Aggregator:   route.AggregateID,
-				Route:        route,
-				FeeSegment:   segment,
-				FeeResult:    feeResult,
-				IsAggregator: true,
-			}
+		if cmp := a.TotalGasFee.Cmp(b.TotalGasFee); cmp != 0 {
+			return cmp < 0
 		}
+		return a.ETA > 0 && (b.ETA == 0 || a.ETA < b.ETA)
+	})
+	bestResult := candidates[0]
+	bestResult.Alternatives = candidates[1:]
+	return bestResult, nil
+}
+
+// priceRoute prices amtRat over route from its fee segment, then from the live quote of the aggregator
+// when available. toDecimals are the decimals of the destination token, those of the live output amount.
+// It returns nil when the amount is out of the route range or matches no fee segment.
+func (mgr *AggregatorManager) priceRoute(ctx context.Context, route *RouteConfig, amtRat *big.Rat, decimals int32, toDecimals int32) *BestRouteResult {
+	// Check whether the amount falls within the route min/max range.
+	if !mgr.isAmountInRouteRange(amtRat, route) {
+		return nil
 	}
 
-	if bestResult == nil {
-		return nil, fmt.Errorf("no matching fee segment for amount %s", amountUI)
+	// Find the matching fee segment for this route.
+	segment := mgr.findMatchingSegment(route.ID, amtRat)
+	if segment == nil {
+		return nil
 	}
 
-	return bestResult, nil
+	// Calculate fees for the matched segment.
+	feeResult := mgr.calculateFee(amtRat, segment, decimals)
+	if feeResult == nil {
+		return nil
+	}
+	feeResult.BestAggregator = route.AggregateID
+
+	// Replace the placeholder channel fee with the live quote.
+	quote, err := mgr.liveQuote(ctx, route, uiToWei(amtRat, decimals), decimals, toDecimals)
+	if err != nil {
+		log.Errorf("aggregator live quote error, falling back to fee segment: %v", err)
+		feeResult.LiveQuoteErr = err
+	} else if quote != nil {
+		mgr.applyLiveQuote(feeResult, quote, decimals)
+	}
+
+	return &BestRouteResult{
+		Aggregator:   route.AggregateID,
+		Route:        route,
+		FeeSegment:   segment,
+		FeeResult:    feeResult,
+		IsAggregator: true,
+	}
+}
+
+// applyLiveQuote sets the output amount quoted by the aggregator on result and replaces its channel fee
+// with the quoted one, when the aggregator priced it.
+func (mgr *AggregatorManager) applyLiveQuote(result *FeeResult, quote *AggregatorQuote, decimals int32) {
+	result.ETA = quote.ETA
+	result.IsLiveQuote = true
+	result.OutputAmount = quote.OutputAmount
+	if quote.ChannelFee == nil {
+		return
+	}
+	result.ChannelFee = new(big.Int).Set(quote.ChannelFee)
+	result.TotalGasFee = new(big.Int).Add(result.ChannelFee, result.OwltoFee)
+	result.ChannelFeeUI = mgr.weiToUI(result.ChannelFee, decimals)
+	result.TotalGasFeeUI = mgr.weiToUI(result.TotalGasFee, decimals)
+}
+
+// uiToWei converts a UI amount to the smallest unit, truncating.
+func uiToWei(amtRat *big.Rat, decimals int32) *big.Int {
+	decimalFactor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	amtWeiRat := new(big.Rat).Mul(amtRat, new(big.Rat).SetInt(decimalFactor))
+	return new(big.Int).Div(amtWeiRat.Num(), amtWeiRat.Denom())
 }
 
 // isAmountInRouteRange checks whether the amount is within the route min/max range.
@@ -643,8 +937,8 @@ func (mgr *AggregatorManager) calculateFee(amtRat *big.Rat, seg *FeeSegment, dec
 	// 3. Total Owlto fee = fixed + percentage.
 	result.OwltoFee = new(big.Int).Add(owltoFixedWei, owltoRateWei)
 
-	// 4. Channel fee should come from the actual aggregator API.
-	//    For now, use the Owlto fixed fee as a placeholder channel fee.
+	// 4. Channel fee placeholder, the Owlto fixed fee.
+	//    FindBestRouteWithContext replaces it with the live quote of the aggregator when available.
 	result.ChannelFee = owltoFixedWei
 
 	// 5. Total gas fee.
@@ -668,6 +962,10 @@ func (mgr *AggregatorManager) calculateFee(amtRat *big.Rat, seg *FeeSegment, dec
 
 // weiToUI converts the smallest unit to a UI amount string.
 func (mgr *AggregatorManager) weiToUI(wei *big.Int, decimals int32) string {
+	return weiToUIString(wei, decimals)
+}
+
+func weiToUIString(wei *big.Int, decimals int32) string {
 	if wei == nil || wei.Sign() == 0 {
 		return "0"
 	}
@@ -0,0 +1,170 @@
+package loader
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// routeTablesDriver serves empty aggregator tables, or fails the route query when failRoutes is set.
+type routeTablesDriver struct {
+	failRoutes atomic.Bool
+	queries    atomic.Int32
+}
+
+func (d *routeTablesDriver) Open(name string) (driver.Conn, error) {
+	return &routeTablesConn{driver: d}, nil
+}
+
+type routeTablesConn struct {
+	driver *routeTablesDriver
+}
+
+func (c *routeTablesConn) Prepare(query string) (driver.Stmt, error) {
+	return &routeTablesStmt{driver: c.driver, query: query}, nil
+}
+func (c *routeTablesConn) Close() error              { return nil }
+func (c *routeTablesConn) Begin() (driver.Tx, error) { return nil, errors.New("not supported") }
+
+type routeTablesStmt struct {
+	driver *routeTablesDriver
+	query  string
+}
+
+func (s *routeTablesStmt) Close() error  { return nil }
+func (s *routeTablesStmt) NumInput() int { return -1 }
+func (s *routeTablesStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, errors.New("not supported")
+}
+func (s *routeTablesStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.driver.queries.Add(1)
+	if s.driver.failRoutes.Load() && strings.Contains(s.query, "t_aggregate_route_config") {
+		return nil, errors.New("route table locked")
+	}
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+type recordingAlerter struct {
+	mutex sync.Mutex
+	msgs  []string
+}
+
+func (a *recordingAlerter) AlertText(msg string, err error) {
+	a.mutex.Lock()
+	a.msgs = append(a.msgs, msg)
+	a.mutex.Unlock()
+}
+func (a *recordingAlerter) AlertTextLazy(msg string, err error) { a.AlertText(msg, err) }
+func (a *recordingAlerter) AlertTextLazyGroup(group string, msg string, err error) {
+	a.AlertText(msg, err)
+}
+
+var registerRouteTablesDriver sync.Once
+var routeTables = &routeTablesDriver{}
+
+func TestAggregatorLoadKeepsStateOnError(t *testing.T) {
+	registerRouteTablesDriver.Do(func() { sql.Register("aggregator_route_tables", routeTables) })
+	db, err := sql.Open("aggregator_route_tables", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	alerter := &recordingAlerter{}
+	mgr := NewAggregatorManager(db, alerter)
+	if err := mgr.ImportSnapshot([]byte(`{
+		"AggregatorConfigs": [{"ID": 1, "Name": "across", "IsEnabled": true}],
+		"Routes": [{"ID": 7, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 8453, "FromTokenSymbol": "USDC", "IsEnabled": true}]
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	routeTables.failRoutes.Store(true)
+	if _, err := mgr.Load(); err == nil {
+		t.Fatal("Load should fail when the route table cannot be read")
+	}
+	if len(mgr.GetAllRoutes()) != 1 {
+		t.Fatal("a failed load must keep the previous routes")
+	}
+	mgr.mutex.RLock()
+	configs := len(mgr.aggregatorConfigs)
+	mgr.mutex.RUnlock()
+	if configs != 1 {
+		t.Fatal("a failed load must keep the previous aggregator configs")
+	}
+	if len(alerter.msgs) != 1 {
+		t.Fatalf("expected one alert, got %v", alerter.msgs)
+	}
+
+	routeTables.failRoutes.Store(false)
+	mgr.SetRefreshInterval(10 * time.Millisecond)
+	mgr.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for len(mgr.GetAllRoutes()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("background refresh did not reload the routes")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mgr.Stop()
+
+	queries := routeTables.queries.Load()
+	time.Sleep(50 * time.Millisecond)
+	if routeTables.queries.Load() != queries {
+		t.Fatal("Stop should stop the background refresh")
+	}
+
+	// Start loads at once, without waiting for the first interval.
+	if err := mgr.ImportSnapshot([]byte(`{"Routes": [{"ID": 7, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 8453, "FromTokenSymbol": "USDC", "IsEnabled": true}]}`)); err != nil {
+		t.Fatal(err)
+	}
+	mgr.SetRefreshInterval(time.Hour)
+	mgr.Start(context.Background())
+	deadline = time.Now().Add(time.Second)
+	for len(mgr.GetAllRoutes()) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Start did not load the routes at once")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mgr.Stop()
+}
+
+func TestFindBestRouteKeepsToken(t *testing.T) {
+	mgr := NewAggregatorManager(nil, nil)
+	if err := mgr.ImportSnapshot([]byte(`{
+		"AggregatorConfigs": [{"ID": 1, "Name": "across", "IsEnabled": true}, {"ID": 2, "Name": "relay", "IsEnabled": true}],
+		"Routes": [
+			{"ID": 1, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 8453, "FromTokenSymbol": "USDC", "IsEnabled": true},
+			{"ID": 2, "AggregateID": 2, "FromChainID": 42161, "ToChainID": 8453, "FromTokenSymbol": "USDC", "ToTokenSymbol": "WETH", "IsEnabled": true}
+		],
+		"FeeSegments": [
+			{"ID": 1, "RouteID": 1, "MinAmountUI": "0", "MaxAmountUI": "0", "OwltoFeeFixedUI": "1", "IsEnabled": true},
+			{"ID": 2, "RouteID": 2, "MinAmountUI": "0", "MaxAmountUI": "0", "OwltoFeeFixedUI": "0.1", "IsEnabled": true}
+		]
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// The USDC fee of the USDC to WETH route looks cheaper but says nothing of the WETH received.
+	best, err := mgr.FindBestRoute(42161, 8453, "USDC", "100", 6)
+	if err != nil {
+		t.Fatalf("FindBestRoute: %v", err)
+	}
+	if best.Route.ID != 1 || len(best.Alternatives) != 0 {
+		t.Fatalf("only the route keeping USDC should compete, got route %d and %d alternatives", best.Route.ID, len(best.Alternatives))
+	}
+}
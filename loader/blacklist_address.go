@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -24,6 +26,8 @@ type BlacklistAddressManager struct {
 	db                *sql.DB
 	alerter           alert.Alerter
 	mutex             *sync.RWMutex
+
+	changeSubscribers *subscribers[BlacklistAddressDiff]
 }
 
 func NewBlacklistAddressManager(db *sql.DB, alerter alert.Alerter) *BlacklistAddressManager {
@@ -33,9 +37,22 @@ func NewBlacklistAddressManager(db *sql.DB, alerter alert.Alerter) *BlacklistAdd
 		db:                db,
 		alerter:           alerter,
 		mutex:             &sync.RWMutex{},
+
+		changeSubscribers: newSubscribers[BlacklistAddressDiff](),
 	}
 }
 
+// SubscribeChanges registers fn to be called with the added, removed and modified blacklist rows after every reload
+// that changed something. Rows carry no chain and are keyed by their detected CanonicalAddress. The returned function unsubscribes fn.
+func (mgr *BlacklistAddressManager) SubscribeChanges(fn func(BlacklistAddressDiff)) func() {
+	return mgr.changeSubscribers.subscribe(fn)
+}
+
+func blacklistAddressEqual(a *BlacklistAddress, b *BlacklistAddress) bool {
+	return a.Id == b.Id && a.Address == b.Address && a.RiskDesc == b.RiskDesc && a.Status == b.Status &&
+		a.CreatedAt.Equal(b.CreatedAt) && a.UpdatedAt.Equal(b.UpdatedAt)
+}
+
 func (mgr *BlacklistAddressManager) GetBlacklistById(id int64) (*BlacklistAddress, bool) {
 	mgr.mutex.RLock()
 	blacklist, ok := mgr.idBlacklists[id]
@@ -43,19 +60,32 @@ func (mgr *BlacklistAddressManager) GetBlacklistById(id int64) (*BlacklistAddres
 	return blacklist, ok
 }
 
+// IsBlacklisted is IsBlacklistedByBackend for callers that do not know the chain of address.
 func (mgr *BlacklistAddressManager) IsBlacklisted(address string) bool {
-	mgr.mutex.RLock()
-	blacklist, ok := mgr.addressBlacklists[strings.ToLower(strings.TrimSpace(address))]
-	mgr.mutex.RUnlock()
-	return ok && blacklist.Status == 1
+	return mgr.IsBlacklistedByBackend(0, address)
+}
+
+// IsBlacklistedByBackend reports whether address, an address of a backend chain, is blacklisted.
+func (mgr *BlacklistAddressManager) IsBlacklistedByBackend(backend Backend, address string) bool {
+	_, ok := mgr.GetBlacklistByBackendAddress(backend, address)
+	return ok
 }
 
+// GetBlacklistByAddress is GetBlacklistByBackendAddress for callers that do not know the chain of address.
 func (mgr *BlacklistAddressManager) GetBlacklistByAddress(address string) (*BlacklistAddress, bool) {
+	return mgr.GetBlacklistByBackendAddress(0, address)
+}
+
+// GetBlacklistByBackendAddress returns the active blacklist row of address, an address of a backend chain.
+// Blacklist rows carry no chain, they are indexed by their detected canonical form, so address is looked up
+// by its backend form first and then by its detected one.
+func (mgr *BlacklistAddressManager) GetBlacklistByBackendAddress(backend Backend, address string) (*BlacklistAddress, bool) {
 	mgr.mutex.RLock()
-	blacklist, ok := mgr.addressBlacklists[strings.ToLower(strings.TrimSpace(address))]
-	mgr.mutex.RUnlock()
-	if ok && blacklist.Status == 1 {
-		return blacklist, true
+	defer mgr.mutex.RUnlock()
+	for _, key := range AddressKeys(backend, address) {
+		if blacklist, ok := mgr.addressBlacklists[key]; ok && blacklist.Status == 1 {
+			return blacklist, true
+		}
 	}
 	return nil, false
 }
@@ -74,18 +104,23 @@ func (mgr *BlacklistAddressManager) GetAllBlacklists() []*BlacklistAddress {
 }
 
 func (mgr *BlacklistAddressManager) LoadAllBlacklists() {
+	mgr.Load()
+}
+
+// Load reloads t_blacklist_address and returns the number of blacklisted addresses loaded.
+func (mgr *BlacklistAddressManager) Load() (int, error) {
 	// Query the database to select all fields
 	rows, err := mgr.db.Query("SELECT id, address, risk_desc, status, created_at, updated_at FROM t_blacklist_address")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_blacklist_address error", err)
-		return
+		return 0, fmt.Errorf("select t_blacklist_address: %w", err)
 	}
 
 	defer rows.Close()
 
-	idBlacklists := make(map[int64]*BlacklistAddress)
-	addressBlacklists := make(map[string]*BlacklistAddress)
+	blacklists := make([]*BlacklistAddress, 0)
 
 	// Iterate over the result set
 	for rows.Next() {
@@ -96,19 +131,60 @@ func (mgr *BlacklistAddressManager) LoadAllBlacklists() {
 			blacklist.Address = strings.TrimSpace(blacklist.Address)
 			blacklist.RiskDesc = strings.TrimSpace(blacklist.RiskDesc)
 
-			idBlacklists[blacklist.Id] = &blacklist
-			addressBlacklists[strings.ToLower(blacklist.Address)] = &blacklist
+			blacklists = append(blacklists, &blacklist)
 		}
 	}
 
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_blacklist_address row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_blacklist_address: %w", err)
+	}
+
+	return mgr.setBlacklists(blacklists), nil
+}
+
+// setBlacklists swaps the blacklist indexes, notifies subscribers and returns the number of distinct ids.
+func (mgr *BlacklistAddressManager) setBlacklists(blacklists []*BlacklistAddress) int {
+	idBlacklists := make(map[int64]*BlacklistAddress)
+	addressBlacklists := make(map[string]*BlacklistAddress)
+	for _, blacklist := range blacklists {
+		idBlacklists[blacklist.Id] = blacklist
+		addressBlacklists[CanonicalAddress(0, blacklist.Address)] = blacklist
 	}
 
 	mgr.mutex.Lock()
+	oldAddressBlacklists := mgr.addressBlacklists
 	mgr.idBlacklists = idBlacklists
 	mgr.addressBlacklists = addressBlacklists
 	mgr.mutex.Unlock()
+
+	if diff := computeDiff(oldAddressBlacklists, addressBlacklists, blacklistAddressEqual); !diff.IsEmpty() {
+		mgr.changeSubscribers.notify(diff)
+	}
+	return len(idBlacklists)
+}
+
+func (mgr *BlacklistAddressManager) SnapshotName() string {
+	return "blacklist_address"
+}
+
+// ExportSnapshot exports every row, including the inactive ones.
+func (mgr *BlacklistAddressManager) ExportSnapshot() (json.RawMessage, error) {
+	mgr.mutex.RLock()
+	blacklists := make([]*BlacklistAddress, 0, len(mgr.idBlacklists))
+	for _, blacklist := range mgr.idBlacklists {
+		blacklists = append(blacklists, blacklist)
+	}
+	mgr.mutex.RUnlock()
+	return exportRows(blacklists)
+}
+
+func (mgr *BlacklistAddressManager) ImportSnapshot(data json.RawMessage) error {
+	blacklists, err := importRows[*BlacklistAddress](data)
+	if err != nil {
+		return err
+	}
+	mgr.setBlacklists(blacklists)
+	return nil
 }
@@ -2,13 +2,15 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
 	"sync"
 
 	"github.com/owlto-dao/utils-go/alert"
-	"github.com/owlto-dao/utils-go/util"
+	"github.com/shopspring/decimal"
 )
 
 type BridgeFee struct {
@@ -29,6 +31,35 @@ type BridgeFee struct {
 	AmountLv2Str string
 	AmountLv3Str string
 	AmountLv4Str string
+
+	Schedule *TierSchedule `json:"-"` // Built from the Lv columns, values are the fee ratios
+}
+
+// buildSchedule builds the schedule of the Lv columns. AmountLv4 is unused, the fourth tier is unbounded,
+// and an amount equal to a breakpoint belongs to the upper tier.
+func (bridgeFee *BridgeFee) buildSchedule() error {
+	amountStrs := []string{bridgeFee.AmountLv1Str, bridgeFee.AmountLv2Str, bridgeFee.AmountLv3Str}
+	amounts := []float64{bridgeFee.AmountLv1, bridgeFee.AmountLv2, bridgeFee.AmountLv3}
+	breakpoints := make([]decimal.Decimal, len(amounts))
+	for i := range amounts {
+		breakpoint, err := tierAmount(amountStrs[i], amounts[i])
+		if err != nil {
+			return fmt.Errorf("amount lv%d: %w", i+1, err)
+		}
+		breakpoints[i] = breakpoint
+	}
+	values := []decimal.Decimal{
+		decimal.NewFromInt(bridgeFee.BridgeFeeRatioLv1),
+		decimal.NewFromInt(bridgeFee.BridgeFeeRatioLv2),
+		decimal.NewFromInt(bridgeFee.BridgeFeeRatioLv3),
+		decimal.NewFromInt(bridgeFee.BridgeFeeRatioLv4),
+	}
+	schedule, err := NewTierSchedule(breakpoints, values, false)
+	if err != nil {
+		return err
+	}
+	bridgeFee.Schedule = schedule
+	return nil
 }
 
 type BridgeFeeManager struct {
@@ -65,35 +96,42 @@ func (mgr *BridgeFeeManager) GetBridgeFee(token string, from string, to string)
 }
 
 func (mgr *BridgeFeeManager) LoadAllBridgeFee(tokenInfoMgr TokenInfoManager) {
+	mgr.LoadBridgeFees(&tokenInfoMgr)
+}
+
+// LoadBridgeFees reloads t_dynamic_bridge_fee and returns the number of rows loaded.
+// tokenInfoMgr must already be loaded, it supplies the keep decimal of tokens missing from t_bridge_fee_decimal.
+func (mgr *BridgeFeeManager) LoadBridgeFees(tokenInfoMgr *TokenInfoManager) (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT token_name, from_chain, to_chain, bridge_fee_ratio_lv1, bridge_fee_ratio_lv2, bridge_fee_ratio_lv3, bridge_fee_ratio_lv4, amount_lv1, amount_lv2, amount_lv3, amount_lv4 FROM t_dynamic_bridge_fee")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_dynamic_bridge_fee error", err)
-		return
-	}
-
-	kdrows, kderr := mgr.db.Query("SELECT token, keep_decimal FROM t_bridge_fee_decimal")
-	if kderr != nil {
-		mgr.alerter.AlertText("select t_bridge_fee_decimal error", kderr)
+		return 0, fmt.Errorf("select t_dynamic_bridge_fee: %w", err)
 	}
 
 	defer rows.Close()
-	defer kdrows.Close()
 
 	tokenDecimal := make(map[string]int64)
-	for kdrows.Next() {
-		var tokenName string
-		var keepDecimal int64
-		if err := kdrows.Scan(&tokenName, &keepDecimal); err != nil {
-			mgr.alerter.AlertText("scan t_bridge_fee_decimal row error", err)
-		} else {
-			tokenName = strings.TrimSpace(tokenName)
-			tokenDecimal[strings.ToLower(tokenName)] = keepDecimal
+	kdrows, kderr := mgr.db.Query("SELECT token, keep_decimal FROM t_bridge_fee_decimal")
+	if kderr != nil || kdrows == nil {
+		mgr.alerter.AlertText("select t_bridge_fee_decimal error", kderr)
+	} else {
+		defer kdrows.Close()
+		for kdrows.Next() {
+			var tokenName string
+			var keepDecimal int64
+			if err := kdrows.Scan(&tokenName, &keepDecimal); err != nil {
+				mgr.alerter.AlertText("scan t_bridge_fee_decimal row error", err)
+			} else {
+				tokenName = strings.TrimSpace(tokenName)
+				tokenDecimal[strings.ToLower(tokenName)] = keepDecimal
+			}
 		}
 	}
 
-	tokenFromToBridgeFees := make(map[string]map[string]map[string]*BridgeFee)
+	bridgeFees := make([]*BridgeFee, 0)
 	counter := 0
 
 	// Iterate over the result set
@@ -144,18 +182,12 @@ func (mgr *BridgeFeeManager) LoadAllBridgeFee(tokenInfoMgr TokenInfoManager) {
 				bridgeFee.KeepDecimal = int32(tokenInfo.Decimals)
 			}
 
-			ftInfos, ok := tokenFromToBridgeFees[strings.ToLower(bridgeFee.TokenName)]
-			if !ok {
-				ftInfos = make(map[string]map[string]*BridgeFee)
-				tokenFromToBridgeFees[strings.ToLower(bridgeFee.TokenName)] = ftInfos
-			}
-			infos, ok := ftInfos[strings.ToLower(bridgeFee.FromChainName)]
-			if !ok {
-				infos = make(map[string]*BridgeFee)
-				ftInfos[strings.ToLower(bridgeFee.FromChainName)] = infos
+			if err := bridgeFee.buildSchedule(); err != nil {
+				mgr.alerter.AlertText("t_dynamic_bridge_fee tier schedule error", err)
+				continue
 			}
-			infos[strings.ToLower(bridgeFee.ToChainName)] = &bridgeFee
 
+			bridgeFees = append(bridgeFees, &bridgeFee)
 			counter++
 		}
 	}
@@ -163,7 +195,27 @@ func (mgr *BridgeFeeManager) LoadAllBridgeFee(tokenInfoMgr TokenInfoManager) {
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_dynamic_bridge_fee row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_dynamic_bridge_fee: %w", err)
+	}
+
+	mgr.setBridgeFees(bridgeFees)
+	return counter, nil
+}
+
+func (mgr *BridgeFeeManager) setBridgeFees(bridgeFees []*BridgeFee) {
+	tokenFromToBridgeFees := make(map[string]map[string]map[string]*BridgeFee)
+	for _, bridgeFee := range bridgeFees {
+		ftInfos, ok := tokenFromToBridgeFees[strings.ToLower(bridgeFee.TokenName)]
+		if !ok {
+			ftInfos = make(map[string]map[string]*BridgeFee)
+			tokenFromToBridgeFees[strings.ToLower(bridgeFee.TokenName)] = ftInfos
+		}
+		infos, ok := ftInfos[strings.ToLower(bridgeFee.FromChainName)]
+		if !ok {
+			infos = make(map[string]*BridgeFee)
+			ftInfos[strings.ToLower(bridgeFee.FromChainName)] = infos
+		}
+		infos[strings.ToLower(bridgeFee.ToChainName)] = bridgeFee
 	}
 
 	mgr.mutex.Lock()
@@ -171,6 +223,43 @@ func (mgr *BridgeFeeManager) LoadAllBridgeFee(tokenInfoMgr TokenInfoManager) {
 	mgr.mutex.Unlock()
 }
 
+func (mgr *BridgeFeeManager) GetAllBridgeFees() []*BridgeFee {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	bridgeFees := make([]*BridgeFee, 0)
+	for _, ftInfos := range mgr.tokenFromToBridgeFees {
+		for _, infos := range ftInfos {
+			for _, bridgeFee := range infos {
+				bridgeFees = append(bridgeFees, bridgeFee)
+			}
+		}
+	}
+	return bridgeFees
+}
+
+func (mgr *BridgeFeeManager) SnapshotName() string {
+	return "bridge_fee"
+}
+
+func (mgr *BridgeFeeManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllBridgeFees())
+}
+
+// ImportSnapshot replaces the bridge fees, the keep decimals are taken as resolved when exported.
+func (mgr *BridgeFeeManager) ImportSnapshot(data json.RawMessage) error {
+	bridgeFees, err := importRows[*BridgeFee](data)
+	if err != nil {
+		return err
+	}
+	for _, bridgeFee := range bridgeFees {
+		if err := bridgeFee.buildSchedule(); err != nil {
+			return fmt.Errorf("bridge fee %s %s %s: %w", bridgeFee.TokenName, bridgeFee.FromChainName, bridgeFee.ToChainName, err)
+		}
+	}
+	mgr.setBridgeFees(bridgeFees)
+	return nil
+}
+
 func (mgr *BridgeFeeManager) FromUiString(amount *big.Int, bridgeFee int64, decimal int32, keepDecimal int32) *big.Int {
 	value := big.NewInt(0)
 	value.Add(value, amount)
@@ -209,55 +298,126 @@ func (mgr *BridgeFeeManager) GetBridgeFeeDetail(tokenName string, fromChainName
 
 }
 
-func (mgr *BridgeFeeManager) GetIncludedBridgeFeeBigInt(tokenName string, fromChainName string, toChainName string, value *big.Int, decimal int32) (int64, bool) {
+// GetIncludedBridgeFeeBigInt returns the fee ratio of value, an amount the bridge fee is included in.
+func (mgr *BridgeFeeManager) GetIncludedBridgeFeeBigInt(tokenName string, fromChainName string, toChainName string, value *big.Int, decimals int32) (int64, bool) {
 	bridgeFee, ok := mgr.GetBridgeFee(tokenName, fromChainName, toChainName)
-	if !ok {
+	if !ok || bridgeFee.Schedule == nil {
 		return 0, false
 	}
 
-	keepDecimal := decimal
-	if bridgeFee.KeepDecimal < decimal {
+	keepDecimal := decimals
+	if bridgeFee.KeepDecimal < decimals {
 		keepDecimal = bridgeFee.KeepDecimal
 	}
 
-	AmountLv1BigInt, err := util.FromUiString(bridgeFee.AmountLv1Str, decimal)
-	if err != nil {
-		return 0, false
+	ratio := bridgeFee.Schedule.IncludedValueOf(func(ratio decimal.Decimal) decimal.Decimal {
+		return decimal.NewFromBigInt(mgr.FromUiString(value, ratio.IntPart(), decimals, keepDecimal), -decimals)
+	})
+	return ratio.IntPart(), true
+}
+
+// GetBridgeFeeToIncludeBigInt grosses value up: it returns the ratio and the fee to add to value so that the
+// bridge fee of the sum, deducted like GetBridgeFeeDetail with the tier of the sum, leaves at least value.
+func (mgr *BridgeFeeManager) GetBridgeFeeToIncludeBigInt(tokenName string, fromChainName string, toChainName string, value *big.Int, decimals int32) (int64, *big.Int, bool) {
+	bridgeFee, ok := mgr.GetBridgeFee(tokenName, fromChainName, toChainName)
+	if !ok || bridgeFee.Schedule == nil {
+		return 0, nil, false
 	}
-	AmountLv2BigInt, err := util.FromUiString(bridgeFee.AmountLv2Str, decimal)
+
+	hi, err := bridgeFee.grossUpperBound(value, decimals)
 	if err != nil {
-		return 0, false
+		return 0, nil, false
 	}
-	AmountLv3BigInt, err := util.FromUiString(bridgeFee.AmountLv3Str, decimal)
-	if err != nil {
-		return 0, false
+	gross := mgr.grossUp(bridgeFee, value, value, hi, decimals)
+	if gross == nil {
+		return 0, nil, false
 	}
+	ratio, _ := mgr.GetBridgeFeeDetail(tokenName, fromChainName, toChainName, gross, decimals)
+	return ratio, new(big.Int).Sub(gross, value), true
+}
 
-	if AmountLv1BigInt.Cmp(mgr.FromUiString(value, bridgeFee.BridgeFeeRatioLv1, decimal, keepDecimal)) > 0 {
-		return bridgeFee.BridgeFeeRatioLv1, true
-	} else if AmountLv2BigInt.Cmp(mgr.FromUiString(value, bridgeFee.BridgeFeeRatioLv2, decimal, keepDecimal)) > 0 {
-		return bridgeFee.BridgeFeeRatioLv2, true
-	} else if AmountLv3BigInt.Cmp(mgr.FromUiString(value, bridgeFee.BridgeFeeRatioLv3, decimal, keepDecimal)) > 0 {
-		return bridgeFee.BridgeFeeRatioLv3, true
-	} else {
-		return bridgeFee.BridgeFeeRatioLv4, true
+func (bridgeFee *BridgeFee) keepDecimal(decimals int32) int32 {
+	if bridgeFee.KeepDecimal < decimals {
+		return bridgeFee.KeepDecimal
 	}
+	return decimals
+}
+
+// tier returns the tier of value, an amount the bridge fee is included in, like GetIncludedBridgeFeeBigInt.
+func (mgr *BridgeFeeManager) tier(bridgeFee *BridgeFee, value *big.Int, decimals int32) int {
+	keepDecimal := bridgeFee.keepDecimal(decimals)
+	return bridgeFee.Schedule.TierIncluded(func(ratio decimal.Decimal) decimal.Decimal {
+		return decimal.NewFromBigInt(mgr.FromUiString(value, ratio.IntPart(), decimals, keepDecimal), -decimals)
+	})
+}
 
+// grossUpperBound returns an amount that leaves at least value once its bridge fee is deducted, whatever its tier.
+func (bridgeFee *BridgeFee) grossUpperBound(value *big.Int, decimals int32) (*big.Int, error) {
+	maxRatio := decimal.Zero
+	for _, ratio := range bridgeFee.Schedule.Values {
+		maxRatio = decimal.Max(maxRatio, ratio)
+	}
+	denominator := decimal.NewFromInt(100000000)
+	if maxRatio.GreaterThanOrEqual(denominator) {
+		return nil, fmt.Errorf("bridge fee ratio %s leaves nothing", maxRatio)
+	}
+	scale := decimal.New(1, decimals-bridgeFee.keepDecimal(decimals))
+	return decimal.NewFromBigInt(value, 0).Mul(denominator).Div(denominator.Sub(maxRatio)).Ceil().Add(scale).BigInt(), nil
 }
 
+// grossUp returns the smallest amount within [lo, hi] that leaves at least value once its bridge fee is deducted
+// like GetBridgeFeeDetail, or nil when there is none. A nil bridgeFee charges no fee.
+//
+// The tiers split [lo, hi] into ranges of a constant ratio, tried in order. Within a range the fee is truncated
+// to the keep decimal, so the amount minus its fee is not monotone, but the smallest amount is value plus one of
+// the fees, a multiple of the keep decimal unit, and whether value plus a fee covers that fee is monotone.
+func (mgr *BridgeFeeManager) grossUp(bridgeFee *BridgeFee, value *big.Int, lo *big.Int, hi *big.Int, decimals int32) *big.Int {
+	if lo.Cmp(hi) > 0 {
+		return nil
+	}
+	if bridgeFee == nil || bridgeFee.Schedule == nil {
+		gross := bigMax(lo, value)
+		if gross.Cmp(hi) > 0 {
+			return nil
+		}
+		return gross
+	}
+
+	keepDecimal := bridgeFee.keepDecimal(decimals)
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-keepDecimal)), nil)
+	end := new(big.Int).Add(hi, big.NewInt(1))
+	for start := new(big.Int).Set(lo); start.Cmp(end) < 0; {
+		tier := mgr.tier(bridgeFee, start, decimals)
+		tierEnd := searchBigInt(start, end, func(amount *big.Int) bool { return mgr.tier(bridgeFee, amount, decimals) != tier })
+		ratio := bridgeFee.Schedule.Value(tier).IntPart()
+		fee := func(amount *big.Int) *big.Int {
+			return new(big.Int).Sub(amount, mgr.FromUiString(amount, ratio, decimals, keepDecimal))
+		}
+		candidate := func(units *big.Int) *big.Int {
+			return bigMax(start, new(big.Int).Add(value, new(big.Int).Mul(units, scale)))
+		}
+
+		last := new(big.Int).Sub(tierEnd, big.NewInt(1))
+		maxUnits := new(big.Int).Div(fee(last), scale)
+		maxUnits.Add(maxUnits, big.NewInt(1))
+		units := searchBigInt(big.NewInt(0), new(big.Int).Add(maxUnits, big.NewInt(1)), func(units *big.Int) bool {
+			gross := candidate(units)
+			return fee(gross).Cmp(new(big.Int).Sub(gross, value)) <= 0
+		})
+		if gross := candidate(units); units.Cmp(maxUnits) <= 0 && gross.Cmp(last) <= 0 {
+			return gross
+		}
+		start = tierEnd
+	}
+	return nil
+}
+
+// GetBridgeFeeNotIncluded returns the fee ratio of value, an amount the bridge fee is charged on top of.
 func (mgr *BridgeFeeManager) GetBridgeFeeNotIncluded(tokenName string, fromChainName string, toChainName string, value float64) (int64, bool) {
 	bridgeFee, ok := mgr.GetBridgeFee(tokenName, fromChainName, toChainName)
-	if !ok {
+	if !ok || bridgeFee.Schedule == nil {
 		return 0, false
 	}
 
-	if value < bridgeFee.AmountLv1 {
-		return bridgeFee.BridgeFeeRatioLv1, true
-	} else if value < bridgeFee.AmountLv2 {
-		return bridgeFee.BridgeFeeRatioLv2, true
-	} else if value < bridgeFee.AmountLv3 {
-		return bridgeFee.BridgeFeeRatioLv3, true
-	} else {
-		return bridgeFee.BridgeFeeRatioLv4, true
-	}
+	return bridgeFee.Schedule.ValueOf(decimal.NewFromFloat(value)).IntPart(), true
 }
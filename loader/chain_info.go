@@ -3,9 +3,12 @@ package loader
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/NethermindEth/starknet.go/rpc"
 	"github.com/block-vision/sui-go-sdk/sui"
@@ -13,6 +16,8 @@ import (
 	solrpc "github.com/gagliardetto/solana-go/rpc"
 	"github.com/owlto-dao/utils-go/alert"
 	"github.com/owlto-dao/utils-go/convert"
+	"github.com/owlto-dao/utils-go/cosmos"
+	"github.com/owlto-dao/utils-go/log"
 	"github.com/sentioxyz/fuel-go"
 	"github.com/xssnick/tonutils-go/liteclient"
 	"github.com/xssnick/tonutils-go/ton"
@@ -65,7 +70,7 @@ type ChainInfo struct {
 	TransferContractAddress sql.NullString
 	DepositContractAddress  sql.NullString
 	Layer1                  sql.NullString
-	Client                  interface{}
+	Client                  interface{} `json:"-"`
 }
 
 func (ci *ChainInfo) GetInt32ChainId() int32 {
@@ -98,6 +103,13 @@ type ChainInfoManager struct {
 	mutex   *sync.RWMutex
 
 	tonClient ton.APIClientWrapped
+
+	clients          map[chainClientKey]interface{} // Clients in use, shared by chains with the same endpoint
+	clientCloseDelay time.Duration
+	clientMutex      *sync.Mutex // Serializes reloads so that a client is closed only once
+	withoutClients   bool        // Set for offline use, e.g. validating a snapshot, chains get no client
+
+	changeSubscribers *subscribers[ChainInfoDiff]
 }
 
 func NewChainInfoManager(db *sql.DB, alerter alert.Alerter) *ChainInfoManager {
@@ -109,9 +121,33 @@ func NewChainInfoManager(db *sql.DB, alerter alert.Alerter) *ChainInfoManager {
 		db:            db,
 		alerter:       alerter,
 		mutex:         &sync.RWMutex{},
+
+		clients:          make(map[chainClientKey]interface{}),
+		clientCloseDelay: time.Minute,
+		clientMutex:      &sync.Mutex{},
+
+		changeSubscribers: newSubscribers[ChainInfoDiff](),
 	}
 }
 
+type chainClientKey struct {
+	backend  Backend
+	endpoint string
+}
+
+// SubscribeChanges registers fn to be called with the added, removed and modified chains after every reload
+// that changed something. The returned function unsubscribes fn.
+func (mgr *ChainInfoManager) SubscribeChanges(fn func(ChainInfoDiff)) func() {
+	return mgr.changeSubscribers.subscribe(fn)
+}
+
+// chainInfoEqual compares the loaded columns of two chains, ignoring their clients.
+func chainInfoEqual(a *ChainInfo, b *ChainInfo) bool {
+	ac, bc := *a, *b
+	ac.Client, bc.Client = nil, nil
+	return ac == bc
+}
+
 func (mgr *ChainInfoManager) GetChainInfoAutoIds() []int64 {
 	mgr.mutex.RLock()
 	ids := make([]int64, 0, len(mgr.idChains))
@@ -166,27 +202,29 @@ func (mgr *ChainInfoManager) GetChainInfoByNetcode(netcode int32) (*ChainInfo, b
 }
 
 func (mgr *ChainInfoManager) GetAllChains() []*ChainInfo {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
 	return mgr.allChains
 }
 
 func (mgr *ChainInfoManager) LoadAllChains() {
+	mgr.Load()
+}
+
+// Load reloads t_chain_info and returns the number of chains loaded.
+func (mgr *ChainInfoManager) Load() (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT id, chainid, real_chainid, name, alias_name, backend, eip1559, network_code, icon, block_interval, timeout, rpc_end_point, explorer_url, official_rpc, disabled, is_testnet, order_weight, gas_token_name, gas_token_address, gas_token_decimal, gas_token_icon, transfer_contract_address, deposit_contract_address, layer1, mev_rpc_url,enable_blacklist_check FROM t_chain_info")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_chain_info error", err)
-		return
+		return 0, fmt.Errorf("select t_chain_info: %w", err)
 	}
 
 	defer rows.Close()
 
-	idChains := make(map[int64]*ChainInfo)
-	netcodeChains := make(map[int32]*ChainInfo)
-	chainIdChains := make(map[string]*ChainInfo)
-	nameChains := make(map[string]*ChainInfo)
-	allChains := make([]*ChainInfo, 0)
-
-	counter := 0
+	chains := make([]*ChainInfo, 0)
 
 	// Iterate over the result set
 	for rows.Next() {
@@ -215,65 +253,177 @@ func (mgr *ChainInfoManager) LoadAllChains() {
 			chain.DepositContractAddress.String = strings.TrimSpace(chain.DepositContractAddress.String)
 			chain.Layer1.String = strings.TrimSpace(chain.Layer1.String)
 
-			if chain.Backend == EthereumBackend {
-				chain.Client, err = ethclient.Dial(chain.RpcEndPoint)
+			chains = append(chains, &chain)
+		}
+	}
+
+	// Check for errors from iterating over rows
+	if err := rows.Err(); err != nil {
+		mgr.alerter.AlertText("get next t_chain_info row error", err)
+		return 0, fmt.Errorf("iterate t_chain_info: %w", err)
+	}
+
+	return mgr.setChains(chains), nil
+}
+
+// setChains sets the client of every chain, swaps the indexes and notifies subscribers.
+// Clients are reused while their backend and endpoint are unchanged, the ones left unused are closed after the close delay.
+// Chains whose client cannot be created are dropped. It returns the number of chains kept.
+func (mgr *ChainInfoManager) setChains(chains []*ChainInfo) int {
+	idChains := make(map[int64]*ChainInfo)
+	netcodeChains := make(map[int32]*ChainInfo)
+	chainIdChains := make(map[string]*ChainInfo)
+	nameChains := make(map[string]*ChainInfo)
+	allChains := make([]*ChainInfo, 0, len(chains))
+
+	mgr.clientMutex.Lock()
+	clients := make(map[chainClientKey]interface{})
+	for _, chain := range chains {
+		if mgr.withoutClients {
+			chain.Client = nil
+		} else if chain.Backend == TonBackend {
+			if mgr.tonClient == nil {
+				client := liteclient.NewConnectionPool()
+				configUrl := ConfigURLTestnet
+				if chain.IsTestnet == 0 {
+					configUrl = ConfigURLMainnet
+				}
+				err := client.AddConnectionsFromConfigUrl(context.Background(), configUrl)
 				if err != nil {
-					mgr.alerter.AlertText("create evm client error", err)
+					mgr.alerter.AlertText("create ton client error", err)
+					client.Stop()
 					continue
 				}
-			} else if chain.Backend == StarknetBackend {
-				chain.Client, err = rpc.NewProvider(chain.RpcEndPoint)
+				apiClient := ton.NewAPIClient(client).WithRetry()
+				mgr.tonClient = apiClient
+			}
+			chain.Client = mgr.tonClient
+		} else {
+			key := chainClientKey{backend: chain.Backend, endpoint: chain.RpcEndPoint}
+			client, ok := clients[key]
+			if !ok {
+				client, ok = mgr.clients[key]
+			}
+			if !ok {
+				var err error
+				client, err = NewChainClient(chain.Backend, chain.RpcEndPoint)
 				if err != nil {
-					mgr.alerter.AlertText("create starknet client error", err)
+					mgr.alerter.AlertText(fmt.Sprintf("create %s client error", backendClientName(chain.Backend)), err)
 					continue
 				}
-			} else if chain.Backend == SolanaBackend {
-				chain.Client = solrpc.New(chain.RpcEndPoint)
-			} else if chain.Backend == SuiBackend {
-				chain.Client = sui.NewSuiClient(chain.RpcEndPoint)
-			} else if chain.Backend == TonBackend {
-				if mgr.tonClient == nil {
-					client := liteclient.NewConnectionPool()
-					configUrl := ConfigURLTestnet
-					if chain.IsTestnet == 0 {
-						configUrl = ConfigURLMainnet
-					}
-					err = client.AddConnectionsFromConfigUrl(context.Background(), configUrl)
-					if err != nil {
-						mgr.alerter.AlertText("create ton client error", err)
-						client.Stop()
-						continue
-					}
-					apiClient := ton.NewAPIClient(client).WithRetry()
-					chain.Client = apiClient
-					mgr.tonClient = apiClient
-				} else {
-					chain.Client = mgr.tonClient
-				}
-			} else if chain.Backend == FuelBackend {
-				chain.Client = fuel.NewClient(chain.RpcEndPoint)
 			}
-
-			idChains[chain.Id] = &chain
-			chainIdChains[strings.ToLower(chain.ChainId)] = &chain
-			nameChains[strings.ToLower(chain.Name)] = &chain
-			netcodeChains[chain.NetworkCode] = &chain
-			allChains = append(allChains, &chain)
-			counter++
+			if client != nil {
+				clients[key] = client
+			}
+			chain.Client = client
 		}
-	}
 
-	// Check for errors from iterating over rows
-	if err := rows.Err(); err != nil {
-		mgr.alerter.AlertText("get next t_chain_info row error", err)
-		return
+		idChains[chain.Id] = chain
+		chainIdChains[strings.ToLower(chain.ChainId)] = chain
+		nameChains[strings.ToLower(chain.Name)] = chain
+		netcodeChains[chain.NetworkCode] = chain
+		allChains = append(allChains, chain)
 	}
 
 	mgr.mutex.Lock()
+	oldIdChains := mgr.idChains
 	mgr.idChains = idChains
 	mgr.chainIdChains = chainIdChains
 	mgr.nameChains = nameChains
 	mgr.netcodeChains = netcodeChains
 	mgr.allChains = allChains
 	mgr.mutex.Unlock()
+
+	oldClients := mgr.clients
+	mgr.clients = clients
+	for key, client := range oldClients {
+		if _, ok := clients[key]; !ok {
+			mgr.closeClientLater(client)
+		}
+	}
+	mgr.clientMutex.Unlock()
+
+	if diff := computeDiff(oldIdChains, idChains, chainInfoEqual); !diff.IsEmpty() {
+		mgr.changeSubscribers.notify(diff)
+	}
+	return len(allChains)
+}
+
+// NewChainClient dials the rpc client of a chain backend.
+// It returns a nil client for backends without one, ton clients are created by ChainInfoManager itself.
+func NewChainClient(backend Backend, endpoint string) (interface{}, error) {
+	switch backend {
+	case EthereumBackend:
+		return ethclient.Dial(endpoint)
+	case StarknetBackend:
+		return rpc.NewProvider(endpoint)
+	case SolanaBackend:
+		return solrpc.New(endpoint), nil
+	case SuiBackend:
+		return sui.NewSuiClient(endpoint), nil
+	case FuelBackend:
+		return fuel.NewClient(endpoint), nil
+	case CosmosBackend:
+		return cosmos.NewClient(endpoint), nil
+	default:
+		return nil, nil
+	}
+}
+
+func backendClientName(backend Backend) string {
+	switch backend {
+	case EthereumBackend:
+		return "evm"
+	case StarknetBackend:
+		return "starknet"
+	case SolanaBackend:
+		return "solana"
+	case SuiBackend:
+		return "sui"
+	case FuelBackend:
+		return "fuel"
+	case CosmosBackend:
+		return "cosmos"
+	default:
+		return fmt.Sprintf("backend %d", backend)
+	}
+}
+
+// SetClientCloseDelay sets how long a client no longer used by any chain stays open,
+// so that callers still holding it can finish their requests.
+func (mgr *ChainInfoManager) SetClientCloseDelay(delay time.Duration) {
+	mgr.clientMutex.Lock()
+	mgr.clientCloseDelay = delay
+	mgr.clientMutex.Unlock()
+}
+
+func (mgr *ChainInfoManager) closeClientLater(client interface{}) {
+	switch c := client.(type) {
+	case interface{ Close() }:
+		time.AfterFunc(mgr.clientCloseDelay, c.Close)
+	case interface{ Close() error }:
+		time.AfterFunc(mgr.clientCloseDelay, func() {
+			if err := c.Close(); err != nil {
+				log.Errorf("close chain client error: %v", err)
+			}
+		})
+	}
+}
+
+func (mgr *ChainInfoManager) SnapshotName() string {
+	return "chain_info"
+}
+
+func (mgr *ChainInfoManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllChains())
+}
+
+// ImportSnapshot replaces the chains with the snapshot ones and creates their clients.
+func (mgr *ChainInfoManager) ImportSnapshot(data json.RawMessage) error {
+	chains, err := importRows[*ChainInfo](data)
+	if err != nil {
+		return err
+	}
+	mgr.setChains(chains)
+	return nil
 }
@@ -0,0 +1,63 @@
+package loader
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+type countingCloser struct {
+	closed *atomic.Int32
+}
+
+func (c countingCloser) Close() {
+	c.closed.Add(1)
+}
+
+func TestChainInfoManagerReusesClients(t *testing.T) {
+	mgr := NewChainInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	mgr.SetClientCloseDelay(time.Millisecond)
+
+	mgr.setChains([]*ChainInfo{
+		{Id: 1, ChainId: "1", Name: "Ethereum", Backend: EthereumBackend, RpcEndPoint: "http://127.0.0.1:8545"},
+		{Id: 2, ChainId: "42161", Name: "Arbitrum", Backend: EthereumBackend, RpcEndPoint: "http://127.0.0.1:8547"},
+	})
+	ethereum, _ := mgr.GetChainInfoById(1)
+	arbitrum, _ := mgr.GetChainInfoById(2)
+
+	mgr.setChains([]*ChainInfo{
+		{Id: 1, ChainId: "1", Name: "Ethereum", Backend: EthereumBackend, RpcEndPoint: "http://127.0.0.1:8545"},
+		{Id: 2, ChainId: "42161", Name: "Arbitrum", Backend: EthereumBackend, RpcEndPoint: "http://127.0.0.1:9547"},
+	})
+	reloadedEthereum, _ := mgr.GetChainInfoById(1)
+	reloadedArbitrum, _ := mgr.GetChainInfoById(2)
+
+	if reloadedEthereum.Client != ethereum.Client {
+		t.Fatal("unchanged chain should keep its client")
+	}
+	if reloadedArbitrum.Client == arbitrum.Client {
+		t.Fatal("chain with a new endpoint should get a new client")
+	}
+	if len(mgr.clients) != 2 {
+		t.Fatalf("clients = %d, want 2", len(mgr.clients))
+	}
+}
+
+func TestChainInfoManagerClosesUnusedClients(t *testing.T) {
+	mgr := NewChainInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	mgr.SetClientCloseDelay(time.Millisecond)
+
+	closed := &atomic.Int32{}
+	mgr.clients[chainClientKey{backend: EthereumBackend, endpoint: "http://removed"}] = countingCloser{closed: closed}
+	mgr.setChains(nil)
+
+	deadline := time.Now().Add(time.Second)
+	for closed.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if closed.Load() != 1 {
+		t.Fatalf("closed = %d, want 1", closed.Load())
+	}
+}
@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"sort"
 	"sync"
 
@@ -13,6 +15,13 @@ type ChannelCommissionRatio struct {
 	ratio   int64
 }
 
+// ChannelCommissionRatioRow is a row of t_channel_commission_ratio.
+type ChannelCommissionRatioRow struct {
+	ChannelId int64
+	TxCount   int64
+	Ratio     int64
+}
+
 type ChannelCommissionRatioManager struct {
 	channelidToCountToRatio map[int64]map[int64]int64
 	channelidToRatioArr     map[int64][]ChannelCommissionRatio
@@ -49,44 +58,56 @@ func (mgr *ChannelCommissionRatioManager) GetRatioByChannelidAndCount(channelid
 }
 
 func (mgr *ChannelCommissionRatioManager) LoadAllCommissionRatio() {
+	mgr.Load()
+}
+
+// Load reloads t_channel_commission_ratio and returns the number of commission ratios loaded.
+func (mgr *ChannelCommissionRatioManager) Load() (int, error) {
 	rows, err := mgr.db.Query("select channel_id, tx_count, commission_ratio from t_channel_commission_ratio order by tx_count asc")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_channel_commission_ratio error", err)
-		return
+		return 0, fmt.Errorf("select t_channel_commission_ratio: %w", err)
 	}
 
 	defer rows.Close()
 
-	channelidToCountToRatio := make(map[int64]map[int64]int64)
-	channelidToRatioArr := make(map[int64][]ChannelCommissionRatio)
-	counter := 0
+	ratioRows := make([]ChannelCommissionRatioRow, 0)
 
 	// Iterate over the result set
 	for rows.Next() {
-		var channelID, txCount, ratio int64
+		var row ChannelCommissionRatioRow
 
-		if err := rows.Scan(&channelID, &txCount, &ratio); err != nil {
+		if err := rows.Scan(&row.ChannelId, &row.TxCount, &row.Ratio); err != nil {
 			mgr.alerter.AlertText("scan t_channel_commission_ratio row error", err)
 		} else {
-			channelidToCountToRatio[channelID] = make(map[int64]int64)
-			channelidToCountToRatio[channelID][txCount] = ratio
-
-			ratioArr, exist := channelidToRatioArr[channelID]
-			if !exist {
-				ratioArr = make([]ChannelCommissionRatio, 0)
-			}
-			ratioArr = append(ratioArr, ChannelCommissionRatio{txCount, ratio})
-			channelidToRatioArr[channelID] = ratioArr
-
-			counter++
+			ratioRows = append(ratioRows, row)
 		}
 	}
 
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_channel_commission_ratio row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_channel_commission_ratio: %w", err)
+	}
+
+	mgr.setRatios(ratioRows)
+	return len(ratioRows), nil
+}
+
+func (mgr *ChannelCommissionRatioManager) setRatios(ratioRows []ChannelCommissionRatioRow) {
+	channelidToCountToRatio := make(map[int64]map[int64]int64)
+	channelidToRatioArr := make(map[int64][]ChannelCommissionRatio)
+	for _, row := range ratioRows {
+		countToRatio, exist := channelidToCountToRatio[row.ChannelId]
+		if !exist {
+			countToRatio = make(map[int64]int64)
+			channelidToCountToRatio[row.ChannelId] = countToRatio
+		}
+		countToRatio[row.TxCount] = row.Ratio
+
+		channelidToRatioArr[row.ChannelId] = append(channelidToRatioArr[row.ChannelId], ChannelCommissionRatio{row.TxCount, row.Ratio})
 	}
 
 	for k, _ := range channelidToRatioArr {
@@ -100,3 +121,28 @@ func (mgr *ChannelCommissionRatioManager) LoadAllCommissionRatio() {
 	mgr.channelidToRatioArr = channelidToRatioArr
 	mgr.mutex.Unlock()
 }
+
+func (mgr *ChannelCommissionRatioManager) SnapshotName() string {
+	return "channel_commission_ratio"
+}
+
+func (mgr *ChannelCommissionRatioManager) ExportSnapshot() (json.RawMessage, error) {
+	mgr.mutex.RLock()
+	ratioRows := make([]ChannelCommissionRatioRow, 0)
+	for channelID, ratioArr := range mgr.channelidToRatioArr {
+		for _, kv := range ratioArr {
+			ratioRows = append(ratioRows, ChannelCommissionRatioRow{ChannelId: channelID, TxCount: kv.txCount, Ratio: kv.ratio})
+		}
+	}
+	mgr.mutex.RUnlock()
+	return exportRows(ratioRows)
+}
+
+func (mgr *ChannelCommissionRatioManager) ImportSnapshot(data json.RawMessage) error {
+	ratioRows, err := importRows[ChannelCommissionRatioRow](data)
+	if err != nil {
+		return err
+	}
+	mgr.setRatios(ratioRows)
+	return nil
+}
@@ -2,6 +2,7 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"strings"
@@ -78,18 +79,23 @@ func (mgr *CircleCctpChainManager) GetChainIds() []int32 {
 }
 
 func (mgr *CircleCctpChainManager) LoadAllChains() {
+	mgr.Load()
+}
+
+// Load reloads t_cctp_support_chain and returns the number of cctp chains loaded.
+func (mgr *CircleCctpChainManager) Load() (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT chainid, min_value, domain, token_messenger, message_transmitter, token_messengerv2, message_transmitterv2 FROM t_cctp_support_chain")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_cctp_support_chain error", err)
-		return
+		return 0, fmt.Errorf("select t_cctp_support_chain: %w", err)
 	}
 
 	defer rows.Close()
 
-	chainIdChains := make(map[int32]*CircleCctpChain)
-	counter := 0
+	chains := make([]*CircleCctpChain, 0)
 
 	// Iterate over the result set
 	for rows.Next() {
@@ -106,18 +112,50 @@ func (mgr *CircleCctpChainManager) LoadAllChains() {
 				continue
 			}
 
-			chainIdChains[chain.ChainId] = &chain
-			counter++
+			chains = append(chains, &chain)
 		}
 	}
 
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_cctp_support_chain row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_cctp_support_chain: %w", err)
+	}
+
+	mgr.setChains(chains)
+	return len(chains), nil
+}
+
+func (mgr *CircleCctpChainManager) setChains(chains []*CircleCctpChain) {
+	chainIdChains := make(map[int32]*CircleCctpChain)
+	for _, chain := range chains {
+		chainIdChains[chain.ChainId] = chain
 	}
 
 	mgr.mutex.Lock()
 	mgr.chainIdChains = chainIdChains
 	mgr.mutex.Unlock()
 }
+
+func (mgr *CircleCctpChainManager) SnapshotName() string {
+	return "circle_cctp_chain"
+}
+
+func (mgr *CircleCctpChainManager) ExportSnapshot() (json.RawMessage, error) {
+	mgr.mutex.RLock()
+	chains := make([]*CircleCctpChain, 0, len(mgr.chainIdChains))
+	for _, chain := range mgr.chainIdChains {
+		chains = append(chains, chain)
+	}
+	mgr.mutex.RUnlock()
+	return exportRows(chains)
+}
+
+func (mgr *CircleCctpChainManager) ImportSnapshot(data json.RawMessage) error {
+	chains, err := importRows[*CircleCctpChain](data)
+	if err != nil {
+		return err
+	}
+	mgr.setChains(chains)
+	return nil
+}
@@ -1,10 +1,14 @@
 package loader
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"github.com/owlto-dao/utils-go/log"
 	"strings"
 	"sync"
+	"time"
 )
 
 type DevRole struct {
@@ -13,8 +17,21 @@ type DevRole struct {
 }
 
 type CmsRole struct {
-	Id   int64
-	Name string
+	Id          int64
+	Name        string
+	Permissions []string // From dev_role_permission, may contain wildcards, see MatchPermission
+}
+
+// CmsAuditRecord is a privileged call of a cms user, written to dev_audit_log.
+type CmsAuditRecord struct {
+	Address    string
+	UserName   string
+	Permission string // Permissions required by the call, comma separated
+	Method     string
+	Path       string
+	Allowed    bool
+	Status     int // Http status of the response, 0 when the call was denied
+	CreatedAt  time.Time
 }
 
 type CmsUser struct {
@@ -27,6 +44,7 @@ type CmsUser struct {
 type CmsUserManager struct {
 	allUsers []*CmsUser
 	db       *sql.DB
+	dialect  Dialect
 	mutex    *sync.RWMutex
 }
 
@@ -34,10 +52,76 @@ func NewCmsUserManager(db *sql.DB) *CmsUserManager {
 	return &CmsUserManager{
 		allUsers: []*CmsUser{},
 		db:       db,
+		dialect:  MySQLDialect,
 		mutex:    &sync.RWMutex{},
 	}
 }
 
+// SetDialect sets the SQL dialect of db, MySQL by default.
+func (mgr *CmsUserManager) SetDialect(dialect Dialect) {
+	mgr.mutex.Lock()
+	mgr.dialect = dialect
+	mgr.mutex.Unlock()
+}
+
+func (mgr *CmsUserManager) getDialect() Dialect {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	return mgr.dialect
+}
+
+// MatchPermission reports whether the granted permission covers the required one. Permissions are
+// ':' separated segments, a "*" segment matches any segment and a trailing "*" any remaining segments:
+// "*" covers everything, "lp:*" covers "lp:write" and "lp:fee:write".
+func MatchPermission(granted string, required string) bool {
+	grantedSegs := strings.Split(strings.ToLower(strings.TrimSpace(granted)), ":")
+	requiredSegs := strings.Split(strings.ToLower(strings.TrimSpace(required)), ":")
+	for i, seg := range grantedSegs {
+		if seg == "*" && i == len(grantedSegs)-1 {
+			return i < len(requiredSegs)
+		}
+		if i >= len(requiredSegs) || (seg != "*" && seg != requiredSegs[i]) {
+			return false
+		}
+	}
+	return len(grantedSegs) == len(requiredSegs)
+}
+
+// HasPermission reports whether a role of the user at userAddress grants permission.
+func (mgr *CmsUserManager) HasPermission(userAddress string, permission string) bool {
+	for _, granted := range mgr.GetPermissions(userAddress) {
+		if MatchPermission(granted, permission) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPermissions returns the permissions granted by the roles of the user at userAddress.
+func (mgr *CmsUserManager) GetPermissions(userAddress string) []string {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	permissions := make([]string, 0)
+	for _, user := range mgr.allUsers {
+		if strings.EqualFold(user.Address, userAddress) {
+			for _, role := range user.Roles {
+				permissions = append(permissions, role.Permissions...)
+			}
+		}
+	}
+	return permissions
+}
+
+// WriteAudit inserts record into dev_audit_log within ctx.
+func (mgr *CmsUserManager) WriteAudit(ctx context.Context, record *CmsAuditRecord) error {
+	_, err := mgr.db.ExecContext(ctx, mgr.getDialect().Rebind("INSERT INTO dev_audit_log (address, user_name, permission, method, path, allowed, status, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"),
+		record.Address, record.UserName, record.Permission, record.Method, record.Path, record.Allowed, record.Status, record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert dev_audit_log: %w", err)
+	}
+	return nil
+}
+
 func (mgr *CmsUserManager) HasRole(userAddress string, roleName string) bool {
 	mgr.mutex.RLock()
 	defer mgr.mutex.RUnlock()
@@ -71,40 +155,56 @@ func (mgr *CmsUserManager) GetAllCmsUsers() []*CmsUser {
 }
 
 func (mgr *CmsUserManager) LoadAllCmsUsers() {
+	mgr.Load()
+}
+
+// Load reloads dev_role, dev_role_permission, dev_white_admin and dev_roles and returns the number of cms users loaded.
+// dev_role_permission may be missing, see queryRolePermissions.
+func (mgr *CmsUserManager) Load() (int, error) {
 	allUsers := []*CmsUser{}
 	allRoles := []*CmsRole{}
 
 	rows, err := mgr.db.Query("SELECT id, name FROM dev_role")
 	if err != nil || rows == nil {
+		err = queryError(err)
 		log.Error("query dev role failed ", err)
-		return
+		return 0, fmt.Errorf("select dev_role: %w", err)
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var role CmsRole
 		if err = rows.Scan(&role.Id, &role.Name); err != nil {
 			log.Error("scan dev_role row error", err)
-			return
+			return 0, fmt.Errorf("scan dev_role: %w", err)
 		} else {
 			allRoles = append(allRoles, &role)
 		}
 	}
 	if err = rows.Err(); err != nil {
 		log.Error("get next dev_role row error", err)
-		return
+		return 0, fmt.Errorf("iterate dev_role: %w", err)
+	}
+
+	permissions, err := mgr.queryRolePermissions()
+	if err != nil {
+		return 0, err
+	}
+	for _, role := range allRoles {
+		role.Permissions = permissions[role.Id]
 	}
 
 	adminRows, err := mgr.db.Query("SELECT id, name, address FROM dev_white_admin")
 	if err != nil || adminRows == nil {
+		err = queryError(err)
 		log.Error("query dev_white_admin failed ", err)
-		return
+		return 0, fmt.Errorf("select dev_white_admin: %w", err)
 	}
 	defer adminRows.Close()
 	for adminRows.Next() {
 		var user CmsUser
 		if err = adminRows.Scan(&user.Id, &user.Name, &user.Address); err != nil {
 			log.Error("scan dev_white_admin failed ", err)
-			return
+			return 0, fmt.Errorf("scan dev_white_admin: %w", err)
 		} else {
 			user.Roles = []*CmsRole{}
 			allUsers = append(allUsers, &user)
@@ -112,20 +212,21 @@ func (mgr *CmsUserManager) LoadAllCmsUsers() {
 	}
 	if err = adminRows.Err(); err != nil {
 		log.Error("get next dev_white_admin failed ", err)
-		return
+		return 0, fmt.Errorf("iterate dev_white_admin: %w", err)
 	}
 
 	devRoleRows, err := mgr.db.Query("SELECT user_id, role_id FROM dev_roles")
 	if err != nil || devRoleRows == nil {
+		err = queryError(err)
 		log.Error("query dev_roles failed ", err)
-		return
+		return 0, fmt.Errorf("select dev_roles: %w", err)
 	}
 	defer devRoleRows.Close()
 	for devRoleRows.Next() {
 		var devRole DevRole
 		if err = devRoleRows.Scan(&devRole.UserId, &devRole.RoleId); err != nil {
 			log.Error("scan dev_role row error", err)
-			return
+			return 0, fmt.Errorf("scan dev_roles: %w", err)
 		} else {
 			for _, user := range allUsers {
 				for _, role := range allRoles {
@@ -138,10 +239,68 @@ func (mgr *CmsUserManager) LoadAllCmsUsers() {
 	}
 	if err = devRoleRows.Err(); err != nil {
 		log.Error("get next dev_roles failed ", err)
-		return
+		return 0, fmt.Errorf("iterate dev_roles: %w", err)
+	}
+
+	mgr.mutex.Lock()
+	mgr.allUsers = allUsers
+	mgr.mutex.Unlock()
+	return len(allUsers), nil
+}
+
+// queryRolePermissions returns the permissions of dev_role_permission by role id. The table is optional,
+// without it the roles grant no permission.
+func (mgr *CmsUserManager) queryRolePermissions() (map[int64][]string, error) {
+	permissions := make(map[int64][]string)
+	rows, err := mgr.db.Query("SELECT role_id, permission FROM dev_role_permission")
+	if isMissingTable(err) {
+		log.Warnf("dev_role_permission is missing, cms roles grant no permission: %v", err)
+		return permissions, nil
+	}
+	if err != nil || rows == nil {
+		err = queryError(err)
+		log.Error("query dev_role_permission failed ", err)
+		return nil, fmt.Errorf("select dev_role_permission: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var roleId int64
+		var permission string
+		if err = rows.Scan(&roleId, &permission); err != nil {
+			log.Error("scan dev_role_permission row error", err)
+			return nil, fmt.Errorf("scan dev_role_permission: %w", err)
+		}
+		permissions[roleId] = append(permissions[roleId], strings.TrimSpace(permission))
+	}
+	if err = rows.Err(); err != nil {
+		log.Error("get next dev_role_permission row error", err)
+		return nil, fmt.Errorf("iterate dev_role_permission: %w", err)
+	}
+	return permissions, nil
+}
+
+func (mgr *CmsUserManager) SnapshotName() string {
+	return "cms_user"
+}
+
+// ExportSnapshot exports the users with their roles embedded.
+func (mgr *CmsUserManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllCmsUsers())
+}
+
+func (mgr *CmsUserManager) ImportSnapshot(data json.RawMessage) error {
+	allUsers, err := importRows[*CmsUser](data)
+	if err != nil {
+		return err
+	}
+	for _, user := range allUsers {
+		if user.Roles == nil {
+			user.Roles = []*CmsRole{}
+		}
 	}
 
 	mgr.mutex.Lock()
 	mgr.allUsers = allUsers
 	mgr.mutex.Unlock()
+	return nil
 }
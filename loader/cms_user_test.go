@@ -0,0 +1,42 @@
+package loader
+
+import "testing"
+
+func TestMatchPermission(t *testing.T) {
+	cases := []struct {
+		granted, required string
+		want              bool
+	}{
+		{"*", "lp:write", true},
+		{"lp:write", "lp:write", true},
+		{"LP:Write", "lp:write", true},
+		{"lp:*", "lp:write", true},
+		{"lp:*", "lp:fee:write", true},
+		{"lp:*", "lp", false},
+		{"*:read", "lp:read", true},
+		{"*:read", "lp:write", false},
+		{"lp:write", "lp:write:all", false},
+		{"lp:write", "lp", false},
+	}
+	for _, c := range cases {
+		if got := MatchPermission(c.granted, c.required); got != c.want {
+			t.Errorf("MatchPermission(%q, %q) = %v, want %v", c.granted, c.required, got, c.want)
+		}
+	}
+}
+
+func TestCmsUserPermissions(t *testing.T) {
+	mgr := NewCmsUserManager(nil)
+	if err := mgr.ImportSnapshot([]byte(`[
+		{"Id": 1, "Name": "ops", "Address": "0xOps", "Roles": [{"Id": 1, "Name": "lp_admin", "Permissions": ["lp:*", "fee:read"]}]},
+		{"Id": 2, "Name": "viewer", "Address": "0xviewer", "Roles": [{"Id": 2, "Name": "viewer"}]}
+	]`)); err != nil {
+		t.Fatal(err)
+	}
+	if !mgr.HasPermission("0xops", "lp:maker:disable") || !mgr.HasPermission("0xOPS", "fee:read") {
+		t.Fatal("ops should be granted lp and fee read permissions")
+	}
+	if mgr.HasPermission("0xops", "fee:write") || mgr.HasPermission("0xviewer", "fee:read") || mgr.HasPermission("0xunknown", "fee:read") {
+		t.Fatal("unexpected permission granted")
+	}
+}
@@ -0,0 +1,160 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect hides the SQL differences between the databases the managers can run on.
+// Queries are written with MySQL style ? placeholders and rebound by the dialect.
+type Dialect interface {
+	Name() string
+	// Rebind rewrites the ? placeholders of query into the dialect's placeholders.
+	Rebind(query string) string
+	// InsertIgnore returns a rebound insert of columns into table that silently skips duplicate keys.
+	InsertIgnore(table string, columns ...string) string
+	// CastDecimal returns expr cast to a decimal type suitable for numeric ordering.
+	CastDecimal(expr string) string
+	// True returns the literal compared against boolean flag columns.
+	True() string
+}
+
+var (
+	MySQLDialect    Dialect = mysqlDialect{}
+	PostgresDialect Dialect = postgresDialect{}
+	SQLiteDialect   Dialect = sqliteDialect{}
+)
+
+// DialectFor returns the dialect of a database/sql driver name.
+func DialectFor(driverName string) (Dialect, error) {
+	switch strings.ToLower(strings.TrimSpace(driverName)) {
+	case "mysql":
+		return MySQLDialect, nil
+	case "postgres", "postgresql", "pgx":
+		return PostgresDialect, nil
+	case "sqlite", "sqlite3":
+		return SQLiteDialect, nil
+	default:
+		return nil, fmt.Errorf("unsupported sql driver %s", driverName)
+	}
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return "mysql"
+}
+
+func (mysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (mysqlDialect) InsertIgnore(table string, columns ...string) string {
+	return fmt.Sprintf("INSERT IGNORE INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders(len(columns)))
+}
+
+func (mysqlDialect) CastDecimal(expr string) string {
+	return fmt.Sprintf("CAST(%s AS DECIMAL(65,18))", expr)
+}
+
+func (mysqlDialect) True() string {
+	return "1"
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return "postgres"
+}
+
+// Rebind numbers the placeholders $1, $2... leaving quoted literals and identifiers untouched.
+func (postgresDialect) Rebind(query string) string {
+	var sb strings.Builder
+	sb.Grow(len(query) + 8)
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			n++
+			sb.WriteByte('$')
+			sb.WriteString(strconv.Itoa(n))
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+func (d postgresDialect) InsertIgnore(table string, columns ...string) string {
+	return d.Rebind(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING", table, strings.Join(columns, ", "), placeholders(len(columns))))
+}
+
+func (postgresDialect) CastDecimal(expr string) string {
+	return fmt.Sprintf("CAST(%s AS NUMERIC)", expr)
+}
+
+func (postgresDialect) True() string {
+	return "TRUE"
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return "sqlite"
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) InsertIgnore(table string, columns ...string) string {
+	return fmt.Sprintf("INSERT OR IGNORE INTO %s (%s) VALUES (%s)", table, strings.Join(columns, ", "), placeholders(len(columns)))
+}
+
+// CastDecimal casts to REAL, sqlite has no arbitrary precision decimals.
+func (sqliteDialect) CastDecimal(expr string) string {
+	return fmt.Sprintf("CAST(%s AS REAL)", expr)
+}
+
+func (sqliteDialect) True() string {
+	return "1"
+}
+
+// isMissingTable reports whether err is the error of MySQL, Postgres or SQLite for a table that does not exist.
+func isMissingTable(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := err.Error()
+	return strings.Contains(message, "Error 1146") || // MySQL ER_NO_SUCH_TABLE
+		strings.Contains(message, "SQLSTATE 42P01") || strings.Contains(message, "(42P01)") || // Postgres undefined_table, pgx and lib/pq
+		strings.Contains(message, "no such table") // SQLite
+}
+
+// errNilRows stands for the error of a query that returned neither rows nor an error.
+var errNilRows = errors.New("query returned no rows")
+
+// queryError returns the error of a query whose rows are nil, err itself unless it is nil.
+func queryError(err error) error {
+	if err == nil {
+		return errNilRows
+	}
+	return err
+}
+
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.Repeat("?, ", n-1) + "?"
+}
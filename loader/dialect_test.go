@@ -0,0 +1,147 @@
+package loader
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/alert"
+	_ "modernc.org/sqlite"
+)
+
+func TestDialectRebind(t *testing.T) {
+	query := "SELECT id FROM t_src_transaction where note = 'why?' and chainid = ? and tx_hash = ?"
+	if got := MySQLDialect.Rebind(query); got != query {
+		t.Fatalf("mysql rebind = %s", got)
+	}
+	want := "SELECT id FROM t_src_transaction where note = 'why?' and chainid = $1 and tx_hash = $2"
+	if got := PostgresDialect.Rebind(query); got != want {
+		t.Fatalf("postgres rebind = %s, want %s", got, want)
+	}
+}
+
+func TestDialectInsertIgnore(t *testing.T) {
+	cases := []struct {
+		dialect Dialect
+		want    string
+	}{
+		{MySQLDialect, "INSERT IGNORE INTO t_dst_transaction (src_action, src_id) VALUES (?, ?)"},
+		{PostgresDialect, "INSERT INTO t_dst_transaction (src_action, src_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"},
+		{SQLiteDialect, "INSERT OR IGNORE INTO t_dst_transaction (src_action, src_id) VALUES (?, ?)"},
+	}
+	for _, c := range cases {
+		if got := c.dialect.InsertIgnore("t_dst_transaction", "src_action", "src_id"); got != c.want {
+			t.Errorf("%s insert ignore = %s, want %s", c.dialect.Name(), got, c.want)
+		}
+	}
+}
+
+func TestDialectFor(t *testing.T) {
+	for driver, want := range map[string]Dialect{"mysql": MySQLDialect, "pgx": PostgresDialect, "sqlite3": SQLiteDialect} {
+		got, err := DialectFor(driver)
+		if err != nil || got != want {
+			t.Errorf("DialectFor(%s) = %v, %v", driver, got, err)
+		}
+	}
+	if _, err := DialectFor("oracle"); err == nil {
+		t.Error("DialectFor should reject unknown drivers")
+	}
+	if got := PostgresDialect.CastDecimal("min_amount_ui"); got != "CAST(min_amount_ui AS NUMERIC)" {
+		t.Errorf("postgres cast decimal = %s", got)
+	}
+}
+
+func TestIsMissingTable(t *testing.T) {
+	for message, want := range map[string]bool{
+		"Error 1146 (42S02): Table 'owlto.dev_role_permission' doesn't exist":   true,
+		`ERROR: relation "dev_role_permission" does not exist (SQLSTATE 42P01)`: true,
+		`pq: relation "dev_role_permission" does not exist (42P01)`:             true,
+		"no such table: dev_role_permission":                                    true,
+		"Error 1045 (28000): Access denied for user 'owlto'@'localhost'":        false,
+		"dial tcp 127.0.0.1:3306: connect: connection refused":                  false,
+	} {
+		if got := isMissingTable(errors.New(message)); got != want {
+			t.Errorf("isMissingTable(%q) = %v, want %v", message, got, want)
+		}
+	}
+	if isMissingTable(nil) {
+		t.Error("nil is not a missing table")
+	}
+}
+
+func TestSQLiteDialectExecutes(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // Every connection to :memory: is a database of its own
+
+	for _, stmt := range []string{
+		`CREATE TABLE t_aggregate_config (id INTEGER PRIMARY KEY, name TEXT, is_enabled INTEGER, priority INTEGER, api_base_url TEXT)`,
+		`CREATE TABLE t_aggregate_chain_config (id INTEGER PRIMARY KEY, aggregate_id INTEGER, chain_name TEXT, chain_id INTEGER,
+			deposit_contract_address TEXT, bridge_fee_rate_bps INTEGER, fill_deadline_seconds INTEGER, swapper_address TEXT,
+			is_enabled INTEGER, priority INTEGER)`,
+		`CREATE TABLE t_aggregate_route_config (id INTEGER PRIMARY KEY, aggregate_id INTEGER, from_chain_id INTEGER, from_chain_name TEXT,
+			from_token_address TEXT, from_token_symbol TEXT, to_chain_id INTEGER, to_chain_name TEXT, to_token_address TEXT,
+			to_token_symbol TEXT, is_native INTEGER, min_amount TEXT, max_amount TEXT, is_enabled INTEGER, priority INTEGER)`,
+		`CREATE TABLE t_aggregate_route_fee_segment (id INTEGER PRIMARY KEY, route_id INTEGER, min_amount_ui TEXT, max_amount_ui TEXT,
+			owlto_fee_fixed_ui TEXT, owlto_fee_rate_bps INTEGER, protocol_fee_rate_bps INTEGER, is_enabled INTEGER, priority INTEGER)`,
+		`CREATE TABLE t_dst_transaction (id INTEGER PRIMARY KEY, src_action TEXT, src_id INTEGER, src_version INTEGER, sender INTEGER,
+			body TEXT, fee_cap TEXT, transfer_token TEXT, transfer_recipient TEXT, transfer_amount TEXT, confirmed_gen INTEGER,
+			UNIQUE (src_action, src_id, src_version))`,
+		`INSERT INTO t_aggregate_config VALUES (1, 'across', 1, 0, ''), (2, 'relay', 0, 0, '')`,
+		`INSERT INTO t_aggregate_chain_config VALUES (1, 1, 'Base', 8453, NULL, 10, NULL, NULL, 1, 0)`,
+		`INSERT INTO t_aggregate_route_config VALUES (7, 1, 42161, 'Arbitrum', '0xa', 'USDC', 8453, 'Base', '0xb', 'USDC', 0, '0', '0', 1, 0)`,
+		// Ordered as text, 1000 would come before 200
+		`INSERT INTO t_aggregate_route_fee_segment VALUES (1, 7, '1000', '0', '1', 0, 0, 1, 0), (2, 7, '200', '1000', '0.5', 0, 0, 1, 0),
+			(3, 7, '0', '200', '0.1', 0, 0, 0, 0)`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	mgr := NewAggregatorManager(db, alert.NewCommonAlerter(0, 0))
+	mgr.SetDialect(SQLiteDialect)
+	if err := mgr.LoadAll(); err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	if configs := len(mgr.aggregatorConfigs); configs != 1 {
+		t.Fatalf("expected the enabled aggregator only, got %d", configs)
+	}
+	if routes := mgr.GetRoutesByChainPairAndSymbol(42161, 8453, "USDC"); len(routes) != 1 || routes[0].ID != 7 {
+		t.Fatalf("unexpected routes %+v", routes)
+	}
+	segments := mgr.feeSegmentsByRouteID[7]
+	if len(segments) != 2 || segments[0].ID != 2 || segments[1].ID != 1 {
+		t.Fatalf("fee segments should be the enabled ones in numeric order: %+v", segments)
+	}
+
+	dstTxMgr := NewDstTxManager(db, alert.NewCommonAlerter(0, 0))
+	dstTxMgr.SetDialect(SQLiteDialect)
+	tx := &DstTx{SrcAction: "bridge", SrcId: 1, SrcVersion: 1, Sender: 2, Body: "{}"}
+	for i := 0; i < 2; i++ {
+		if err := dstTxMgr.Save(tx); err != nil {
+			t.Fatalf("Save %d: %v", i, err)
+		}
+	}
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM t_dst_transaction").Scan(&count); err != nil || count != 1 {
+		t.Fatalf("duplicate dst transactions should be ignored: %d rows, %v", count, err)
+	}
+	if !dstTxMgr.IsDstTxExist(1, "bridge", 1) || dstTxMgr.IsDstTxExist(1, "bridge", 2) {
+		t.Fatal("IsDstTxExist should find the saved transaction only")
+	}
+}
+
+func TestQueryError(t *testing.T) {
+	if err := fmt.Errorf("select t_account: %w", queryError(nil)); !errors.Is(err, errNilRows) || err.Error() != "select t_account: query returned no rows" {
+		t.Fatalf("unexpected error for nil rows: %v", err)
+	}
+	cause := errors.New("connection refused")
+	if err := queryError(cause); err != cause {
+		t.Fatalf("queryError should keep the query error, got %v", err)
+	}
+}
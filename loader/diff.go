@@ -0,0 +1,105 @@
+package loader
+
+import (
+	"runtime/debug"
+	"sync"
+
+	"github.com/owlto-dao/utils-go/log"
+)
+
+// Change holds the previous and the reloaded value of a modified entry.
+type Change[V any] struct {
+	Old V
+	New V
+}
+
+// Diff describes how the entries of a manager changed between two loads.
+type Diff[K comparable, V any] struct {
+	Added    map[K]V
+	Removed  map[K]V
+	Modified map[K]Change[V]
+}
+
+// IsEmpty reports whether nothing changed.
+func (d Diff[K, V]) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Modified) == 0
+}
+
+// ChainInfoDiff is keyed by ChainInfo.Id.
+type ChainInfoDiff = Diff[int64, *ChainInfo]
+
+// TokenInfoDiff is keyed by TokenInfoKey.
+type TokenInfoDiff = Diff[string, *TokenInfo]
+
+// BlacklistAddressDiff is keyed by the normalized blacklisted address.
+type BlacklistAddressDiff = Diff[string, *BlacklistAddress]
+
+func computeDiff[K comparable, V any](oldEntries map[K]V, newEntries map[K]V, equal func(a V, b V) bool) Diff[K, V] {
+	diff := Diff[K, V]{
+		Added:    make(map[K]V),
+		Removed:  make(map[K]V),
+		Modified: make(map[K]Change[V]),
+	}
+	for key, newValue := range newEntries {
+		oldValue, ok := oldEntries[key]
+		if !ok {
+			diff.Added[key] = newValue
+		} else if !equal(oldValue, newValue) {
+			diff.Modified[key] = Change[V]{Old: oldValue, New: newValue}
+		}
+	}
+	for key, oldValue := range oldEntries {
+		if _, ok := newEntries[key]; !ok {
+			diff.Removed[key] = oldValue
+		}
+	}
+	return diff
+}
+
+// subscribers fans a reload diff out to the registered callbacks.
+type subscribers[T any] struct {
+	nextId int
+	fns    map[int]func(T)
+	mutex  *sync.Mutex
+}
+
+func newSubscribers[T any]() *subscribers[T] {
+	return &subscribers[T]{
+		fns:   make(map[int]func(T)),
+		mutex: &sync.Mutex{},
+	}
+}
+
+func (s *subscribers[T]) subscribe(fn func(T)) func() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	id := s.nextId
+	s.nextId++
+	s.fns[id] = fn
+	return func() {
+		s.mutex.Lock()
+		delete(s.fns, id)
+		s.mutex.Unlock()
+	}
+}
+
+// notify calls every subscriber synchronously, a panicking subscriber does not affect the others.
+func (s *subscribers[T]) notify(value T) {
+	s.mutex.Lock()
+	fns := make([]func(T), 0, len(s.fns))
+	for _, fn := range s.fns {
+		fns = append(fns, fn)
+	}
+	s.mutex.Unlock()
+
+	for _, fn := range fns {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Errorf("loader change subscriber panic: %v, stack: %s", r, string(debug.Stack()))
+				}
+			}()
+			fn(value)
+		}()
+	}
+}
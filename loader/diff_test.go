@@ -0,0 +1,48 @@
+package loader
+
+import (
+	"testing"
+)
+
+func TestComputeChainInfoDiff(t *testing.T) {
+	oldChains := map[int64]*ChainInfo{
+		1: {Id: 1, Name: "Ethereum", RpcEndPoint: "https://eth.example", Client: "old client"},
+		2: {Id: 2, Name: "Arbitrum", RpcEndPoint: "https://arb.example"},
+		3: {Id: 3, Name: "Optimism"},
+	}
+	newChains := map[int64]*ChainInfo{
+		1: {Id: 1, Name: "Ethereum", RpcEndPoint: "https://eth.example", Client: "new client"},
+		2: {Id: 2, Name: "Arbitrum", RpcEndPoint: "https://arb.example", Disabled: 1},
+		4: {Id: 4, Name: "Base"},
+	}
+
+	diff := computeDiff(oldChains, newChains, chainInfoEqual)
+	if len(diff.Added) != 1 || diff.Added[4] == nil {
+		t.Fatalf("unexpected added chains: %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[3] == nil {
+		t.Fatalf("unexpected removed chains: %v", diff.Removed)
+	}
+	if len(diff.Modified) != 1 {
+		t.Fatalf("unexpected modified chains: %v", diff.Modified)
+	}
+	change := diff.Modified[2]
+	if change.Old.Disabled != 0 || change.New.Disabled != 1 {
+		t.Fatalf("unexpected change: %+v", change)
+	}
+}
+
+func TestSubscribersNotify(t *testing.T) {
+	subs := newSubscribers[ChainInfoDiff]()
+	var calls int
+	unsubscribe := subs.subscribe(func(ChainInfoDiff) { calls++ })
+	subs.subscribe(func(ChainInfoDiff) { panic("broken subscriber") })
+
+	subs.notify(ChainInfoDiff{})
+	unsubscribe()
+	subs.notify(ChainInfoDiff{})
+
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
@@ -3,6 +3,7 @@ package loader
 import (
 	"database/sql"
 	"strings"
+	"sync"
 
 	"github.com/owlto-dao/utils-go/alert"
 )
@@ -27,18 +28,33 @@ type TxGen struct {
 
 type DstTxManager struct {
 	db      *sql.DB
+	dialect Dialect
 	alerter alert.Alerter
-	//mutex   *sync.RWMutex
+	mutex   *sync.RWMutex
 }
 
 func NewDstTxManager(db *sql.DB, alerter alert.Alerter) *DstTxManager {
 	return &DstTxManager{
 		db:      db,
+		dialect: MySQLDialect,
 		alerter: alerter,
-		//mutex:   &sync.RWMutex{},
+		mutex:   &sync.RWMutex{},
 	}
 }
 
+// SetDialect sets the SQL dialect of db, MySQL by default.
+func (mgr *DstTxManager) SetDialect(dialect Dialect) {
+	mgr.mutex.Lock()
+	mgr.dialect = dialect
+	mgr.mutex.Unlock()
+}
+
+func (mgr *DstTxManager) getDialect() Dialect {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	return mgr.dialect
+}
+
 func (mgr *DstTxManager) GetDoneTxGenBySrc(srcId int64, action string, version int32) *TxGen {
 	genId := mgr.GetDstTxConfirmGen(srcId, action, version)
 	if genId == 0 {
@@ -49,7 +65,7 @@ func (mgr *DstTxManager) GetDoneTxGenBySrc(srcId int64, action string, version i
 
 func (mgr *DstTxManager) GetDoneTxGen(genId int64) *TxGen {
 	var gen TxGen
-	err := mgr.db.QueryRow("SELECT id,hash, confirmed_success FROM t_dst_transaction_gen where id = ? and confirmed_success is not null", genId).Scan(&gen.Id, &gen.Hash, &gen.ConfirmedSuccess)
+	err := mgr.db.QueryRow(mgr.getDialect().Rebind("SELECT id,hash, confirmed_success FROM t_dst_transaction_gen where id = ? and confirmed_success is not null"), genId).Scan(&gen.Id, &gen.Hash, &gen.ConfirmedSuccess)
 	if err != nil {
 		return nil
 	}
@@ -59,13 +75,13 @@ func (mgr *DstTxManager) GetDoneTxGen(genId int64) *TxGen {
 
 func (mgr *DstTxManager) IsDstTxExist(srcId int64, action string, version int32) bool {
 	var id int64
-	err := mgr.db.QueryRow("SELECT id FROM t_dst_transaction where src_action = ? and src_id = ? and src_version = ?", strings.TrimSpace(action), srcId, version).Scan(&id)
+	err := mgr.db.QueryRow(mgr.getDialect().Rebind("SELECT id FROM t_dst_transaction where src_action = ? and src_id = ? and src_version = ?"), strings.TrimSpace(action), srcId, version).Scan(&id)
 	return err == nil
 }
 
 func (mgr *DstTxManager) GetDstTxConfirmGen(srcId int64, action string, version int32) int64 {
 	var genId int64 = 0
-	err := mgr.db.QueryRow("SELECT confirmed_gen FROM t_dst_transaction where src_action = ? and src_id = ? and src_version = ? and confirmed_gen is not null", strings.TrimSpace(action), srcId, version).Scan(&genId)
+	err := mgr.db.QueryRow(mgr.getDialect().Rebind("SELECT confirmed_gen FROM t_dst_transaction where src_action = ? and src_id = ? and src_version = ? and confirmed_gen is not null"), strings.TrimSpace(action), srcId, version).Scan(&genId)
 	if err != nil {
 		return 0
 	}
@@ -80,8 +96,7 @@ func (mgr *DstTxManager) Save(tx *DstTx) error {
 	tx.TransferRecipient.String = strings.TrimSpace(tx.TransferRecipient.String)
 	tx.TransferAmount.String = strings.TrimSpace(tx.TransferAmount.String)
 
-	query := `INSERT IGNORE INTO t_dst_transaction (src_action, src_id, src_version, sender, body, fee_cap, transfer_token, transfer_recipient, transfer_amount)
-              VALUES (?, ?, ?, ?, ?, ?, ? , ?, ?)`
+	query := mgr.getDialect().InsertIgnore("t_dst_transaction", "src_action", "src_id", "src_version", "sender", "body", "fee_cap", "transfer_token", "transfer_recipient", "transfer_amount")
 
 	// Execute the SQL statement with tx data
 	_, err := mgr.db.Exec(query, tx.SrcAction, tx.SrcId, tx.SrcVersion, tx.Sender, tx.Body, tx.FeeCap, tx.TransferToken, tx.TransferRecipient, tx.TransferAmount)
@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"math/big"
 	"strconv"
 	"strings"
@@ -9,6 +11,7 @@ import (
 
 	"github.com/owlto-dao/utils-go/alert"
 	"github.com/owlto-dao/utils-go/util"
+	"github.com/shopspring/decimal"
 )
 
 type Dtc struct {
@@ -32,6 +35,39 @@ type Dtc struct {
 	AmountLv2Str string
 	AmountLv3Str string
 	AmountLv4Str string
+
+	Schedule *TierSchedule `json:"-"` // Built from the Lv columns, values are the dtc amounts
+}
+
+// buildSchedule builds the schedule of the Lv columns. AmountLv4 is unused, the fourth tier is unbounded,
+// and an amount equal to a breakpoint belongs to the lower tier.
+func (dtc *Dtc) buildSchedule() error {
+	amountStrs := []string{dtc.AmountLv1Str, dtc.AmountLv2Str, dtc.AmountLv3Str}
+	amounts := []float64{dtc.AmountLv1, dtc.AmountLv2, dtc.AmountLv3}
+	breakpoints := make([]decimal.Decimal, len(amounts))
+	for i := range amounts {
+		breakpoint, err := tierAmount(amountStrs[i], amounts[i])
+		if err != nil {
+			return fmt.Errorf("amount lv%d: %w", i+1, err)
+		}
+		breakpoints[i] = breakpoint
+	}
+	dtcStrs := []string{dtc.DtcLv1Str, dtc.DtcLv2Str, dtc.DtcLv3Str, dtc.DtcLv4Str}
+	dtcs := []float64{dtc.DtcLv1, dtc.DtcLv2, dtc.DtcLv3, dtc.DtcLv4}
+	values := make([]decimal.Decimal, len(dtcs))
+	for i := range dtcs {
+		value, err := tierAmount(dtcStrs[i], dtcs[i])
+		if err != nil {
+			return fmt.Errorf("dtc lv%d: %w", i+1, err)
+		}
+		values[i] = value
+	}
+	schedule, err := NewTierSchedule(breakpoints, values, true)
+	if err != nil {
+		return err
+	}
+	dtc.Schedule = schedule
+	return nil
 }
 
 type DtcManager struct {
@@ -74,17 +110,23 @@ func (mgr *DtcManager) GetDtc(token string, from string, to string) (*Dtc, bool)
 }
 
 func (mgr *DtcManager) LoadAllDtc() {
+	mgr.Load()
+}
+
+// Load reloads t_dynamic_dtc and returns the number of dtc rows loaded.
+func (mgr *DtcManager) Load() (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT token_name, from_chain, to_chain, dtc_lv1, dtc_lv2, dtc_lv3, dtc_lv4, amount_lv1, amount_lv2, amount_lv3, amount_lv4 FROM t_dynamic_dtc")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_dynamic_dtc error", err)
-		return
+		return 0, fmt.Errorf("select t_dynamic_dtc: %w", err)
 	}
 
 	defer rows.Close()
 
-	tokenFromToDtcs := make(map[string]map[string]map[string]*Dtc)
+	dtcs := make([]*Dtc, 0)
 	counter := 0
 
 	// Iterate over the result set
@@ -149,18 +191,12 @@ func (mgr *DtcManager) LoadAllDtc() {
 			dtc.AmountLv3 = amount3
 			dtc.AmountLv4 = amount4
 
-			ftInfos, ok := tokenFromToDtcs[strings.ToLower(dtc.TokenName)]
-			if !ok {
-				ftInfos = make(map[string]map[string]*Dtc)
-				tokenFromToDtcs[strings.ToLower(dtc.TokenName)] = ftInfos
-			}
-			infos, ok := ftInfos[strings.ToLower(dtc.FromChainName)]
-			if !ok {
-				infos = make(map[string]*Dtc)
-				ftInfos[strings.ToLower(dtc.FromChainName)] = infos
+			if err := dtc.buildSchedule(); err != nil {
+				mgr.alerter.AlertText("t_dynamic_dtc tier schedule error", err)
+				continue
 			}
-			infos[strings.ToLower(dtc.ToChainName)] = &dtc
 
+			dtcs = append(dtcs, &dtc)
 			counter++
 		}
 	}
@@ -168,7 +204,27 @@ func (mgr *DtcManager) LoadAllDtc() {
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_dynamic_dtc row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_dynamic_dtc: %w", err)
+	}
+
+	mgr.setDtcs(dtcs)
+	return counter, nil
+}
+
+func (mgr *DtcManager) setDtcs(dtcs []*Dtc) {
+	tokenFromToDtcs := make(map[string]map[string]map[string]*Dtc)
+	for _, dtc := range dtcs {
+		ftInfos, ok := tokenFromToDtcs[strings.ToLower(dtc.TokenName)]
+		if !ok {
+			ftInfos = make(map[string]map[string]*Dtc)
+			tokenFromToDtcs[strings.ToLower(dtc.TokenName)] = ftInfos
+		}
+		infos, ok := ftInfos[strings.ToLower(dtc.FromChainName)]
+		if !ok {
+			infos = make(map[string]*Dtc)
+			ftInfos[strings.ToLower(dtc.FromChainName)] = infos
+		}
+		infos[strings.ToLower(dtc.ToChainName)] = dtc
 	}
 
 	mgr.mutex.Lock()
@@ -176,6 +232,36 @@ func (mgr *DtcManager) LoadAllDtc() {
 	mgr.mutex.Unlock()
 }
 
+func (mgr *DtcManager) SnapshotName() string {
+	return "dtc"
+}
+
+func (mgr *DtcManager) ExportSnapshot() (json.RawMessage, error) {
+	dtcs := make([]*Dtc, 0)
+	for _, ftInfos := range mgr.GetDtcs() {
+		for _, infos := range ftInfos {
+			for _, dtc := range infos {
+				dtcs = append(dtcs, dtc)
+			}
+		}
+	}
+	return exportRows(dtcs)
+}
+
+func (mgr *DtcManager) ImportSnapshot(data json.RawMessage) error {
+	dtcs, err := importRows[*Dtc](data)
+	if err != nil {
+		return err
+	}
+	for _, dtc := range dtcs {
+		if err := dtc.buildSchedule(); err != nil {
+			return fmt.Errorf("dtc %s %s %s: %w", dtc.TokenName, dtc.FromChainName, dtc.ToChainName, err)
+		}
+	}
+	mgr.setDtcs(dtcs)
+	return nil
+}
+
 func (mgr *DtcManager) GetIncludedDtc(tokenName string, fromChainName string, toChainName string, value float64) (float64, string, bool) {
 	dtc, ok := mgr.GetDtc(tokenName, fromChainName, toChainName)
 	if !ok {
@@ -230,38 +316,28 @@ func (mgr *DtcManager) FromUiString(amount string, dtc string, decimals int32) *
 	return value
 }
 
+// GetIncludedDtcBigInt returns the dtc of value, an amount the dtc is included in.
 func (mgr *DtcManager) GetIncludedDtcBigInt(tokenName string, fromChainName string, toChainName string, value *big.Int, decimals int32) (*big.Int, bool) {
 	dtc, ok := mgr.GetDtc(tokenName, fromChainName, toChainName)
-	if !ok {
+	if !ok || dtc.Schedule == nil {
 		return nil, false
 	}
 
-	if value.Cmp(mgr.FromUiString(dtc.AmountLv1Str, dtc.DtcLv1Str, decimals)) <= 0 {
-		return mgr.FromUiString("", dtc.DtcLv1Str, decimals), true
-	} else if value.Cmp(mgr.FromUiString(dtc.AmountLv2Str, dtc.DtcLv2Str, decimals)) <= 0 {
-		return mgr.FromUiString("", dtc.DtcLv2Str, decimals), true
-	} else if value.Cmp(mgr.FromUiString(dtc.AmountLv3Str, dtc.DtcLv3Str, decimals)) <= 0 {
-		return mgr.FromUiString("", dtc.DtcLv3Str, decimals), true
-	} else {
-		return mgr.FromUiString("", dtc.DtcLv4Str, decimals), true
-	}
+	amount := decimal.NewFromBigInt(value, -decimals)
+	included := dtc.Schedule.IncludedValueOf(func(dtcValue decimal.Decimal) decimal.Decimal {
+		return amount.Sub(dtcValue)
+	})
+	return included.Shift(decimals).BigInt(), true
 }
 
+// GetDtcToIncludeBigInt returns the dtc of value, an amount the dtc is charged on top of.
 func (mgr *DtcManager) GetDtcToIncludeBigInt(tokenName string, fromChainName string, toChainName string, value *big.Int, decimals int32) (*big.Int, bool) {
 	dtc, ok := mgr.GetDtc(tokenName, fromChainName, toChainName)
-	if !ok {
+	if !ok || dtc.Schedule == nil {
 		return nil, false
 	}
 
-	if value.Cmp(mgr.FromUiString(dtc.AmountLv1Str, "", decimals)) <= 0 {
-		return mgr.FromUiString("", dtc.DtcLv1Str, decimals), true
-	} else if value.Cmp(mgr.FromUiString(dtc.AmountLv2Str, "", decimals)) <= 0 {
-		return mgr.FromUiString("", dtc.DtcLv2Str, decimals), true
-	} else if value.Cmp(mgr.FromUiString(dtc.AmountLv3Str, "", decimals)) <= 0 {
-		return mgr.FromUiString("", dtc.DtcLv3Str, decimals), true
-	} else {
-		return mgr.FromUiString("", dtc.DtcLv4Str, decimals), true
-	}
+	return dtc.Schedule.ValueOf(decimal.NewFromBigInt(value, -decimals)).Shift(decimals).BigInt(), true
 }
 
 func (mgr *DtcManager) GetMinValueIncludeGasFee(tokenName string, fromChainName string, toChainName string, decimals int32) (string, bool) {
@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -59,18 +61,22 @@ func (mgr *ExchangeInfoManager) GetExchangeInfoByName(name string) (*ExchangeInf
 }
 
 func (mgr *ExchangeInfoManager) LoadAllExchanges() {
+	mgr.Load()
+}
+
+// Load reloads t_exchange_info and returns the number of exchanges loaded.
+func (mgr *ExchangeInfoManager) Load() (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT id, name, icon, disabled, official_url, order_weight FROM t_exchange_info")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_exchange_info error", err)
-		return
+		return 0, fmt.Errorf("select t_exchange_info: %w", err)
 	}
 
 	defer rows.Close()
 
-	idExchanges := make(map[int32]*ExchangeInfo)
-	nameExchanges := make(map[string]*ExchangeInfo)
 	allExchanges := make([]*ExchangeInfo, 0, 100)
 	counter := 0
 
@@ -81,8 +87,6 @@ func (mgr *ExchangeInfoManager) LoadAllExchanges() {
 			mgr.alerter.AlertText("scan t_exchange_info row error", err)
 		} else {
 			xchg.Name = strings.TrimSpace(xchg.Name)
-			idExchanges[xchg.Id] = &xchg
-			nameExchanges[strings.ToLower(xchg.Name)] = &xchg
 			allExchanges = append(allExchanges, &xchg)
 			counter++
 		}
@@ -91,7 +95,19 @@ func (mgr *ExchangeInfoManager) LoadAllExchanges() {
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_exchange_info row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_exchange_info: %w", err)
+	}
+
+	mgr.setExchanges(allExchanges)
+	return counter, nil
+}
+
+func (mgr *ExchangeInfoManager) setExchanges(allExchanges []*ExchangeInfo) {
+	idExchanges := make(map[int32]*ExchangeInfo)
+	nameExchanges := make(map[string]*ExchangeInfo)
+	for _, xchg := range allExchanges {
+		idExchanges[xchg.Id] = xchg
+		nameExchanges[strings.ToLower(xchg.Name)] = xchg
 	}
 
 	mgr.mutex.Lock()
@@ -100,3 +116,20 @@ func (mgr *ExchangeInfoManager) LoadAllExchanges() {
 	mgr.allExchanges = allExchanges
 	mgr.mutex.Unlock()
 }
+
+func (mgr *ExchangeInfoManager) SnapshotName() string {
+	return "exchange_info"
+}
+
+func (mgr *ExchangeInfoManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllExchanges())
+}
+
+func (mgr *ExchangeInfoManager) ImportSnapshot(data json.RawMessage) error {
+	allExchanges, err := importRows[*ExchangeInfo](data)
+	if err != nil {
+		return err
+	}
+	mgr.setExchanges(allExchanges)
+	return nil
+}
@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -130,17 +132,22 @@ func (mgr *LpInfoManager) GetTokensByLp(version int32, from string, to string) (
 }
 
 func (mgr *LpInfoManager) LoadAllLpInfo() {
+	mgr.Load()
+}
+
+// Load reloads t_lp_info and returns the number of lp infos loaded.
+func (mgr *LpInfoManager) Load() (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT version, token_name, from_chain, to_chain, maker_address, min_value, max_value, is_disabled, bridge_fee_ratio FROM t_lp_info")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_lp_info error", err)
-		return
+		return 0, fmt.Errorf("select t_lp_info: %w", err)
 	}
 
 	defer rows.Close()
 
-	lpInfos := make(map[int32]map[string]map[string]map[string]map[string]*LpInfo)
 	allLpInfos := make([]*LpInfo, 0, 100)
 	counter := 0
 
@@ -176,28 +183,6 @@ func (mgr *LpInfoManager) LoadAllLpInfo() {
 			info.MaxValue = max
 			info.BridgeFeeRatio = bdgfee
 
-			versions, ok := lpInfos[info.Version]
-			if !ok {
-				versions = make(map[string]map[string]map[string]map[string]*LpInfo)
-				lpInfos[info.Version] = versions
-			}
-
-			ftInfos, ok := versions[strings.ToLower(info.TokenName)]
-			if !ok {
-				ftInfos = make(map[string]map[string]map[string]*LpInfo)
-				versions[strings.ToLower(info.TokenName)] = ftInfos
-			}
-			infos, ok := ftInfos[strings.ToLower(info.FromChainName)]
-			if !ok {
-				infos = make(map[string]map[string]*LpInfo)
-				ftInfos[strings.ToLower(info.FromChainName)] = infos
-			}
-			makers, ok := infos[strings.ToLower(info.ToChainName)]
-			if !ok {
-				makers = make(map[string]*LpInfo)
-				infos[strings.ToLower(info.ToChainName)] = makers
-			}
-			makers[strings.ToLower(info.MakerAddress)] = &info
 			allLpInfos = append(allLpInfos, &info)
 			counter++
 		}
@@ -206,7 +191,38 @@ func (mgr *LpInfoManager) LoadAllLpInfo() {
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_lp_info row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_lp_info: %w", err)
+	}
+
+	mgr.setLpInfos(allLpInfos)
+	return counter, nil
+}
+
+func (mgr *LpInfoManager) setLpInfos(allLpInfos []*LpInfo) {
+	lpInfos := make(map[int32]map[string]map[string]map[string]map[string]*LpInfo)
+	for _, info := range allLpInfos {
+		versions, ok := lpInfos[info.Version]
+		if !ok {
+			versions = make(map[string]map[string]map[string]map[string]*LpInfo)
+			lpInfos[info.Version] = versions
+		}
+
+		ftInfos, ok := versions[strings.ToLower(info.TokenName)]
+		if !ok {
+			ftInfos = make(map[string]map[string]map[string]*LpInfo)
+			versions[strings.ToLower(info.TokenName)] = ftInfos
+		}
+		infos, ok := ftInfos[strings.ToLower(info.FromChainName)]
+		if !ok {
+			infos = make(map[string]map[string]*LpInfo)
+			ftInfos[strings.ToLower(info.FromChainName)] = infos
+		}
+		makers, ok := infos[strings.ToLower(info.ToChainName)]
+		if !ok {
+			makers = make(map[string]*LpInfo)
+			infos[strings.ToLower(info.ToChainName)] = makers
+		}
+		makers[strings.ToLower(info.MakerAddress)] = info
 	}
 
 	mgr.mutex.Lock()
@@ -214,3 +230,20 @@ func (mgr *LpInfoManager) LoadAllLpInfo() {
 	mgr.allLpInfos = allLpInfos
 	mgr.mutex.Unlock()
 }
+
+func (mgr *LpInfoManager) SnapshotName() string {
+	return "lp_info"
+}
+
+func (mgr *LpInfoManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllLpInfos())
+}
+
+func (mgr *LpInfoManager) ImportSnapshot(data json.RawMessage) error {
+	lpInfos, err := importRows[*LpInfo](data)
+	if err != nil {
+		return err
+	}
+	mgr.setLpInfos(lpInfos)
+	return nil
+}
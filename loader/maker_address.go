@@ -1,10 +1,22 @@
 package loader
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
 	"github.com/owlto-dao/utils-go/log"
 )
 
+// ErrNoMakerAddress is returned when no maker address of the env and backend can be selected.
+var ErrNoMakerAddress = errors.New("no maker address available")
+
 type MakerAddressGroupPO struct {
 	Id        int64
 	GroupName string
@@ -31,7 +43,8 @@ type MakerAddressManager struct {
 	envGroup              map[string][]*MakerAddress
 	backendAddressToGroup map[Backend]map[string]int64
 
-	db *sql.DB
+	db    *sql.DB
+	mutex *sync.RWMutex
 }
 
 func NewMakerAddressManager(db *sql.DB) *MakerAddressManager {
@@ -40,15 +53,27 @@ func NewMakerAddressManager(db *sql.DB) *MakerAddressManager {
 		envGroup:              make(map[string][]*MakerAddress),
 		backendAddressToGroup: make(map[Backend]map[string]int64),
 		db:                    db,
+		mutex:                 &sync.RWMutex{},
 	}
 }
 
+func normalizeMakerEnv(env string) string {
+	return strings.ToLower(strings.TrimSpace(env))
+}
+
 func (mgr *MakerAddressManager) LoadAllMakerAddresses() {
+	mgr.Load()
+}
+
+// Load reloads t_maker_address_groups, t_maker_addresses and t_security_addresses
+// and returns the number of maker address groups loaded.
+func (mgr *MakerAddressManager) Load() (int, error) {
 	// Query the database for all maker address groups
 	groupRows, err := mgr.db.Query("SELECT id, group_name, env FROM t_maker_address_groups")
 	if err != nil || groupRows == nil {
+		err = queryError(err)
 		log.Errorf("select maker_address_groups error: %v", err)
-		return
+		return 0, fmt.Errorf("select t_maker_address_groups: %w", err)
 	}
 	defer groupRows.Close()
 
@@ -72,14 +97,15 @@ func (mgr *MakerAddressManager) LoadAllMakerAddresses() {
 	// Check for errors from iterating over rows
 	if err = groupRows.Err(); err != nil {
 		log.Errorf("get next maker_address_groups row error: %v", err)
-		return
+		return 0, fmt.Errorf("iterate t_maker_address_groups: %w", err)
 	}
 
 	// Query the database for all maker addresses
 	addressRows, err := mgr.db.Query("SELECT id, group_id, backend, address FROM t_maker_addresses")
 	if err != nil || addressRows == nil {
+		err = queryError(err)
 		log.Errorf("select maker_addresses error: %v", err)
-		return
+		return 0, fmt.Errorf("select t_maker_addresses: %w", err)
 	}
 	defer addressRows.Close()
 
@@ -100,19 +126,20 @@ func (mgr *MakerAddressManager) LoadAllMakerAddresses() {
 		if _, ok := backendAddressToGroup[address.Backend]; !ok {
 			backendAddressToGroup[address.Backend] = make(map[string]int64)
 		}
-		backendAddressToGroup[address.Backend][address.Address] = address.GroupId
+		backendAddressToGroup[address.Backend][CanonicalAddress(address.Backend, address.Address)] = address.GroupId
 	}
 
 	if err = addressRows.Err(); err != nil {
 		log.Errorf("get next maker_addresses row error: %v", err)
-		return
+		return 0, fmt.Errorf("iterate t_maker_addresses: %w", err)
 	}
 
 	// Query the database for all security addresses
 	securityAddressRows, err := mgr.db.Query("SELECT id, group_id, backend, address FROM t_security_addresses")
 	if err != nil || securityAddressRows == nil {
+		err = queryError(err)
 		log.Errorf("select security_addresses error: %v", err)
-		return
+		return 0, fmt.Errorf("select t_security_addresses: %w", err)
 	}
 	defer securityAddressRows.Close()
 
@@ -130,31 +157,211 @@ func (mgr *MakerAddressManager) LoadAllMakerAddresses() {
 
 	if err = securityAddressRows.Err(); err != nil {
 		log.Errorf("get next security_addresses row error: %v", err)
-		return
+		return 0, fmt.Errorf("iterate t_security_addresses: %w", err)
 	}
 
-	mgr.groupIdAddress = groups
+	mgr.setGroups(groups, backendAddressToGroup)
+	return len(groups), nil
+}
+
+func (mgr *MakerAddressManager) setGroups(groups map[int64]*MakerAddress, backendAddressToGroup map[Backend]map[string]int64) {
 	envGroup := make(map[string][]*MakerAddress)
 	for _, group := range groups {
-		envGroup[group.Env] = append(envGroup[group.Env], group)
+		env := normalizeMakerEnv(group.Env)
+		envGroup[env] = append(envGroup[env], group)
 	}
+	for _, envGroups := range envGroup {
+		sort.Slice(envGroups, func(i, j int) bool { return envGroups[i].GroupId < envGroups[j].GroupId })
+	}
+
+	mgr.mutex.Lock()
+	mgr.groupIdAddress = groups
 	mgr.envGroup = envGroup
 	mgr.backendAddressToGroup = backendAddressToGroup
+	mgr.mutex.Unlock()
+}
+
+func (mgr *MakerAddressManager) SnapshotName() string {
+	return "maker_address"
+}
+
+// ExportSnapshot exports the groups with their maker and security addresses embedded.
+func (mgr *MakerAddressManager) ExportSnapshot() (json.RawMessage, error) {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	groups := make([]*MakerAddress, 0, len(mgr.groupIdAddress))
+	for _, group := range mgr.groupIdAddress {
+		groups = append(groups, group)
+	}
+	return exportRows(groups)
+}
+
+func (mgr *MakerAddressManager) ImportSnapshot(data json.RawMessage) error {
+	rows, err := importRows[*MakerAddress](data)
+	if err != nil {
+		return err
+	}
+
+	groups := make(map[int64]*MakerAddress, len(rows))
+	backendAddressToGroup := make(map[Backend]map[string]int64)
+	for _, group := range rows {
+		groups[group.GroupId] = group
+		for _, address := range group.Addresses {
+			if _, ok := backendAddressToGroup[address.Backend]; !ok {
+				backendAddressToGroup[address.Backend] = make(map[string]int64)
+			}
+			backendAddressToGroup[address.Backend][CanonicalAddress(address.Backend, address.Address)] = address.GroupId
+		}
+	}
+	mgr.setGroups(groups, backendAddressToGroup)
+	return nil
 }
 
 func (mgr *MakerAddressManager) GetMakerAddressesByEnv(env string) []*MakerAddress {
-	return mgr.envGroup[env]
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	return mgr.envGroup[normalizeMakerEnv(env)]
 }
 
 func (mgr *MakerAddressManager) GetMakerAddressByGroupId(groupId int64) *MakerAddress {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
 	return mgr.groupIdAddress[groupId]
 }
 
 func (mgr *MakerAddressManager) GetGroupIDByBackendAndAddress(backend Backend, address string) int64 {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
 	if addressMap, ok := mgr.backendAddressToGroup[backend]; ok {
-		if groupId, ok := addressMap[address]; ok {
+		if groupId, ok := addressMap[CanonicalAddress(backend, address)]; ok {
 			return groupId
 		}
 	}
 	return 0
 }
+
+// allAddresses returns the addresses of every group, security addresses included, by backend and keyed by
+// their canonical form.
+func (mgr *MakerAddressManager) allAddresses() map[Backend]map[string]bool {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	addresses := make(map[Backend]map[string]bool)
+	add := func(address *MakerAddressPO) {
+		if addresses[address.Backend] == nil {
+			addresses[address.Backend] = make(map[string]bool)
+		}
+		addresses[address.Backend][CanonicalAddress(address.Backend, address.Address)] = true
+	}
+	for _, group := range mgr.groupIdAddress {
+		for _, address := range group.Addresses {
+			add(address)
+		}
+		for _, address := range group.SecurityAddresses {
+			add(address)
+		}
+	}
+	return addresses
+}
+
+// GetMakerAddressesByEnvBackend returns the maker addresses of backend in every group of env, ordered by id.
+func (mgr *MakerAddressManager) GetMakerAddressesByEnvBackend(env string, backend Backend) []*MakerAddressPO {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	addresses := make([]*MakerAddressPO, 0)
+	for _, group := range mgr.envGroup[normalizeMakerEnv(env)] {
+		for _, address := range group.Addresses {
+			if address.Backend == backend {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool { return addresses[i].Id < addresses[j].Id })
+	return addresses
+}
+
+// SelectMakerAddress picks one of the maker addresses of env and backend with selector.
+func (mgr *MakerAddressManager) SelectMakerAddress(ctx context.Context, env string, backend Backend, selector MakerSelector) (*MakerAddressPO, error) {
+	candidates := mgr.GetMakerAddressesByEnvBackend(env, backend)
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: env %s, backend %d", ErrNoMakerAddress, env, backend)
+	}
+	return selector.Select(ctx, fmt.Sprintf("%s/%d", normalizeMakerEnv(env), backend), candidates)
+}
+
+// MakerSelector picks a maker address among candidates, key identifies the env and backend they belong to.
+type MakerSelector interface {
+	Select(ctx context.Context, key string, candidates []*MakerAddressPO) (*MakerAddressPO, error)
+}
+
+// RoundRobinSelector cycles through the candidates of each key.
+type RoundRobinSelector struct {
+	next  map[string]uint64
+	mutex sync.Mutex
+}
+
+func NewRoundRobinSelector() *RoundRobinSelector {
+	return &RoundRobinSelector{next: make(map[string]uint64)}
+}
+
+func (s *RoundRobinSelector) Select(ctx context.Context, key string, candidates []*MakerAddressPO) (*MakerAddressPO, error) {
+	if len(candidates) == 0 {
+		return nil, ErrNoMakerAddress
+	}
+	s.mutex.Lock()
+	n := s.next[key]
+	s.next[key] = n + 1
+	s.mutex.Unlock()
+	return candidates[n%uint64(len(candidates))], nil
+}
+
+// BalanceSelector picks the candidate with the highest balance. Candidates whose balance
+// cannot be read are skipped.
+type BalanceSelector struct {
+	Balance func(ctx context.Context, address *MakerAddressPO) (*big.Int, error)
+}
+
+func (s *BalanceSelector) Select(ctx context.Context, key string, candidates []*MakerAddressPO) (*MakerAddressPO, error) {
+	var best *MakerAddressPO
+	var bestBalance *big.Int
+	var lastErr error
+	for _, candidate := range candidates {
+		balance, err := s.Balance(ctx, candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if best == nil || balance.Cmp(bestBalance) > 0 {
+			best, bestBalance = candidate, balance
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNoMakerAddress, key, lastErr)
+	}
+	return best, nil
+}
+
+// PendingLoadSelector picks the candidate with the fewest pending transactions. Candidates whose
+// pending count cannot be read are skipped.
+type PendingLoadSelector struct {
+	Pending func(ctx context.Context, address *MakerAddressPO) (int, error)
+}
+
+func (s *PendingLoadSelector) Select(ctx context.Context, key string, candidates []*MakerAddressPO) (*MakerAddressPO, error) {
+	var best *MakerAddressPO
+	bestPending := 0
+	var lastErr error
+	for _, candidate := range candidates {
+		pending, err := s.Pending(ctx, candidate)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if best == nil || pending < bestPending {
+			best, bestPending = candidate, pending
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrNoMakerAddress, key, lastErr)
+	}
+	return best, nil
+}
@@ -0,0 +1,113 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func newTestMakerAddressManager(t *testing.T) *MakerAddressManager {
+	mgr := NewMakerAddressManager(nil)
+	if err := mgr.ImportSnapshot([]byte(`[
+		{"GroupId": 1, "GroupName": "hot", "Env": "Prod", "Addresses": [
+			{"Id": 1, "GroupId": 1, "Backend": 1, "Address": "0xAbC0000000000000000000000000000000000001"},
+			{"Id": 2, "GroupId": 1, "Backend": 3, "Address": "So1anaKey1111111111111111111111111111111111"}
+		]},
+		{"GroupId": 2, "GroupName": "warm", "Env": "prod", "Addresses": [
+			{"Id": 3, "GroupId": 2, "Backend": 1, "Address": "0xabc0000000000000000000000000000000000002"}
+		]}
+	]`)); err != nil {
+		t.Fatal(err)
+	}
+	return mgr
+}
+
+func TestMakerAddressNormalization(t *testing.T) {
+	mgr := newTestMakerAddressManager(t)
+	if id := mgr.GetGroupIDByBackendAndAddress(EthereumBackend, " 0xabc0000000000000000000000000000000000001"); id != 1 {
+		t.Fatalf("evm lookup should ignore case, got group %d", id)
+	}
+	if id := mgr.GetGroupIDByBackendAndAddress(SolanaBackend, "so1anakey1111111111111111111111111111111111"); id != 0 {
+		t.Fatal("solana lookup must be case-sensitive")
+	}
+	if id := mgr.GetGroupIDByBackendAndAddress(SolanaBackend, "So1anaKey1111111111111111111111111111111111"); id != 1 {
+		t.Fatalf("solana lookup failed, got group %d", id)
+	}
+	if groups := mgr.GetMakerAddressesByEnv("PROD"); len(groups) != 2 {
+		t.Fatalf("expected 2 prod groups, got %d", len(groups))
+	}
+}
+
+func TestSelectMakerAddress(t *testing.T) {
+	mgr := newTestMakerAddressManager(t)
+	ctx := context.Background()
+
+	roundRobin := NewRoundRobinSelector()
+	var picked []int64
+	for i := 0; i < 3; i++ {
+		address, err := mgr.SelectMakerAddress(ctx, "prod", EthereumBackend, roundRobin)
+		if err != nil {
+			t.Fatal(err)
+		}
+		picked = append(picked, address.Id)
+	}
+	if picked[0] != 1 || picked[1] != 3 || picked[2] != 1 {
+		t.Fatalf("unexpected round robin order %v", picked)
+	}
+
+	balances := map[int64]int64{1: 10, 3: 20}
+	address, err := mgr.SelectMakerAddress(ctx, "prod", EthereumBackend, &BalanceSelector{
+		Balance: func(ctx context.Context, address *MakerAddressPO) (*big.Int, error) {
+			return big.NewInt(balances[address.Id]), nil
+		},
+	})
+	if err != nil || address.Id != 3 {
+		t.Fatalf("expected the richest maker, got %v, %v", address, err)
+	}
+
+	address, err = mgr.SelectMakerAddress(ctx, "prod", EthereumBackend, &PendingLoadSelector{
+		Pending: func(ctx context.Context, address *MakerAddressPO) (int, error) {
+			if address.Id == 1 {
+				return 0, errors.New("rpc down")
+			}
+			return 5, nil
+		},
+	})
+	if err != nil || address.Id != 3 {
+		t.Fatalf("expected the only readable maker, got %v, %v", address, err)
+	}
+
+	if _, err := mgr.SelectMakerAddress(ctx, "test", EthereumBackend, roundRobin); !errors.Is(err, ErrNoMakerAddress) {
+		t.Fatalf("expected ErrNoMakerAddress, got %v", err)
+	}
+}
+
+func TestMakerAddressConcurrentReload(t *testing.T) {
+	mgr := newTestMakerAddressManager(t)
+	data, err := mgr.ExportSnapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if err := mgr.ImportSnapshot(data); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			mgr.GetMakerAddressesByEnv("prod")
+			mgr.GetGroupIDByBackendAndAddress(EthereumBackend, "0xabc0000000000000000000000000000000000002")
+		}
+	}()
+	wg.Wait()
+}
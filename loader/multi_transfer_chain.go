@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -41,15 +43,18 @@ func NewMultiTransferChainManager(db *sql.DB, alerter alert.Alerter) *MultiTrans
 }
 
 func (mgr *MultiTransferChainManager) LoadAllChains() {
+	mgr.Load()
+}
+
+// Load reloads t_multi_transfer_chain and returns the number of chains loaded.
+func (mgr *MultiTransferChainManager) Load() (int, error) {
 	rows, err := mgr.db.Query("SELECT id, chain_id, chain_name, backend, disabled, created_at, updated_at FROM t_multi_transfer_chain")
 	if err != nil {
 		mgr.alerter.AlertText("select t_multi_transfer_chain error", err)
-		return
+		return 0, fmt.Errorf("select t_multi_transfer_chain: %w", err)
 	}
 	defer rows.Close()
 
-	idChains := make(map[int64]*MultiTransferChain)
-	chainIdChains := make(map[int32]*MultiTransferChain)
 	allChains := make([]*MultiTransferChain, 0)
 
 	for rows.Next() {
@@ -61,14 +66,24 @@ func (mgr *MultiTransferChainManager) LoadAllChains() {
 
 		chain.ChainName = strings.TrimSpace(chain.ChainName)
 
-		idChains[chain.Id] = &chain
-		chainIdChains[chain.ChainId] = &chain
 		allChains = append(allChains, &chain)
 	}
 
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("iterate t_multi_transfer_chain rows error", err)
-		return
+		return 0, fmt.Errorf("iterate t_multi_transfer_chain: %w", err)
+	}
+
+	mgr.setChains(allChains)
+	return len(allChains), nil
+}
+
+func (mgr *MultiTransferChainManager) setChains(allChains []*MultiTransferChain) {
+	idChains := make(map[int64]*MultiTransferChain)
+	chainIdChains := make(map[int32]*MultiTransferChain)
+	for _, chain := range allChains {
+		idChains[chain.Id] = chain
+		chainIdChains[chain.ChainId] = chain
 	}
 
 	mgr.mutex.Lock()
@@ -78,6 +93,23 @@ func (mgr *MultiTransferChainManager) LoadAllChains() {
 	mgr.mutex.Unlock()
 }
 
+func (mgr *MultiTransferChainManager) SnapshotName() string {
+	return "multi_transfer_chain"
+}
+
+func (mgr *MultiTransferChainManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllChains())
+}
+
+func (mgr *MultiTransferChainManager) ImportSnapshot(data json.RawMessage) error {
+	allChains, err := importRows[*MultiTransferChain](data)
+	if err != nil {
+		return err
+	}
+	mgr.setChains(allChains)
+	return nil
+}
+
 func (mgr *MultiTransferChainManager) GetChainById(id int64) (*MultiTransferChain, bool) {
 	mgr.mutex.RLock()
 	defer mgr.mutex.RUnlock()
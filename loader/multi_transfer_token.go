@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
@@ -44,15 +46,18 @@ func NewMultiTransferTokenManager(db *sql.DB, alerter alert.Alerter) *MultiTrans
 }
 
 func (mgr *MultiTransferTokenManager) LoadAllTokens() {
+	mgr.Load()
+}
+
+// Load reloads t_multi_transfer_token and returns the number of tokens loaded.
+func (mgr *MultiTransferTokenManager) Load() (int, error) {
 	rows, err := mgr.db.Query("SELECT id, chain_name, token_name, token_address, decimals, max_value, dtc, disabled, created_at, updated_at FROM t_multi_transfer_token")
 	if err != nil {
 		mgr.alerter.AlertText("select t_multi_transfer_token error", err)
-		return
+		return 0, fmt.Errorf("select t_multi_transfer_token: %w", err)
 	}
 	defer rows.Close()
 
-	chainNameTokenAddrs := make(map[string]map[string]*MultiTransferToken)
-	chainNameTokenNames := make(map[string]map[string]*MultiTransferToken)
 	allTokens := make([]*MultiTransferToken, 0)
 
 	for rows.Next() {
@@ -68,13 +73,29 @@ func (mgr *MultiTransferTokenManager) LoadAllTokens() {
 		token.MaxValue = strings.TrimSpace(token.MaxValue)
 		token.Dtc = strings.TrimSpace(token.Dtc)
 
+		allTokens = append(allTokens, &token)
+	}
+
+	if err := rows.Err(); err != nil {
+		mgr.alerter.AlertText("iterate t_multi_transfer_token rows error", err)
+		return 0, fmt.Errorf("iterate t_multi_transfer_token: %w", err)
+	}
+
+	mgr.setTokens(allTokens)
+	return len(allTokens), nil
+}
+
+func (mgr *MultiTransferTokenManager) setTokens(allTokens []*MultiTransferToken) {
+	chainNameTokenAddrs := make(map[string]map[string]*MultiTransferToken)
+	chainNameTokenNames := make(map[string]map[string]*MultiTransferToken)
+	for _, token := range allTokens {
 		// Group by ChainName -> TokenAddress
 		tokenAddrs, ok := chainNameTokenAddrs[strings.ToLower(token.ChainName)]
 		if !ok {
 			tokenAddrs = make(map[string]*MultiTransferToken)
 			chainNameTokenAddrs[strings.ToLower(token.ChainName)] = tokenAddrs
 		}
-		tokenAddrs[strings.ToLower(token.TokenAddress)] = &token
+		tokenAddrs[strings.ToLower(token.TokenAddress)] = token
 
 		// Group by ChainName -> TokenName
 		tokenNames, ok := chainNameTokenNames[strings.ToLower(token.ChainName)]
@@ -82,14 +103,7 @@ func (mgr *MultiTransferTokenManager) LoadAllTokens() {
 			tokenNames = make(map[string]*MultiTransferToken)
 			chainNameTokenNames[strings.ToLower(token.ChainName)] = tokenNames
 		}
-		tokenNames[strings.ToLower(token.TokenName)] = &token
-
-		allTokens = append(allTokens, &token)
-	}
-
-	if err := rows.Err(); err != nil {
-		mgr.alerter.AlertText("iterate t_multi_transfer_token rows error", err)
-		return
+		tokenNames[strings.ToLower(token.TokenName)] = token
 	}
 
 	mgr.mutex.Lock()
@@ -99,6 +113,23 @@ func (mgr *MultiTransferTokenManager) LoadAllTokens() {
 	mgr.mutex.Unlock()
 }
 
+func (mgr *MultiTransferTokenManager) SnapshotName() string {
+	return "multi_transfer_token"
+}
+
+func (mgr *MultiTransferTokenManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllTokens())
+}
+
+func (mgr *MultiTransferTokenManager) ImportSnapshot(data json.RawMessage) error {
+	allTokens, err := importRows[*MultiTransferToken](data)
+	if err != nil {
+		return err
+	}
+	mgr.setTokens(allTokens)
+	return nil
+}
+
 func (mgr *MultiTransferTokenManager) GetByChainNameTokenAddr(chainName string, tokenAddr string) (*MultiTransferToken, bool) {
 	mgr.mutex.RLock()
 	defer mgr.mutex.RUnlock()
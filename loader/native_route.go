@@ -0,0 +1,98 @@
+package loader
+
+import (
+	"math/big"
+	"strings"
+)
+
+// nativeRoutes prices the Owlto Native maker routes of the lp infos for FindBestRoute.
+type nativeRoutes struct {
+	chainInfoMgr *ChainInfoManager
+	quoteEngine  *QuoteEngine
+	lpVersion    int32
+}
+
+// SetNativeRoutes makes FindBestRoute compare the Owlto Native routes of the t_lp_info version
+// against the aggregator routes, priced by quoteEngine. A nil quoteEngine turns them off.
+func (mgr *AggregatorManager) SetNativeRoutes(chainInfoMgr *ChainInfoManager, quoteEngine *QuoteEngine, lpVersion int32) {
+	var native *nativeRoutes
+	if quoteEngine != nil {
+		native = &nativeRoutes{chainInfoMgr: chainInfoMgr, quoteEngine: quoteEngine, lpVersion: lpVersion}
+	}
+	mgr.mutex.Lock()
+	mgr.native = native
+	mgr.mutex.Unlock()
+}
+
+// candidates quotes every enabled maker of the chain pair. Makers the amount cannot go through,
+// because of their limits or a missing fee, are left out.
+func (n *nativeRoutes) candidates(fromChainID, toChainID int64, tokenSymbol string, amtRat *big.Rat, decimals int32) []*BestRouteResult {
+	fromChain, ok := n.chainInfoMgr.GetChainInfoByInt64ChainId(fromChainID)
+	if !ok {
+		return nil
+	}
+	toChain, ok := n.chainInfoMgr.GetChainInfoByInt64ChainId(toChainID)
+	if !ok {
+		return nil
+	}
+	makers, ok := n.quoteEngine.lpInfoMgr.GetLpInfos(n.lpVersion, tokenSymbol, fromChain.Name, toChain.Name)
+	if !ok {
+		return nil
+	}
+	token, ok := n.quoteEngine.tokenInfoMgr.GetByChainNameTokenName(fromChain.Name, tokenSymbol)
+	if !ok {
+		return nil
+	}
+	amount := uiToWei(amtRat, token.Decimals)
+
+	candidates := make([]*BestRouteResult, 0, len(makers))
+	for _, lpInfo := range makers {
+		if lpInfo.IsDisabled != 0 {
+			continue
+		}
+		quote, err := n.quoteEngine.Quote(QuoteRequest{
+			Version:       n.lpVersion,
+			TokenName:     tokenSymbol,
+			FromChainName: fromChain.Name,
+			ToChainName:   toChain.Name,
+			MakerAddress:  lpInfo.MakerAddress,
+			Amount:        amount,
+			Mode:          QuoteAmountIncludesFees,
+		})
+		if err != nil {
+			continue
+		}
+
+		// Compare in the decimals of the caller, which may differ from t_token_info.
+		fee := convertDecimals(quote.TotalFee, token.Decimals, decimals, true)
+		candidates = append(candidates, &BestRouteResult{
+			Aggregator: AggregatorNone,
+			Route: &RouteConfig{
+				AggregateID:      AggregatorNone,
+				FromChainID:      fromChainID,
+				FromChainName:    fromChain.Name,
+				FromTokenAddress: token.TokenAddress,
+				FromTokenSymbol:  strings.ToUpper(tokenSymbol),
+				ToChainID:        toChainID,
+				ToChainName:      toChain.Name,
+				ToTokenSymbol:    strings.ToUpper(tokenSymbol),
+				MinAmount:        lpInfo.MinValueStr,
+				MaxAmount:        lpInfo.MaxValueStr,
+				IsEnabled:        true,
+			},
+			FeeResult: &FeeResult{
+				BestAggregator: AggregatorNone,
+				ChannelFee:     big.NewInt(0),
+				OwltoFee:       fee,
+				TotalGasFee:    fee,
+				ProtocolFee:    big.NewInt(0),
+				ChannelFeeUI:   "0",
+				OwltoFeeUI:     weiToUIString(fee, decimals),
+				TotalGasFeeUI:  weiToUIString(fee, decimals),
+			},
+			IsAggregator: false,
+			NativeQuote:  quote,
+		})
+	}
+	return candidates
+}
@@ -0,0 +1,47 @@
+package loader
+
+import (
+	"testing"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+func TestFindBestRouteWithNativeRoutes(t *testing.T) {
+	chainInfoMgr := NewChainInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	chainInfoMgr.withoutClients = true
+	chainInfoMgr.setChains([]*ChainInfo{
+		{Id: 1, ChainId: "42161", Name: "Arbitrum", Backend: EthereumBackend},
+		{Id: 2, ChainId: "8453", Name: "Base", Backend: EthereumBackend},
+	})
+
+	mgr := NewAggregatorManager(nil, alert.NewCommonAlerter(0, 0))
+	if err := mgr.ImportSnapshot([]byte(`{
+		"AggregatorConfigs": [{"ID": 1, "Name": "across", "IsEnabled": true}],
+		"Routes": [{"ID": 7, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 8453, "FromTokenSymbol": "USDC", "IsEnabled": true}],
+		"FeeSegments": [{"ID": 1, "RouteID": 7, "MinAmountUI": "0", "MaxAmountUI": "0", "OwltoFeeFixedUI": "0.5", "IsEnabled": true}]
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	mgr.SetNativeRoutes(chainInfoMgr, newTestQuoteEngine(t), 1)
+
+	// Native: 0.5 dtc + 0.4995 bridge fee, across: 0.5 placeholder channel fee + 0.5 owlto fee.
+	best, err := mgr.FindBestRoute(42161, 8453, "USDC", "500", 6)
+	if err != nil {
+		t.Fatalf("FindBestRoute: %v", err)
+	}
+	if best.IsAggregator || best.NativeQuote == nil || best.FeeResult.TotalGasFeeUI != "0.999500" {
+		t.Fatalf("expected the native route to win, got %s %+v", best.Aggregator, best.FeeResult)
+	}
+	if len(best.Alternatives) != 1 || best.Alternatives[0].Aggregator != AggregatorAcross || best.Alternatives[0].FeeResult.TotalGasFeeUI != "1.000000" {
+		t.Fatalf("unexpected alternatives: %+v", best.Alternatives)
+	}
+
+	// Above the lp max value only the aggregator can take the transfer.
+	best, err = mgr.FindBestRoute(42161, 8453, "USDC", "6000", 6)
+	if err != nil {
+		t.Fatalf("FindBestRoute: %v", err)
+	}
+	if !best.IsAggregator || len(best.Alternatives) != 0 {
+		t.Fatalf("expected the aggregator route alone, got %s with %d alternatives", best.Aggregator, len(best.Alternatives))
+	}
+}
@@ -2,6 +2,9 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"sort"
 	"sync"
 	"time"
@@ -27,6 +30,7 @@ type NodeInfoManager struct {
 	allNodes             []*NodeInfo
 
 	db      *sql.DB
+	dialect Dialect
 	alerter alert.Alerter
 	mutex   *sync.RWMutex
 }
@@ -38,11 +42,25 @@ func NewNodeInfoManager(db *sql.DB, alerter alert.Alerter) *NodeInfoManager {
 		realChainIdTypeNodes: make(map[int64]map[int32][]*NodeInfo),
 		allNodes:             make([]*NodeInfo, 0, 64),
 		db:                   db,
+		dialect:              MySQLDialect,
 		alerter:              alerter,
 		mutex:                &sync.RWMutex{},
 	}
 }
 
+// SetDialect sets the SQL dialect of db, MySQL by default.
+func (mgr *NodeInfoManager) SetDialect(dialect Dialect) {
+	mgr.mutex.Lock()
+	mgr.dialect = dialect
+	mgr.mutex.Unlock()
+}
+
+func (mgr *NodeInfoManager) getDialect() Dialect {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	return mgr.dialect
+}
+
 func (mgr *NodeInfoManager) GetNodeInfoById(id int64) (*NodeInfo, bool) {
 	mgr.mutex.RLock()
 	defer mgr.mutex.RUnlock()
@@ -122,19 +140,22 @@ func (mgr *NodeInfoManager) GetAvailableNodeByRealChainId(realChainId int64) (*N
 }
 
 func (mgr *NodeInfoManager) LoadAllNodes() {
+	mgr.Load()
+}
+
+// Load reloads t_node_info and returns the number of nodes loaded.
+func (mgr *NodeInfoManager) Load() (int, error) {
 	rows, err := mgr.db.Query(`
 		SELECT id, update_timestamp, insert_timestamp, chain_id, real_chain_id, rpc_url, type, usability
 		FROM t_node_info
 	`)
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_node_info error", err)
-		return
+		return 0, fmt.Errorf("select t_node_info: %w", err)
 	}
 	defer rows.Close()
 
-	idNodes := make(map[int64]*NodeInfo)
-	realChainIdNodes := make(map[int64][]*NodeInfo)
-	realChainIdTypeNodes := make(map[int64]map[int32][]*NodeInfo)
 	allNodes := make([]*NodeInfo, 0, 64)
 
 	for rows.Next() {
@@ -153,18 +174,29 @@ func (mgr *NodeInfoManager) LoadAllNodes() {
 			continue
 		}
 
-		idNodes[node.Id] = &node
-		realChainIdNodes[node.RealChainId] = append(realChainIdNodes[node.RealChainId], &node)
-		if _, ok := realChainIdTypeNodes[node.RealChainId]; !ok {
-			realChainIdTypeNodes[node.RealChainId] = make(map[int32][]*NodeInfo)
-		}
-		realChainIdTypeNodes[node.RealChainId][node.Type] = append(realChainIdTypeNodes[node.RealChainId][node.Type], &node)
 		allNodes = append(allNodes, &node)
 	}
 
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("iterate t_node_info rows error", err)
-		return
+		return 0, fmt.Errorf("iterate t_node_info: %w", err)
+	}
+
+	mgr.setNodes(allNodes)
+	return len(allNodes), nil
+}
+
+func (mgr *NodeInfoManager) setNodes(allNodes []*NodeInfo) {
+	idNodes := make(map[int64]*NodeInfo)
+	realChainIdNodes := make(map[int64][]*NodeInfo)
+	realChainIdTypeNodes := make(map[int64]map[int32][]*NodeInfo)
+	for _, node := range allNodes {
+		idNodes[node.Id] = node
+		realChainIdNodes[node.RealChainId] = append(realChainIdNodes[node.RealChainId], node)
+		if _, ok := realChainIdTypeNodes[node.RealChainId]; !ok {
+			realChainIdTypeNodes[node.RealChainId] = make(map[int32][]*NodeInfo)
+		}
+		realChainIdTypeNodes[node.RealChainId][node.Type] = append(realChainIdTypeNodes[node.RealChainId][node.Type], node)
 	}
 
 	sortNodesByUsability(allNodes)
@@ -185,6 +217,107 @@ func (mgr *NodeInfoManager) LoadAllNodes() {
 	mgr.mutex.Unlock()
 }
 
+func (mgr *NodeInfoManager) SnapshotName() string {
+	return "node_info"
+}
+
+func (mgr *NodeInfoManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllNodes())
+}
+
+func (mgr *NodeInfoManager) ImportSnapshot(data json.RawMessage) error {
+	nodes, err := importRows[*NodeInfo](data)
+	if err != nil {
+		return err
+	}
+	mgr.setNodes(nodes)
+	return nil
+}
+
+// UpdateUsability persists the usability of a node to t_node_info, then applies it in memory
+// so that node rankings change without waiting for the next reload.
+func (mgr *NodeInfoManager) UpdateUsability(id int64, usability int32) error {
+	return mgr.UpdateUsabilities(map[int64]int32{id: usability})
+}
+
+// UpdateUsabilities is UpdateUsability for several nodes, by id. The usabilities persisted are applied
+// in memory at once, even when others fail.
+func (mgr *NodeInfoManager) UpdateUsabilities(usabilities map[int64]int32) error {
+	persisted := make(map[int64]int32, len(usabilities))
+	var errs []error
+	for id, usability := range usabilities {
+		_, err := mgr.db.Exec(mgr.getDialect().Rebind("UPDATE t_node_info SET usability = ? WHERE id = ?"), usability, id)
+		if err != nil {
+			mgr.alerter.AlertText("update t_node_info usability error", err)
+			errs = append(errs, fmt.Errorf("update t_node_info %d: %w", id, err))
+			continue
+		}
+		persisted[id] = usability
+	}
+	mgr.SetUsabilities(persisted)
+	return errors.Join(errs...)
+}
+
+// SetUsability changes the usability of a node in memory only, it is overwritten by the next reload.
+func (mgr *NodeInfoManager) SetUsability(id int64, usability int32) bool {
+	return mgr.SetUsabilities(map[int64]int32{id: usability}) == 1
+}
+
+// SetUsabilities is SetUsability for several nodes, by id, and returns how many nodes were found.
+// Changed nodes are copied, nodes returned before keep their usability, and only the lists holding them are sorted again.
+func (mgr *NodeInfoManager) SetUsabilities(usabilities map[int64]int32) int {
+	mgr.mutex.Lock()
+	defer mgr.mutex.Unlock()
+
+	found := 0
+	updated := make(map[int64]*NodeInfo, len(usabilities))
+	for id, usability := range usabilities {
+		node, ok := mgr.idNodes[id]
+		if !ok {
+			continue
+		}
+		found++
+		if node.Usability == usability {
+			continue
+		}
+		copied := *node
+		copied.Usability = usability
+		updated[id] = &copied
+		mgr.idNodes[id] = &copied
+	}
+	if len(updated) == 0 {
+		return found
+	}
+
+	// The lists never leave the manager, the getters return copies, so they are updated in place.
+	replace := func(nodes []*NodeInfo) {
+		for i, node := range nodes {
+			if copied, ok := updated[node.Id]; ok {
+				nodes[i] = copied
+			}
+		}
+		sortNodesByUsability(nodes)
+	}
+	type chainType struct {
+		realChainId int64
+		nodeType    int32
+	}
+	chains := make(map[int64]bool)
+	chainTypes := make(map[chainType]bool)
+	for _, node := range updated {
+		chains[node.RealChainId] = true
+		chainTypes[chainType{node.RealChainId, node.Type}] = true
+	}
+	for realChainId := range chains {
+		replace(mgr.realChainIdNodes[realChainId])
+	}
+	for key := range chainTypes {
+		replace(mgr.realChainIdTypeNodes[key.realChainId][key.nodeType])
+	}
+	replace(mgr.allNodes)
+	return found
+}
+
 func sortNodesByUsability(nodes []*NodeInfo) {
 	sort.Slice(nodes, func(i, j int) bool {
 		if nodes[i].Usability == nodes[j].Usability {
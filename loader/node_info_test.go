@@ -0,0 +1,64 @@
+package loader
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSetUsabilities(t *testing.T) {
+	mgr := NewNodeInfoManager(nil, nil)
+	mgr.setNodes([]*NodeInfo{
+		{Id: 1, RealChainId: 1, Type: 0, Usability: 100},
+		{Id: 2, RealChainId: 1, Type: 0, Usability: 50},
+		{Id: 3, RealChainId: 1, Type: 1, Usability: 80},
+		{Id: 4, RealChainId: 10, Type: 0, Usability: 90},
+	})
+	before, _ := mgr.GetNodeInfoById(1)
+	listed := mgr.GetNodeInfosByRealChainId(1)
+
+	if found := mgr.SetUsabilities(map[int64]int32{1: 0, 2: 60, 99: 10}); found != 2 {
+		t.Fatalf("found %d nodes, want 2", found)
+	}
+	if before.Usability != 100 || listed[0] != before {
+		t.Fatal("nodes returned before the update should be left untouched")
+	}
+	if best, _ := mgr.GetBestNodeByRealChainIdAndType(1, 0); best.Id != 2 || best.Usability != 60 {
+		t.Fatalf("unexpected best node %+v", best)
+	}
+	if nodes := mgr.GetNodeInfosByRealChainId(1); nodes[0].Id != 3 || nodes[1].Id != 2 || nodes[2].Id != 1 {
+		t.Fatalf("chain nodes are not sorted again: %d %d %d", nodes[0].Id, nodes[1].Id, nodes[2].Id)
+	}
+	if nodes := mgr.GetAllNodes(); nodes[0].Id != 4 || nodes[len(nodes)-1].Id != 1 {
+		t.Fatalf("all nodes are not sorted again: first %d, last %d", nodes[0].Id, nodes[len(nodes)-1].Id)
+	}
+	if !mgr.SetUsability(1, 0) || mgr.SetUsability(99, 10) {
+		t.Fatal("SetUsability should report whether the node exists")
+	}
+}
+
+func TestSetUsabilitiesDuringReload(t *testing.T) {
+	mgr := NewNodeInfoManager(nil, nil)
+	nodes := func() []*NodeInfo {
+		return []*NodeInfo{{Id: 1, RealChainId: 1, Usability: 100}, {Id: 2, RealChainId: 1, Usability: 50}}
+	}
+	mgr.setNodes(nodes())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			mgr.setNodes(nodes())
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			mgr.SetUsabilities(map[int64]int32{1: int32(i), 2: int32(200 - i)})
+		}
+	}()
+	wg.Wait()
+	if got := len(mgr.GetAllNodes()); got != 2 {
+		t.Fatalf("reload and usability updates lost nodes: %d left", got)
+	}
+}
@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -45,12 +47,18 @@ func (mgr *PopularListManager) GetPopularWeight(weights map[string]int32, chain
 }
 
 func (mgr *PopularListManager) LoadAllPopularList() {
+	mgr.Load()
+}
+
+// Load reloads t_popular_list and returns the number of rows loaded.
+func (mgr *PopularListManager) Load() (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT chain_name, popular_weight, tag FROM t_popular_list")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_popular_list error", err)
-		return
+		return 0, fmt.Errorf("select t_popular_list: %w", err)
 	}
 
 	defer rows.Close()
@@ -85,10 +93,45 @@ func (mgr *PopularListManager) LoadAllPopularList() {
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_popular_list row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_popular_list: %w", err)
+	}
+
+	mgr.mutex.Lock()
+	mgr.chainToPopularList = chainToPopularList
+	mgr.mutex.Unlock()
+	return counter, nil
+}
+
+func (mgr *PopularListManager) SnapshotName() string {
+	return "popular_list"
+}
+
+func (mgr *PopularListManager) ExportSnapshot() (json.RawMessage, error) {
+	mgr.mutex.RLock()
+	popularLists := make([]PopularList, 0, len(mgr.chainToPopularList))
+	for _, popularList := range mgr.chainToPopularList {
+		popularLists = append(popularLists, popularList)
+	}
+	mgr.mutex.RUnlock()
+	return exportRows(popularLists)
+}
+
+func (mgr *PopularListManager) ImportSnapshot(data json.RawMessage) error {
+	popularLists, err := importRows[PopularList](data)
+	if err != nil {
+		return err
+	}
+
+	chainToPopularList := make(map[string]PopularList)
+	for _, popularList := range popularLists {
+		if popularList.PopularWeight == nil {
+			popularList.PopularWeight = make(map[string]int32)
+		}
+		chainToPopularList[strings.ToLower(strings.TrimSpace(popularList.ChainName))] = popularList
 	}
 
 	mgr.mutex.Lock()
 	mgr.chainToPopularList = chainToPopularList
 	mgr.mutex.Unlock()
+	return nil
 }
@@ -0,0 +1,285 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/owlto-dao/utils-go/util"
+	"github.com/shopspring/decimal"
+)
+
+// CommissionRatioDenominator is the denominator of the channel commission ratios, they are in basis points.
+const CommissionRatioDenominator = 10000
+
+var (
+	ErrQuoteNoLp      = errors.New("no lp for the route")
+	ErrQuoteBelowMin  = errors.New("amount below the lp min value")
+	ErrQuoteAboveMax  = errors.New("amount above the lp max value")
+	ErrQuoteNoDtc     = errors.New("no dtc for the route")
+	ErrQuoteNoToken   = errors.New("token not found")
+	ErrQuoteBadAmount = errors.New("amount must be positive")
+)
+
+type QuoteMode int32
+
+const (
+	// QuoteAmountIncludesFees quotes an amount sent by the user, the fees are deducted from it.
+	QuoteAmountIncludesFees QuoteMode = iota
+	// QuoteReceiveExact quotes an amount to receive, the fees are added on top of it.
+	QuoteReceiveExact
+)
+
+// QuoteRequest describes the transfer to price. Amount is in smallest units of the source token
+// for QuoteAmountIncludesFees, and of the destination token for QuoteReceiveExact.
+type QuoteRequest struct {
+	Version        int32 // t_lp_info version
+	TokenName      string
+	FromChainName  string
+	ToChainName    string
+	MakerAddress   string
+	Amount         *big.Int
+	Mode           QuoteMode
+	ChannelId      int64 // 0 means no channel, no commission
+	ChannelTxCount int64 // Past transactions of the channel, selects the commission ratio
+}
+
+// Quote is the full price breakdown of a transfer. Every amount but ReceiveAmount is in smallest units
+// of the source token, ReceiveAmount is in smallest units of the destination token.
+type Quote struct {
+	Mode       QuoteMode
+	LpInfo     *LpInfo
+	Decimals   int32
+	ToDecimals int32
+
+	SendAmount     *big.Int // Paid by the user on the source chain
+	ReceiveAmount  *big.Int // Received on the destination chain
+	Dtc            *big.Int
+	BridgeFee      *big.Int
+	BridgeFeeRatio int64 // In 1/100000000
+	TotalFee       *big.Int
+	// Commission is the part of the fees owed to the channel, it changes neither the send nor the receive amount.
+	Commission         *big.Int
+	CommissionRatioBps int64
+
+	SendAmountUI    string
+	ReceiveAmountUI string
+	DtcUI           string
+	BridgeFeeUI     string
+	TotalFeeUI      string
+	CommissionUI    string
+}
+
+// QuoteEngine prices transfers from the lp limits, the dtc, the bridge fee and the channel commission.
+type QuoteEngine struct {
+	tokenInfoMgr  *TokenInfoManager
+	lpInfoMgr     *LpInfoManager
+	bridgeFeeMgr  *BridgeFeeManager
+	dtcMgr        *DtcManager
+	commissionMgr *ChannelCommissionRatioManager
+}
+
+// NewQuoteEngine creates a QuoteEngine, commissionMgr may be nil when no commission is paid.
+func NewQuoteEngine(tokenInfoMgr *TokenInfoManager, lpInfoMgr *LpInfoManager, bridgeFeeMgr *BridgeFeeManager, dtcMgr *DtcManager, commissionMgr *ChannelCommissionRatioManager) *QuoteEngine {
+	return &QuoteEngine{
+		tokenInfoMgr:  tokenInfoMgr,
+		lpInfoMgr:     lpInfoMgr,
+		bridgeFeeMgr:  bridgeFeeMgr,
+		dtcMgr:        dtcMgr,
+		commissionMgr: commissionMgr,
+	}
+}
+
+// Quote prices req. The dtc is taken first out of the sent amount and the bridge fee out of the rest.
+// In receive exact mode the sent amount is the smallest one whose fees, taken the same way with the tiers
+// of that amount, leave at least the requested amount, and ReceiveAmount is what it actually delivers.
+// A route without bridge fee is free of bridge fee, a route without dtc cannot be quoted.
+// The lp min and max values bound the sent amount.
+func (e *QuoteEngine) Quote(req QuoteRequest) (*Quote, error) {
+	if req.Amount == nil || req.Amount.Sign() <= 0 {
+		return nil, ErrQuoteBadAmount
+	}
+	lpInfo, ok := e.lpInfoMgr.GetLpInfo(req.Version, req.TokenName, req.FromChainName, req.ToChainName, req.MakerAddress)
+	if !ok || lpInfo.IsDisabled != 0 {
+		return nil, fmt.Errorf("%w: %s %s -> %s", ErrQuoteNoLp, req.TokenName, req.FromChainName, req.ToChainName)
+	}
+	fromToken, ok := e.tokenInfoMgr.GetByChainNameTokenName(req.FromChainName, req.TokenName)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s on %s", ErrQuoteNoToken, req.TokenName, req.FromChainName)
+	}
+	decimals := fromToken.Decimals
+	toDecimals := decimals
+	if toToken, ok := e.tokenInfoMgr.GetByChainNameTokenName(req.ToChainName, req.TokenName); ok {
+		toDecimals = toToken.Decimals
+	}
+
+	quote := &Quote{Mode: req.Mode, LpInfo: lpInfo, Decimals: decimals, ToDecimals: toDecimals}
+	switch req.Mode {
+	case QuoteAmountIncludesFees:
+		quote.SendAmount = new(big.Int).Set(req.Amount)
+	case QuoteReceiveExact:
+		send, err := e.sendAmountToReceive(req, convertDecimals(req.Amount, toDecimals, decimals, true), decimals)
+		if err != nil {
+			return nil, err
+		}
+		quote.SendAmount = send
+	default:
+		return nil, fmt.Errorf("unknown quote mode %d", req.Mode)
+	}
+	received, err := e.deductFees(req, quote, decimals)
+	if err != nil {
+		return nil, err
+	}
+	quote.ReceiveAmount = convertDecimals(received, decimals, toDecimals, false)
+
+	if err := checkLpLimits(lpInfo, quote.SendAmount, decimals); err != nil {
+		return nil, err
+	}
+
+	quote.TotalFee = new(big.Int).Add(quote.Dtc, quote.BridgeFee)
+	quote.Commission = big.NewInt(0)
+	if e.commissionMgr != nil && req.ChannelId != 0 {
+		if ratio, ok := e.commissionMgr.GetRatioByChannelidAndCount(req.ChannelId, req.ChannelTxCount); ok {
+			quote.CommissionRatioBps = ratio
+			quote.Commission.Mul(quote.TotalFee, big.NewInt(ratio))
+			quote.Commission.Quo(quote.Commission, big.NewInt(CommissionRatioDenominator))
+		}
+	}
+
+	quote.SendAmountUI = toUiString(quote.SendAmount, decimals)
+	quote.ReceiveAmountUI = toUiString(quote.ReceiveAmount, toDecimals)
+	quote.DtcUI = toUiString(quote.Dtc, decimals)
+	quote.BridgeFeeUI = toUiString(quote.BridgeFee, decimals)
+	quote.TotalFeeUI = toUiString(quote.TotalFee, decimals)
+	quote.CommissionUI = toUiString(quote.Commission, decimals)
+	return quote, nil
+}
+
+// deductFees sets the dtc and the bridge fee of quote.SendAmount and returns what is left, in source decimals.
+func (e *QuoteEngine) deductFees(req QuoteRequest, quote *Quote, decimals int32) (*big.Int, error) {
+	dtc, ok := e.dtcMgr.GetIncludedDtcBigInt(req.TokenName, req.FromChainName, req.ToChainName, quote.SendAmount, decimals)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s -> %s", ErrQuoteNoDtc, req.TokenName, req.FromChainName, req.ToChainName)
+	}
+	quote.Dtc = dtc
+	value := new(big.Int).Sub(quote.SendAmount, dtc)
+	if value.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: amount does not cover the dtc %s", ErrQuoteBelowMin, toUiString(dtc, decimals))
+	}
+	quote.BridgeFee = big.NewInt(0)
+	if _, ok := e.bridgeFeeMgr.GetBridgeFee(req.TokenName, req.FromChainName, req.ToChainName); ok {
+		quote.BridgeFeeRatio, quote.BridgeFee = e.bridgeFeeMgr.GetBridgeFeeDetail(req.TokenName, req.FromChainName, req.ToChainName, value, decimals)
+	}
+	return value.Sub(value, quote.BridgeFee), nil
+}
+
+// sendAmountToReceive returns the smallest amount to send for deductFees to leave at least received.
+// The dtc tiers split the sent amounts into ranges of a constant dtc, tried in order, and within a range
+// the bridge fee is grossed up over the amounts left once that dtc is taken.
+func (e *QuoteEngine) sendAmountToReceive(req QuoteRequest, received *big.Int, decimals int32) (*big.Int, error) {
+	dtc, ok := e.dtcMgr.GetDtc(req.TokenName, req.FromChainName, req.ToChainName)
+	if !ok || dtc.Schedule == nil {
+		return nil, fmt.Errorf("%w: %s %s -> %s", ErrQuoteNoDtc, req.TokenName, req.FromChainName, req.ToChainName)
+	}
+	bridgeFee, ok := e.bridgeFeeMgr.GetBridgeFee(req.TokenName, req.FromChainName, req.ToChainName)
+	if !ok {
+		bridgeFee = nil
+	}
+
+	maxValue := new(big.Int).Set(received)
+	if bridgeFee != nil && bridgeFee.Schedule != nil {
+		var err error
+		if maxValue, err = bridgeFee.grossUpperBound(received, decimals); err != nil {
+			return nil, err
+		}
+	}
+	maxDtc := decimal.Zero
+	for _, value := range dtc.Schedule.Values {
+		maxDtc = decimal.Max(maxDtc, value)
+	}
+	end := new(big.Int).Add(maxValue, maxDtc.Shift(decimals).Ceil().BigInt())
+	end.Add(end, big.NewInt(1))
+
+	dtcTier := func(send *big.Int) int {
+		amount := decimal.NewFromBigInt(send, -decimals)
+		return dtc.Schedule.TierIncluded(func(dtcValue decimal.Decimal) decimal.Decimal {
+			return amount.Sub(dtcValue)
+		})
+	}
+	for start := big.NewInt(1); start.Cmp(end) < 0; {
+		tier := dtcTier(start)
+		tierEnd := searchBigInt(start, end, func(send *big.Int) bool { return dtcTier(send) != tier })
+		dtcAmount := dtc.Schedule.Value(tier).Shift(decimals).BigInt()
+
+		lo := bigMax(new(big.Int).Sub(start, dtcAmount), big.NewInt(1))
+		hi := new(big.Int).Sub(tierEnd, big.NewInt(1))
+		hi.Sub(hi, dtcAmount)
+		if value := e.bridgeFeeMgr.grossUp(bridgeFee, received, lo, hi, decimals); value != nil {
+			return value.Add(value, dtcAmount), nil
+		}
+		start = tierEnd
+	}
+	return nil, fmt.Errorf("%w: no amount to send receives %s", ErrQuoteAboveMax, toUiString(received, decimals))
+}
+
+// searchBigInt returns the smallest n in [lo, hi) for which pred holds, or hi when there is none.
+// pred must be monotone, false then true.
+func searchBigInt(lo *big.Int, hi *big.Int, pred func(n *big.Int) bool) *big.Int {
+	lo, hi = new(big.Int).Set(lo), new(big.Int).Set(hi)
+	for lo.Cmp(hi) < 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if pred(mid) {
+			hi = mid
+		} else {
+			lo = mid.Add(mid, big.NewInt(1))
+		}
+	}
+	return lo
+}
+
+func bigMax(a *big.Int, b *big.Int) *big.Int {
+	if a.Cmp(b) >= 0 {
+		return new(big.Int).Set(a)
+	}
+	return new(big.Int).Set(b)
+}
+
+// checkLpLimits checks amount against the lp min and max values, a max of 0 means no upper limit.
+func checkLpLimits(lpInfo *LpInfo, amount *big.Int, decimals int32) error {
+	if lpInfo.MinValueStr != "" {
+		min, err := util.FromUiString(lpInfo.MinValueStr, decimals)
+		if err != nil {
+			return fmt.Errorf("lp min value %s: %w", lpInfo.MinValueStr, err)
+		}
+		if amount.Cmp(min) < 0 {
+			return fmt.Errorf("%w: %s < %s", ErrQuoteBelowMin, toUiString(amount, decimals), lpInfo.MinValueStr)
+		}
+	}
+	if lpInfo.MaxValueStr != "" {
+		max, err := util.FromUiString(lpInfo.MaxValueStr, decimals)
+		if err != nil {
+			return fmt.Errorf("lp max value %s: %w", lpInfo.MaxValueStr, err)
+		}
+		if max.Sign() > 0 && amount.Cmp(max) > 0 {
+			return fmt.Errorf("%w: %s > %s", ErrQuoteAboveMax, toUiString(amount, decimals), lpInfo.MaxValueStr)
+		}
+	}
+	return nil
+}
+
+// convertDecimals converts amount between token decimals, rounding up or truncating when precision is lost.
+func convertDecimals(amount *big.Int, from int32, to int32, roundUp bool) *big.Int {
+	if from == to {
+		return new(big.Int).Set(amount)
+	}
+	converted := decimal.NewFromBigInt(amount, to-from)
+	if roundUp {
+		return converted.Ceil().BigInt()
+	}
+	return converted.BigInt()
+}
+
+func toUiString(amount *big.Int, decimals int32) string {
+	return decimal.NewFromBigInt(amount, -decimals).String()
+}
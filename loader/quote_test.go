@@ -0,0 +1,135 @@
+package loader
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func newTestQuoteEngine(t *testing.T) *QuoteEngine {
+	tokenInfoMgr := NewTokenInfoManager(nil, nil)
+	tokenInfoMgr.setTokens([]*TokenInfo{
+		{Id: 1, TokenName: "USDC", ChainName: "Arbitrum", ChainId: 42161, TokenAddress: "0xaf88d065e77c8cc2239327c5edb3a432268e5831", Decimals: 6},
+		{Id: 2, TokenName: "USDC", ChainName: "Base", ChainId: 8453, TokenAddress: "0x833589fcd6edb6e08f4c7c32d4f71b54bda02913", Decimals: 6},
+	})
+
+	lpInfoMgr := NewLpInfoManager(nil, nil)
+	lpInfoMgr.setLpInfos([]*LpInfo{
+		{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", MakerAddress: "0xmaker", MinValueStr: "1", MaxValueStr: "5000"},
+	})
+
+	bridgeFee := &BridgeFee{
+		TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", KeepDecimal: 6,
+		BridgeFeeRatioLv1: 0, BridgeFeeRatioLv2: 100000, BridgeFeeRatioLv3: 50000, BridgeFeeRatioLv4: 0,
+		AmountLv1Str: "100", AmountLv2Str: "1000", AmountLv3Str: "10000", AmountLv4Str: "0",
+	}
+	dtc := &Dtc{
+		TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base",
+		DtcLv1Str: "1", DtcLv2Str: "0.5", DtcLv3Str: "0.2", DtcLv4Str: "0",
+		AmountLv1Str: "100", AmountLv2Str: "1000", AmountLv3Str: "10000", AmountLv4Str: "0",
+	}
+	if err := bridgeFee.buildSchedule(); err != nil {
+		t.Fatal(err)
+	}
+	if err := dtc.buildSchedule(); err != nil {
+		t.Fatal(err)
+	}
+	bridgeFeeMgr := NewBridgeFeeManager(nil, nil)
+	bridgeFeeMgr.setBridgeFees([]*BridgeFee{bridgeFee})
+	dtcMgr := NewDtcManager(nil, nil)
+	dtcMgr.setDtcs([]*Dtc{dtc})
+
+	commissionMgr := NewChannelCommissionRatioManager(nil, nil)
+	commissionMgr.setRatios([]ChannelCommissionRatioRow{{ChannelId: 9, TxCount: 100, Ratio: 2000}})
+
+	return NewQuoteEngine(tokenInfoMgr, lpInfoMgr, bridgeFeeMgr, dtcMgr, commissionMgr)
+}
+
+func TestQuoteAmountIncludesFees(t *testing.T) {
+	engine := newTestQuoteEngine(t)
+	quote, err := engine.Quote(QuoteRequest{
+		Version: 1, TokenName: "usdc", FromChainName: "arbitrum", ToChainName: "base", MakerAddress: "0xMaker",
+		Amount: big.NewInt(500_000000), Mode: QuoteAmountIncludesFees, ChannelId: 9, ChannelTxCount: 3,
+	})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if quote.Dtc.Int64() != 500000 || quote.BridgeFeeRatio != 100000 || quote.BridgeFee.Int64() != 499500 {
+		t.Fatalf("unexpected fees: dtc %v, ratio %d, bridge fee %v", quote.Dtc, quote.BridgeFeeRatio, quote.BridgeFee)
+	}
+	if quote.ReceiveAmount.Int64() != 499000500 || quote.ReceiveAmountUI != "499.0005" {
+		t.Fatalf("unexpected receive amount %v (%s)", quote.ReceiveAmount, quote.ReceiveAmountUI)
+	}
+	if quote.TotalFeeUI != "0.9995" || quote.Commission.Int64() != 199900 {
+		t.Fatalf("unexpected total fee %s, commission %v", quote.TotalFeeUI, quote.Commission)
+	}
+}
+
+func TestQuoteReceiveExact(t *testing.T) {
+	engine := newTestQuoteEngine(t)
+	quote, err := engine.Quote(QuoteRequest{
+		Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", MakerAddress: "0xmaker",
+		Amount: big.NewInt(499_000500), Mode: QuoteReceiveExact,
+	})
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if quote.BridgeFee.Int64() != 499499 || quote.Dtc.Int64() != 500000 || quote.SendAmount.Int64() != 499_999999 {
+		t.Fatalf("unexpected quote: bridge fee %v, dtc %v, send %v", quote.BridgeFee, quote.Dtc, quote.SendAmount)
+	}
+	if quote.Commission.Sign() != 0 {
+		t.Fatalf("no channel should mean no commission, got %v", quote.Commission)
+	}
+}
+
+func TestQuoteReceiveExactRoundTrip(t *testing.T) {
+	engine := newTestQuoteEngine(t)
+	engine.lpInfoMgr.setLpInfos([]*LpInfo{
+		{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", MakerAddress: "0xmaker", MinValueStr: "0", MaxValueStr: "0"},
+	})
+	req := QuoteRequest{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", MakerAddress: "0xmaker"}
+
+	// Amounts in every tier and around the breakpoints, where the tier of the sent amount differs from the received one
+	amounts := []int64{1, 500000, 98_000000, 98_999999, 99_000000, 99_500000, 100_000000, 500_000000,
+		997_000000, 998_500000, 999_000000, 999_499999, 999_500000, 1000_000000, 5000_123456,
+		9979_000000, 9979_800000, 9980_000000, 9999_800000, 10000_000000, 123456_789012}
+	for _, amount := range amounts {
+		req.Mode, req.Amount = QuoteReceiveExact, big.NewInt(amount)
+		quote, err := engine.Quote(req)
+		if err != nil {
+			t.Fatalf("receive exact %d: %v", amount, err)
+		}
+
+		req.Mode, req.Amount = QuoteAmountIncludesFees, quote.SendAmount
+		forward, err := engine.Quote(req)
+		if err != nil {
+			t.Fatalf("includes fees %v: %v", quote.SendAmount, err)
+		}
+		if forward.ReceiveAmount.Int64() < amount || forward.ReceiveAmount.Cmp(quote.ReceiveAmount) != 0 {
+			t.Errorf("sending %v receives %v, want %d", quote.SendAmount, forward.ReceiveAmount, amount)
+		}
+
+		req.Amount = new(big.Int).Sub(quote.SendAmount, big.NewInt(1))
+		if less, err := engine.Quote(req); err == nil && less.ReceiveAmount.Int64() >= amount {
+			t.Errorf("sending %v already receives %v, want %d", req.Amount, less.ReceiveAmount, amount)
+		}
+	}
+}
+
+func TestQuoteLimits(t *testing.T) {
+	engine := newTestQuoteEngine(t)
+	req := QuoteRequest{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", MakerAddress: "0xmaker"}
+
+	req.Amount = big.NewInt(1_000000)
+	if _, err := engine.Quote(req); !errors.Is(err, ErrQuoteBelowMin) {
+		t.Errorf("amount eaten by the dtc: %v", err)
+	}
+	req.Amount = big.NewInt(6000_000000)
+	if _, err := engine.Quote(req); !errors.Is(err, ErrQuoteAboveMax) {
+		t.Errorf("amount above max: %v", err)
+	}
+	req.ToChainName = "Linea"
+	if _, err := engine.Quote(req); !errors.Is(err, ErrQuoteNoLp) {
+		t.Errorf("unknown route: %v", err)
+	}
+}
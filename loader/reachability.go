@@ -0,0 +1,248 @@
+package loader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/shopspring/decimal"
+)
+
+// ReachSource is what carries a transfer to a destination: the lps of an LpVersion or an aggregator.
+type ReachSource struct {
+	IsAggregator bool
+	LpVersion    int32        // Set when !IsAggregator
+	Aggregator   AggregatorID // Set when IsAggregator
+}
+
+func (s ReachSource) String() string {
+	if s.IsAggregator {
+		return s.Aggregator.String()
+	}
+	return fmt.Sprintf("lp_v%d", s.LpVersion)
+}
+
+// Destination is a (chain, token) reachable from an origin through one source.
+type Destination struct {
+	ToChainId   int64
+	ToChainName string
+	TokenName   string // Received token, upper case
+	MinValue    string // UI amount, the lowest minimum of the makers or routes of the source
+	MaxValue    string // UI amount, the highest maximum of the makers or routes of the source, "0" means no upper limit
+	Source      ReachSource
+
+	fromChain     *ChainInfo
+	toChain       *ChainInfo
+	multiTransfer bool
+}
+
+// ReachFilter narrows the destinations returned by the ReachabilityIndex.
+type ReachFilter struct {
+	IncludeTestnet        bool // Keep testnet chains
+	IncludeDisabledChains bool // Keep disabled chains
+	MultiTransferOnly     bool // Only destinations enabled in t_multi_transfer_chain
+}
+
+func (f ReachFilter) accepts(dest *Destination) bool {
+	for _, chain := range []*ChainInfo{dest.fromChain, dest.toChain} {
+		if !f.IncludeTestnet && chain.IsTestnet != 0 {
+			return false
+		}
+		if !f.IncludeDisabledChains && chain.Disabled != 0 {
+			return false
+		}
+	}
+	return !f.MultiTransferOnly || dest.multiTransfer
+}
+
+type reachOrigin struct {
+	chainId   int64
+	tokenName string
+}
+
+// ReachabilityIndex answers where a token can go from a chain, over the enabled lps of every version
+// and the enabled aggregator routes. It is rebuilt from the managers by Load, register it after them.
+// Lps and routes of chains missing from t_chain_info are left out.
+type ReachabilityIndex struct {
+	chainInfoMgr          *ChainInfoManager
+	lpInfoMgr             *LpInfoManager
+	aggregatorMgr         *AggregatorManager         // Optional
+	multiTransferChainMgr *MultiTransferChainManager // Optional
+
+	destinations map[reachOrigin][]*Destination
+	mutex        *sync.RWMutex
+}
+
+func NewReachabilityIndex(chainInfoMgr *ChainInfoManager, lpInfoMgr *LpInfoManager, aggregatorMgr *AggregatorManager, multiTransferChainMgr *MultiTransferChainManager) *ReachabilityIndex {
+	return &ReachabilityIndex{
+		chainInfoMgr:          chainInfoMgr,
+		lpInfoMgr:             lpInfoMgr,
+		aggregatorMgr:         aggregatorMgr,
+		multiTransferChainMgr: multiTransferChainMgr,
+		destinations:          make(map[reachOrigin][]*Destination),
+		mutex:                 &sync.RWMutex{},
+	}
+}
+
+type reachKey struct {
+	origin    reachOrigin
+	toChainId int64
+	tokenName string
+	source    ReachSource
+}
+
+// Load rebuilds the index and returns the number of destinations indexed.
+func (idx *ReachabilityIndex) Load() (int, error) {
+	multiTransfer := make(map[int64]bool)
+	if idx.multiTransferChainMgr != nil {
+		for _, chain := range idx.multiTransferChainMgr.GetAllChains() {
+			if chain.Disabled == 0 {
+				multiTransfer[int64(chain.ChainId)] = true
+			}
+		}
+	}
+
+	entries := make(map[reachKey]*Destination)
+	add := func(from *ChainInfo, to *ChainInfo, fromToken string, toToken string, minValue string, maxValue string, source ReachSource) {
+		origin := reachOrigin{chainId: from.GetInt64ChainId(), tokenName: strings.ToUpper(strings.TrimSpace(fromToken))}
+		key := reachKey{origin: origin, toChainId: to.GetInt64ChainId(), tokenName: strings.ToUpper(strings.TrimSpace(toToken)), source: source}
+		dest, ok := entries[key]
+		if !ok {
+			entries[key] = &Destination{
+				ToChainId:     key.toChainId,
+				ToChainName:   to.Name,
+				TokenName:     key.tokenName,
+				MinValue:      minValue,
+				MaxValue:      maxValue,
+				Source:        source,
+				fromChain:     from,
+				toChain:       to,
+				multiTransfer: multiTransfer[key.toChainId],
+			}
+			return
+		}
+		dest.MinValue = lowerMin(dest.MinValue, minValue)
+		dest.MaxValue = higherMax(dest.MaxValue, maxValue)
+	}
+
+	for _, lpInfo := range idx.lpInfoMgr.GetAllLpInfos() {
+		if lpInfo.IsDisabled != 0 {
+			continue
+		}
+		from, ok := idx.chainInfoMgr.GetChainInfoByName(lpInfo.FromChainName)
+		if !ok {
+			continue
+		}
+		to, ok := idx.chainInfoMgr.GetChainInfoByName(lpInfo.ToChainName)
+		if !ok {
+			continue
+		}
+		minValue, maxValue := lpInfo.MinValueStr, lpInfo.MaxValueStr
+		if strings.TrimSpace(minValue) == "" {
+			minValue = "0"
+		}
+		if strings.TrimSpace(maxValue) == "" {
+			maxValue = "0"
+		}
+		add(from, to, lpInfo.TokenName, lpInfo.TokenName, minValue, maxValue, ReachSource{LpVersion: lpInfo.Version})
+	}
+
+	if idx.aggregatorMgr != nil {
+		for _, route := range idx.aggregatorMgr.GetAllRoutes() {
+			if !route.IsEnabled {
+				continue
+			}
+			from, ok := idx.chainInfoMgr.GetChainInfoByInt64ChainId(route.FromChainID)
+			if !ok {
+				continue
+			}
+			to, ok := idx.chainInfoMgr.GetChainInfoByInt64ChainId(route.ToChainID)
+			if !ok {
+				continue
+			}
+			toToken := route.ToTokenSymbol
+			if strings.TrimSpace(toToken) == "" {
+				toToken = route.FromTokenSymbol
+			}
+			add(from, to, route.FromTokenSymbol, toToken, route.MinAmount, route.MaxAmount, ReachSource{IsAggregator: true, Aggregator: route.AggregateID})
+		}
+	}
+
+	destinations := make(map[reachOrigin][]*Destination)
+	for key, dest := range entries {
+		destinations[key.origin] = append(destinations[key.origin], dest)
+	}
+	for _, dests := range destinations {
+		sort.Slice(dests, func(i, j int) bool {
+			if dests[i].ToChainName != dests[j].ToChainName {
+				return dests[i].ToChainName < dests[j].ToChainName
+			}
+			if dests[i].TokenName != dests[j].TokenName {
+				return dests[i].TokenName < dests[j].TokenName
+			}
+			return dests[i].Source.String() < dests[j].Source.String()
+		})
+	}
+
+	idx.mutex.Lock()
+	idx.destinations = destinations
+	idx.mutex.Unlock()
+	return len(entries), nil
+}
+
+// GetDestinations returns the destinations of token from the chain named fromChainName accepted by filter.
+func (idx *ReachabilityIndex) GetDestinations(fromChainName string, token string, filter ReachFilter) []*Destination {
+	chain, ok := idx.chainInfoMgr.GetChainInfoByName(fromChainName)
+	if !ok {
+		return nil
+	}
+	return idx.GetDestinationsByChainId(chain.GetInt64ChainId(), token, filter)
+}
+
+// GetDestinationsByChainId returns the destinations of token from fromChainId accepted by filter.
+func (idx *ReachabilityIndex) GetDestinationsByChainId(fromChainId int64, token string, filter ReachFilter) []*Destination {
+	idx.mutex.RLock()
+	dests := idx.destinations[reachOrigin{chainId: fromChainId, tokenName: strings.ToUpper(strings.TrimSpace(token))}]
+	idx.mutex.RUnlock()
+
+	accepted := make([]*Destination, 0, len(dests))
+	for _, dest := range dests {
+		if filter.accepts(dest) {
+			accepted = append(accepted, dest)
+		}
+	}
+	return accepted
+}
+
+// lowerMin returns the lowest of two UI minimums, an unparsable value loses.
+func lowerMin(a string, b string) string {
+	da, errA := decimal.NewFromString(a)
+	db, errB := decimal.NewFromString(b)
+	if errA != nil {
+		return b
+	}
+	if errB != nil || da.LessThanOrEqual(db) {
+		return a
+	}
+	return b
+}
+
+// higherMax returns the highest of two UI maximums where "0" means no upper limit, an unparsable value loses.
+func higherMax(a string, b string) string {
+	da, errA := decimal.NewFromString(a)
+	db, errB := decimal.NewFromString(b)
+	if errA != nil {
+		return b
+	}
+	if errB != nil {
+		return a
+	}
+	if da.IsZero() || db.IsZero() {
+		return "0"
+	}
+	if da.GreaterThanOrEqual(db) {
+		return a
+	}
+	return b
+}
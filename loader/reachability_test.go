@@ -0,0 +1,66 @@
+package loader
+
+import (
+	"testing"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+func TestReachabilityIndex(t *testing.T) {
+	chainInfoMgr := NewChainInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	chainInfoMgr.withoutClients = true
+	chainInfoMgr.setChains([]*ChainInfo{
+		{Id: 1, ChainId: "42161", Name: "Arbitrum", Backend: EthereumBackend},
+		{Id: 2, ChainId: "8453", Name: "Base", Backend: EthereumBackend},
+		{Id: 3, ChainId: "11155111", Name: "Sepolia", Backend: EthereumBackend, IsTestnet: 1},
+		{Id: 4, ChainId: "10", Name: "Optimism", Backend: EthereumBackend, Disabled: 1},
+	})
+
+	lpInfoMgr := NewLpInfoManager(nil, nil)
+	lpInfoMgr.setLpInfos([]*LpInfo{
+		{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", MakerAddress: "0xa", MinValueStr: "1", MaxValueStr: "5000"},
+		{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", MakerAddress: "0xb", MinValueStr: "0.5", MaxValueStr: "8000"},
+		{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base", MakerAddress: "0xc", MinValueStr: "0.1", MaxValueStr: "90000", IsDisabled: 1},
+		{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Sepolia", MakerAddress: "0xa", MinValueStr: "1", MaxValueStr: "10"},
+		{Version: 1, TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Optimism", MakerAddress: "0xa", MinValueStr: "1", MaxValueStr: "10"},
+	})
+
+	aggregatorMgr := NewAggregatorManager(nil, alert.NewCommonAlerter(0, 0))
+	if err := aggregatorMgr.ImportSnapshot([]byte(`{
+		"AggregatorConfigs": [{"ID": 1, "Name": "across", "IsEnabled": true}],
+		"Routes": [{"ID": 7, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 8453, "FromTokenSymbol": "USDC", "ToTokenSymbol": "USDT", "MinAmount": "10", "MaxAmount": "0", "IsEnabled": true}]
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	multiTransferChainMgr := NewMultiTransferChainManager(nil, nil)
+	multiTransferChainMgr.setChains([]*MultiTransferChain{{Id: 1, ChainId: 10, ChainName: "Optimism"}})
+
+	idx := NewReachabilityIndex(chainInfoMgr, lpInfoMgr, aggregatorMgr, multiTransferChainMgr)
+	if n, err := idx.Load(); err != nil || n != 4 {
+		t.Fatalf("Load: %d destinations, %v", n, err)
+	}
+
+	dests := idx.GetDestinations("arbitrum", "usdc", ReachFilter{})
+	if len(dests) != 2 {
+		t.Fatalf("expected 2 mainnet destinations, got %d", len(dests))
+	}
+	lp, agg := dests[0], dests[1]
+	if lp.ToChainName != "Base" || lp.TokenName != "USDC" || lp.Source.IsAggregator || lp.MinValue != "0.5" || lp.MaxValue != "8000" {
+		t.Fatalf("unexpected lp destination: %+v", lp)
+	}
+	if agg.TokenName != "USDT" || agg.Source.Aggregator != AggregatorAcross || agg.MinValue != "10" || agg.MaxValue != "0" {
+		t.Fatalf("unexpected aggregator destination: %+v", agg)
+	}
+
+	if dests := idx.GetDestinationsByChainId(42161, "USDC", ReachFilter{IncludeTestnet: true, IncludeDisabledChains: true}); len(dests) != 4 {
+		t.Fatalf("expected 4 destinations without filtering, got %d", len(dests))
+	}
+	dests = idx.GetDestinationsByChainId(42161, "USDC", ReachFilter{IncludeDisabledChains: true, MultiTransferOnly: true})
+	if len(dests) != 1 || dests[0].ToChainName != "Optimism" {
+		t.Fatalf("unexpected multi transfer destinations: %+v", dests)
+	}
+	if dests := idx.GetDestinations("Base", "USDC", ReachFilter{}); len(dests) != 0 {
+		t.Fatalf("Base has no destinations, got %d", len(dests))
+	}
+}
@@ -0,0 +1,392 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-metrics"
+	"github.com/owlto-dao/utils-go/alert"
+	"github.com/owlto-dao/utils-go/telemetry"
+)
+
+// Loadable is implemented by managers whose in-memory state is (re)loaded from the database.
+// Load returns the number of rows loaded.
+type Loadable interface {
+	Load() (int, error)
+}
+
+// LoadableFunc adapts a function to Loadable, e.g. for managers that need another manager to load:
+//
+//	registry.Register("token_info", loader.LoadableFunc(func() (int, error) {
+//		return tokenInfoMgr.LoadTokens(chainInfoMgr)
+//	}), time.Minute, "chain_info")
+type LoadableFunc func() (int, error)
+
+func (f LoadableFunc) Load() (int, error) {
+	return f()
+}
+
+// LoadStatus describes the load history of a registered manager.
+type LoadStatus struct {
+	Name          string
+	Interval      time.Duration
+	DependsOn     []string
+	Loads         int64         // Number of load attempts
+	Failures      int64         // Number of failed load attempts
+	Rows          int           // Rows of the last successful load
+	LastLoadAt    time.Time     // Start of the last attempt
+	LastSuccessAt time.Time     // Start of the last successful attempt
+	LastDuration  time.Duration // Duration of the last attempt
+	LastError     error         // Error of the last attempt, nil if it succeeded
+}
+
+// IsHealthy reports whether the manager has loaded successfully and is not stale.
+// A manager is stale when it has missed three refreshes in a row.
+func (s LoadStatus) IsHealthy(now time.Time) bool {
+	if s.LastSuccessAt.IsZero() {
+		return false
+	}
+	if s.Interval > 0 && now.Sub(s.LastSuccessAt) > 3*s.Interval {
+		return false
+	}
+	return true
+}
+
+type registryEntry struct {
+	name      string
+	loadable  Loadable
+	interval  time.Duration
+	dependsOn []string
+	loadMutex sync.Mutex // Serializes loads of this entry
+	status    LoadStatus // Guarded by Registry.mutex
+}
+
+// Registry owns the refresh schedule of loader managers.
+// Managers are loaded in dependency order, then each one is refreshed on its own interval.
+type Registry struct {
+	entries map[string]*registryEntry
+	names   []string         // Registration order
+	order   []*registryEntry // Dependency order, rebuilt after every Register
+
+	alerter alert.Alerter
+	mutex   *sync.RWMutex
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewRegistry creates an empty registry. alerter may be nil, skipped loads are then only returned.
+func NewRegistry(alerter alert.Alerter) *Registry {
+	return &Registry{
+		entries: make(map[string]*registryEntry),
+		alerter: alerter,
+		mutex:   &sync.RWMutex{},
+	}
+}
+
+// Register adds a manager to the registry.
+// interval <= 0 means the manager is only loaded by LoadAll and Reload.
+// dependsOn names managers that must be loaded first; they must be registered before Start or LoadAll.
+func (r *Registry) Register(name string, loadable Loadable, interval time.Duration, dependsOn ...string) error {
+	if name == "" || loadable == nil {
+		return fmt.Errorf("register loader: name and loadable are required")
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.cancel != nil {
+		return fmt.Errorf("register loader %s: registry already started", name)
+	}
+	if _, ok := r.entries[name]; ok {
+		return fmt.Errorf("register loader %s: already registered", name)
+	}
+
+	deps := make([]string, len(dependsOn))
+	copy(deps, dependsOn)
+	r.entries[name] = &registryEntry{
+		name:      name,
+		loadable:  loadable,
+		interval:  interval,
+		dependsOn: deps,
+		status: LoadStatus{
+			Name:      name,
+			Interval:  interval,
+			DependsOn: deps,
+		},
+	}
+	r.names = append(r.names, name)
+	r.order = nil
+	return nil
+}
+
+// LoadAll loads every registered manager once, in dependency order.
+// A manager whose dependency has never loaded successfully is skipped.
+func (r *Registry) LoadAll() error {
+	order, err := r.sortedEntries()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, entry := range order {
+		if err := r.load(entry); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Reload loads the named manager, then every manager depending on it, directly or not.
+func (r *Registry) Reload(name string) error {
+	order, err := r.sortedEntries()
+	if err != nil {
+		return err
+	}
+	r.mutex.RLock()
+	_, ok := r.entries[name]
+	r.mutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("reload loader %s: not registered", name)
+	}
+
+	affected := map[string]bool{name: true}
+	var errs []error
+	for _, entry := range order {
+		if !affected[entry.name] {
+			for _, dep := range entry.dependsOn {
+				if affected[dep] {
+					affected[entry.name] = true
+					break
+				}
+			}
+		}
+		if affected[entry.name] {
+			if err := r.load(entry); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Start loads every manager once, then refreshes each one on its interval until Stop is called or ctx is done.
+// The error of the initial load is returned, but refreshing starts regardless.
+func (r *Registry) Start(ctx context.Context) error {
+	order, err := r.sortedEntries()
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	if r.cancel != nil {
+		r.mutex.Unlock()
+		return fmt.Errorf("registry already started")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r.cancel = cancel
+	r.mutex.Unlock()
+
+	loadErr := r.LoadAll()
+
+	for _, entry := range order {
+		if entry.interval <= 0 {
+			continue
+		}
+		r.wg.Add(1)
+		go r.refreshLoop(ctx, entry)
+	}
+	return loadErr
+}
+
+// Stop stops refreshing and waits for in-flight loads to finish.
+func (r *Registry) Stop() {
+	r.mutex.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+		r.wg.Wait()
+	}
+}
+
+// Status returns the load status of the named manager.
+func (r *Registry) Status(name string) (LoadStatus, bool) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	entry, ok := r.entries[name]
+	if !ok {
+		return LoadStatus{}, false
+	}
+	return entry.status, true
+}
+
+// Statuses returns the load status of every registered manager, in dependency order.
+func (r *Registry) Statuses() []LoadStatus {
+	order, err := r.sortedEntries()
+	if err != nil {
+		// Fall back to registration order rather than hiding the statuses.
+		r.mutex.RLock()
+		order = make([]*registryEntry, 0, len(r.names))
+		for _, name := range r.names {
+			order = append(order, r.entries[name])
+		}
+		r.mutex.RUnlock()
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	statuses := make([]LoadStatus, 0, len(order))
+	for _, entry := range order {
+		statuses = append(statuses, entry.status)
+	}
+	return statuses
+}
+
+// Health returns an error describing every manager that is not healthy, nil if all are.
+func (r *Registry) Health() error {
+	now := time.Now()
+	var errs []error
+	for _, status := range r.Statuses() {
+		if status.IsHealthy(now) {
+			continue
+		}
+		if status.LastError != nil {
+			errs = append(errs, fmt.Errorf("loader %s unhealthy: %w", status.Name, status.LastError))
+		} else if status.LastSuccessAt.IsZero() {
+			errs = append(errs, fmt.Errorf("loader %s never loaded", status.Name))
+		} else {
+			errs = append(errs, fmt.Errorf("loader %s stale since %s", status.Name, status.LastSuccessAt.Format(time.RFC3339)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *Registry) refreshLoop(ctx context.Context, entry *registryEntry) {
+	defer r.wg.Done()
+	ticker := time.NewTicker(entry.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.load(entry)
+		}
+	}
+}
+
+// load runs a single load of entry and records its outcome.
+func (r *Registry) load(entry *registryEntry) (err error) {
+	for _, dep := range entry.dependsOn {
+		status, _ := r.Status(dep)
+		if status.LastSuccessAt.IsZero() {
+			err = fmt.Errorf("load %s: dependency %s not loaded", entry.name, dep)
+			if r.alerter != nil {
+				r.alerter.AlertTextLazyGroup("loader_registry_"+entry.name, "skip loader", err)
+			}
+			return err
+		}
+	}
+
+	entry.loadMutex.Lock()
+	defer entry.loadMutex.Unlock()
+
+	start := time.Now()
+	rows, err := safeLoad(entry.loadable)
+	if err != nil {
+		err = fmt.Errorf("load %s: %w", entry.name, err)
+	}
+
+	r.mutex.Lock()
+	entry.status.Loads++
+	entry.status.LastLoadAt = start
+	entry.status.LastDuration = time.Since(start)
+	entry.status.LastError = err
+	if err != nil {
+		entry.status.Failures++
+	} else {
+		entry.status.Rows = rows
+		entry.status.LastSuccessAt = start
+	}
+	lastRows := entry.status.Rows
+	r.mutex.Unlock()
+
+	labels := []metrics.Label{telemetry.NewLabel("loader", entry.name)}
+	telemetry.SetGaugeWithLabels([]string{"loader", "rows"}, float32(lastRows), labels)
+	if err != nil {
+		telemetry.IncrCounterWithLabels([]string{"loader", "failures"}, 1, labels)
+	}
+	return err
+}
+
+func safeLoad(loadable Loadable) (rows int, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v, stack: %s", rec, string(debug.Stack()))
+		}
+	}()
+	return loadable.Load()
+}
+
+// sortedEntries returns the entries in dependency order, registration order breaking ties.
+func (r *Registry) sortedEntries() ([]*registryEntry, error) {
+	r.mutex.RLock()
+	if r.order != nil {
+		order := r.order
+		r.mutex.RUnlock()
+		return order, nil
+	}
+	r.mutex.RUnlock()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	names := r.names
+	for _, name := range names {
+		for _, dep := range r.entries[name].dependsOn {
+			if _, ok := r.entries[dep]; !ok {
+				return nil, fmt.Errorf("loader %s depends on unregistered loader %s", name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(names))
+	order := make([]*registryEntry, 0, len(names))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("loader dependency cycle at %s", name)
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range r.entries[name].dependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, r.entries[name])
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	r.order = order
+	return order, nil
+}
@@ -0,0 +1,186 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+type recordingLoads struct {
+	mutex *sync.Mutex
+	names []string
+}
+
+func (r *recordingLoads) loadable(name string, rows int, err error) Loadable {
+	return LoadableFunc(func() (int, error) {
+		r.mutex.Lock()
+		r.names = append(r.names, name)
+		r.mutex.Unlock()
+		return rows, err
+	})
+}
+
+func (r *recordingLoads) loaded() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	out := make([]string, len(r.names))
+	copy(out, r.names)
+	return out
+}
+
+func TestRegistryLoadAllDependencyOrder(t *testing.T) {
+	loads := &recordingLoads{mutex: &sync.Mutex{}}
+	registry := NewRegistry(alert.NewCommonAlerter(0, 0))
+
+	mustRegister(t, registry, "bridge_fee", loads.loadable("bridge_fee", 3, nil), 0, "token_info")
+	mustRegister(t, registry, "token_info", loads.loadable("token_info", 2, nil), 0, "chain_info")
+	mustRegister(t, registry, "chain_info", loads.loadable("chain_info", 1, nil), 0)
+
+	if err := registry.LoadAll(); err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	assertLoads(t, loads.loaded(), "chain_info", "token_info", "bridge_fee")
+
+	status, ok := registry.Status("token_info")
+	if !ok || status.Rows != 2 || status.Loads != 1 || status.LastSuccessAt.IsZero() {
+		t.Fatalf("unexpected token_info status: %+v", status)
+	}
+	if err := registry.Health(); err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+}
+
+func TestRegistrySkipsDependentsOfFailedLoader(t *testing.T) {
+	loads := &recordingLoads{mutex: &sync.Mutex{}}
+	registry := NewRegistry(alert.NewCommonAlerter(0, 0))
+
+	mustRegister(t, registry, "token_info", loads.loadable("token_info", 0, errors.New("db down")), 0)
+	mustRegister(t, registry, "bridge_fee", loads.loadable("bridge_fee", 3, nil), 0, "token_info")
+
+	if err := registry.LoadAll(); err == nil {
+		t.Fatal("LoadAll should fail")
+	}
+	assertLoads(t, loads.loaded(), "token_info")
+
+	status, _ := registry.Status("token_info")
+	if status.Failures != 1 || status.LastError == nil {
+		t.Fatalf("unexpected token_info status: %+v", status)
+	}
+	if err := registry.Health(); err == nil {
+		t.Fatal("Health should report the failed loaders")
+	}
+}
+
+func TestRegistryWithoutAlerter(t *testing.T) {
+	loads := &recordingLoads{mutex: &sync.Mutex{}}
+	registry := NewRegistry(nil)
+
+	mustRegister(t, registry, "token_info", loads.loadable("token_info", 0, errors.New("db down")), 0)
+	mustRegister(t, registry, "bridge_fee", loads.loadable("bridge_fee", 3, nil), 0, "token_info")
+
+	if err := registry.LoadAll(); err == nil {
+		t.Fatal("LoadAll should fail")
+	}
+	assertLoads(t, loads.loaded(), "token_info")
+}
+
+func TestRegistryReloadCascades(t *testing.T) {
+	loads := &recordingLoads{mutex: &sync.Mutex{}}
+	registry := NewRegistry(alert.NewCommonAlerter(0, 0))
+
+	mustRegister(t, registry, "chain_info", loads.loadable("chain_info", 1, nil), 0)
+	mustRegister(t, registry, "token_info", loads.loadable("token_info", 1, nil), 0, "chain_info")
+	mustRegister(t, registry, "bridge_fee", loads.loadable("bridge_fee", 1, nil), 0, "token_info")
+	mustRegister(t, registry, "dtc", loads.loadable("dtc", 1, nil), 0)
+
+	if err := registry.LoadAll(); err != nil {
+		t.Fatalf("LoadAll: %v", err)
+	}
+	loads.names = nil
+
+	if err := registry.Reload("token_info"); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	assertLoads(t, loads.loaded(), "token_info", "bridge_fee")
+}
+
+func TestRegistryReloadWhileRegistering(t *testing.T) {
+	loads := &recordingLoads{mutex: &sync.Mutex{}}
+	registry := NewRegistry(alert.NewCommonAlerter(0, 0))
+	mustRegister(t, registry, "chain_info", loads.loadable("chain_info", 1, nil), 0)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			mustRegister(t, registry, fmt.Sprintf("token_info_%d", i), loads.loadable("token_info", 1, nil), 0, "chain_info")
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		if err := registry.Reload("chain_info"); err != nil {
+			t.Errorf("Reload: %v", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestRegistryRejectsBadDependencies(t *testing.T) {
+	registry := NewRegistry(alert.NewCommonAlerter(0, 0))
+	mustRegister(t, registry, "a", LoadableFunc(func() (int, error) { return 0, nil }), 0, "b")
+	mustRegister(t, registry, "b", LoadableFunc(func() (int, error) { return 0, nil }), 0, "a")
+	if err := registry.LoadAll(); err == nil {
+		t.Fatal("LoadAll should detect the dependency cycle")
+	}
+
+	registry = NewRegistry(alert.NewCommonAlerter(0, 0))
+	mustRegister(t, registry, "a", LoadableFunc(func() (int, error) { return 0, nil }), 0, "missing")
+	if err := registry.LoadAll(); err == nil {
+		t.Fatal("LoadAll should detect the missing dependency")
+	}
+}
+
+func TestRegistryStartRefreshes(t *testing.T) {
+	loads := &recordingLoads{mutex: &sync.Mutex{}}
+	registry := NewRegistry(alert.NewCommonAlerter(0, 0))
+	mustRegister(t, registry, "chain_info", loads.loadable("chain_info", 1, nil), 10*time.Millisecond)
+
+	if err := registry.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	time.Sleep(55 * time.Millisecond)
+	registry.Stop()
+
+	count := len(loads.loaded())
+	if count < 3 {
+		t.Fatalf("expected periodic loads, got %d", count)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if len(loads.loaded()) != count {
+		t.Fatal("loads continued after Stop")
+	}
+}
+
+func mustRegister(t *testing.T, registry *Registry, name string, loadable Loadable, interval time.Duration, dependsOn ...string) {
+	t.Helper()
+	if err := registry.Register(name, loadable, interval, dependsOn...); err != nil {
+		t.Fatalf("Register %s: %v", name, err)
+	}
+}
+
+func assertLoads(t *testing.T, got []string, want ...string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("loads = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("loads = %v, want %v", got, want)
+		}
+	}
+}
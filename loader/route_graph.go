@@ -0,0 +1,301 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// RouteNode is a token on a chain, the symbol is upper case.
+type RouteNode struct {
+	ChainID     int64
+	TokenSymbol string
+}
+
+func NewRouteNode(chainID int64, tokenSymbol string) RouteNode {
+	return RouteNode{ChainID: chainID, TokenSymbol: strings.ToUpper(strings.TrimSpace(tokenSymbol))}
+}
+
+func (n RouteNode) String() string {
+	return fmt.Sprintf("%s@%d", n.TokenSymbol, n.ChainID)
+}
+
+// RouteEdge is a hop between two nodes, over an aggregator route or over the Owlto Native lps.
+type RouteEdge struct {
+	From   RouteNode
+	To     RouteNode
+	Route  *RouteConfig // Nil for native edges
+	Native bool
+}
+
+// RouteGraph is a snapshot of the hops between chain and token nodes.
+type RouteGraph struct {
+	edges map[RouteNode][]*RouteEdge
+}
+
+// RouteHop is a priced edge of a RoutePath.
+type RouteHop struct {
+	Edge     *RouteEdge
+	Result   *BestRouteResult
+	InputUI  string
+	OutputUI string
+}
+
+// RoutePath is a priced path of one or more hops.
+type RoutePath struct {
+	Hops     []*RouteHop
+	InputUI  string
+	OutputUI string
+
+	output *big.Rat
+}
+
+// Edges returns the hops leaving node.
+func (g *RouteGraph) Edges(node RouteNode) []*RouteEdge {
+	return g.edges[node]
+}
+
+// Paths returns every path from from to to of at most maxHops edges, never visiting a node twice.
+func (g *RouteGraph) Paths(from RouteNode, to RouteNode, maxHops int) [][]*RouteEdge {
+	paths := make([][]*RouteEdge, 0)
+	visited := map[RouteNode]bool{from: true}
+	var walk func(node RouteNode, path []*RouteEdge)
+	walk = func(node RouteNode, path []*RouteEdge) {
+		if len(path) == maxHops {
+			return
+		}
+		for _, edge := range g.edges[node] {
+			if visited[edge.To] {
+				continue
+			}
+			next := append(path[:len(path):len(path)], edge)
+			if edge.To == to {
+				paths = append(paths, next)
+				continue
+			}
+			visited[edge.To] = true
+			walk(edge.To, next)
+			visited[edge.To] = false
+		}
+	}
+	walk(from, nil)
+	return paths
+}
+
+// GetRoutesByChainPairAndSymbols returns the routes of the chain pair from fromSymbol to toSymbol.
+// A route without destination symbol keeps its source token.
+func (mgr *AggregatorManager) GetRoutesByChainPairAndSymbols(fromChainID, toChainID int64, fromSymbol string, toSymbol string) []*RouteConfig {
+	from := NewRouteNode(fromChainID, fromSymbol)
+	to := NewRouteNode(toChainID, toSymbol)
+	var routes []*RouteConfig
+	for _, route := range mgr.GetRoutesByChainPair(fromChainID, toChainID) {
+		if edge := routeEdge(route); edge.From == from && edge.To == to {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+func routeEdge(route *RouteConfig) *RouteEdge {
+	toSymbol := route.ToTokenSymbol
+	if strings.TrimSpace(toSymbol) == "" {
+		toSymbol = route.FromTokenSymbol
+	}
+	return &RouteEdge{
+		From:  NewRouteNode(route.FromChainID, route.FromTokenSymbol),
+		To:    NewRouteNode(route.ToChainID, toSymbol),
+		Route: route,
+	}
+}
+
+// RouteGraph builds the graph of the enabled aggregator routes and, when set, of the Owlto Native lps.
+func (mgr *AggregatorManager) RouteGraph() *RouteGraph {
+	graph := &RouteGraph{edges: make(map[RouteNode][]*RouteEdge)}
+	for _, route := range mgr.GetAllRoutes() {
+		if !route.IsEnabled {
+			continue
+		}
+		edge := routeEdge(route)
+		graph.edges[edge.From] = append(graph.edges[edge.From], edge)
+	}
+
+	mgr.mutex.RLock()
+	native := mgr.native
+	mgr.mutex.RUnlock()
+	if native != nil {
+		seen := make(map[[2]RouteNode]bool)
+		for _, lpInfo := range native.quoteEngine.lpInfoMgr.GetAllLpInfos() {
+			if lpInfo.Version != native.lpVersion || lpInfo.IsDisabled != 0 {
+				continue
+			}
+			fromChain, ok := native.chainInfoMgr.GetChainInfoByName(lpInfo.FromChainName)
+			if !ok {
+				continue
+			}
+			toChain, ok := native.chainInfoMgr.GetChainInfoByName(lpInfo.ToChainName)
+			if !ok {
+				continue
+			}
+			edge := &RouteEdge{
+				From:   NewRouteNode(fromChain.GetInt64ChainId(), lpInfo.TokenName),
+				To:     NewRouteNode(toChain.GetInt64ChainId(), lpInfo.TokenName),
+				Native: true,
+			}
+			if key := [2]RouteNode{edge.From, edge.To}; !seen[key] {
+				seen[key] = true
+				graph.edges[edge.From] = append(graph.edges[edge.From], edge)
+			}
+		}
+	}
+
+	// Stable edge order makes the search deterministic.
+	for _, edges := range graph.edges {
+		sort.SliceStable(edges, func(i, j int) bool {
+			if edges[i].Native != edges[j].Native {
+				return !edges[i].Native
+			}
+			if edges[i].To != edges[j].To {
+				return edges[i].To.String() < edges[j].To.String()
+			}
+			return edges[i].Route.ID < edges[j].Route.ID
+		})
+	}
+	return graph
+}
+
+// FindPaths prices every path of at most maxHops hops from from to to for amountUI of the source token,
+// feeding the output of each hop into the next one. Paths with a hop refusing its input amount are dropped.
+// Paths are ranked on the received amount, which accounts for the cumulative fees, then on their length.
+// decimals is the decimals of the source token, the decimals of the other tokens come from t_token_info
+// when native routes are set and default to decimals otherwise. A hop changing token is only priced
+// from the output amount of a live quote, fees in the source token do not tell what the other token receives.
+func (mgr *AggregatorManager) FindPaths(ctx context.Context, from RouteNode, to RouteNode, amountUI string, decimals int32, maxHops int) ([]*RoutePath, error) {
+	if maxHops <= 0 {
+		maxHops = 2
+	}
+	amtRat, ok := new(big.Rat).SetString(amountUI)
+	if !ok || amtRat.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount: %s", amountUI)
+	}
+
+	mgr.mutex.RLock()
+	native := mgr.native
+	mgr.mutex.RUnlock()
+	decimalsOf := func(node RouteNode) int32 {
+		if node == from {
+			return decimals
+		}
+		return tokenDecimals(native, node, decimals)
+	}
+
+	edgePaths := mgr.RouteGraph().Paths(from, to, maxHops)
+	if len(edgePaths) == 0 {
+		return nil, fmt.Errorf("no path found from %s to %s", from, to)
+	}
+
+	paths := make([]*RoutePath, 0, len(edgePaths))
+	for _, edges := range edgePaths {
+		path := &RoutePath{Hops: make([]*RouteHop, 0, len(edges)), InputUI: amountUI}
+		input := amtRat
+		for _, edge := range edges {
+			hopDecimals, toDecimals := decimalsOf(edge.From), decimalsOf(edge.To)
+			result, output := mgr.priceEdge(ctx, native, edge, input, hopDecimals, toDecimals)
+			if result == nil {
+				path = nil
+				break
+			}
+			path.Hops = append(path.Hops, &RouteHop{
+				Edge:     edge,
+				Result:   result,
+				InputUI:  input.FloatString(int(hopDecimals)),
+				OutputUI: output.FloatString(int(toDecimals)),
+			})
+			input = output
+		}
+		if path == nil {
+			continue
+		}
+		path.output = input
+		path.OutputUI = path.Hops[len(path.Hops)-1].OutputUI
+		paths = append(paths, path)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no path from %s to %s accepts amount %s", from, to, amountUI)
+	}
+
+	sort.SliceStable(paths, func(i, j int) bool {
+		if cmp := paths[i].output.Cmp(paths[j].output); cmp != 0 {
+			return cmp > 0
+		}
+		return len(paths[i].Hops) < len(paths[j].Hops)
+	})
+	return paths, nil
+}
+
+// priceEdge prices input over edge and returns the result with the UI amount received,
+// or nil when the edge refuses the amount. decimals and toDecimals are those of the tokens of edge.
+func (mgr *AggregatorManager) priceEdge(ctx context.Context, native *nativeRoutes, edge *RouteEdge, input *big.Rat, decimals int32, toDecimals int32) (*BestRouteResult, *big.Rat) {
+	if edge.Native {
+		if native == nil {
+			return nil, nil
+		}
+		candidates := native.candidates(edge.From.ChainID, edge.To.ChainID, edge.From.TokenSymbol, input, decimals)
+		var best *BestRouteResult
+		for _, candidate := range candidates {
+			if best == nil || candidate.FeeResult.TotalGasFee.Cmp(best.FeeResult.TotalGasFee) < 0 {
+				best = candidate
+			}
+		}
+		if best == nil {
+			return nil, nil
+		}
+		quote := best.NativeQuote
+		return best, new(big.Rat).SetFrac(quote.ReceiveAmount, pow10(quote.ToDecimals))
+	}
+
+	result := mgr.priceRoute(ctx, edge.Route, input, decimals, toDecimals)
+	if result == nil {
+		return nil, nil
+	}
+	fee := result.FeeResult
+	amount := uiToWei(input, decimals)
+	var received *big.Int
+	switch {
+	case fee.OutputAmount != nil && edge.From.TokenSymbol == edge.To.TokenSymbol:
+		// The aggregator delivers the output, the Owlto fee is charged on top of what it keeps.
+		received = new(big.Int).Sub(fee.OutputAmount, convertDecimals(fee.OwltoFee, decimals, toDecimals, true))
+	case fee.OutputAmount != nil:
+		// The Owlto fee is in the source token, it takes its share of the input out of the output.
+		if amount.Cmp(fee.OwltoFee) <= 0 {
+			return nil, nil
+		}
+		received = new(big.Int).Mul(fee.OutputAmount, new(big.Int).Sub(amount, fee.OwltoFee))
+		received.Quo(received, amount)
+	case edge.From.TokenSymbol == edge.To.TokenSymbol:
+		received = convertDecimals(new(big.Int).Sub(amount, fee.TotalGasFee), decimals, toDecimals, false)
+	default:
+		return nil, nil
+	}
+	if received.Sign() <= 0 {
+		return nil, nil
+	}
+	return result, new(big.Rat).SetFrac(received, pow10(toDecimals))
+}
+
+// tokenDecimals returns the decimals of the token of node from t_token_info when native routes are set,
+// or fallback.
+func tokenDecimals(native *nativeRoutes, node RouteNode, fallback int32) int32 {
+	if native == nil {
+		return fallback
+	}
+	if token, ok := native.quoteEngine.tokenInfoMgr.GetByChainIdTokenName(node.ChainID, node.TokenSymbol); ok {
+		return token.Decimals
+	}
+	return fallback
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,104 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+func TestFindPathsAcrossTokensAndHops(t *testing.T) {
+	chainInfoMgr := NewChainInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	chainInfoMgr.withoutClients = true
+	chainInfoMgr.setChains([]*ChainInfo{
+		{Id: 1, ChainId: "1", Name: "Ethereum", Backend: EthereumBackend},
+		{Id: 2, ChainId: "42161", Name: "Arbitrum", Backend: EthereumBackend},
+		{Id: 3, ChainId: "8453", Name: "Base", Backend: EthereumBackend},
+	})
+
+	// Across keeps 1 token, 5 to Base from Ethereum.
+	across := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		amount, _ := new(big.Int).SetString(r.URL.Query().Get("amount"), 10)
+		fee := big.NewInt(1_000000)
+		if r.URL.Query().Get("originChainId") == "1" && r.URL.Query().Get("destinationChainId") == "8453" {
+			fee = big.NewInt(5_000000)
+		}
+		fmt.Fprintf(w, `{"totalRelayFee": {"total": "%s"}, "outputAmount": "%s"}`, fee, new(big.Int).Sub(amount, fee))
+	}))
+	defer across.Close()
+
+	mgr := NewAggregatorManager(nil, alert.NewCommonAlerter(0, 0))
+	if err := mgr.ImportSnapshot([]byte(`{
+		"AggregatorConfigs": [{"ID": 1, "Name": "across", "IsEnabled": true, "APIBaseURL": "` + across.URL + `"}],
+		"Routes": [
+			{"ID": 7, "AggregateID": 1, "FromChainID": 1, "ToChainID": 42161, "FromTokenSymbol": "USDT", "ToTokenSymbol": "USDC", "IsEnabled": true},
+			{"ID": 8, "AggregateID": 1, "FromChainID": 1, "ToChainID": 8453, "FromTokenSymbol": "USDT", "ToTokenSymbol": "USDC", "IsEnabled": true},
+			{"ID": 9, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 8453, "FromTokenSymbol": "USDC", "IsEnabled": true}
+		],
+		"FeeSegments": [
+			{"ID": 1, "RouteID": 7, "MinAmountUI": "0", "MaxAmountUI": "0", "OwltoFeeFixedUI": "0", "IsEnabled": true},
+			{"ID": 2, "RouteID": 8, "MinAmountUI": "0", "MaxAmountUI": "0", "OwltoFeeFixedUI": "5", "IsEnabled": true},
+			{"ID": 3, "RouteID": 9, "MinAmountUI": "0", "MaxAmountUI": "0", "OwltoFeeFixedUI": "0.5", "IsEnabled": true}
+		]
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+	mgr.SetQuoteClient(AggregatorAcross, &AcrossQuoteClient{})
+	mgr.SetNativeRoutes(chainInfoMgr, newTestQuoteEngine(t), 1)
+
+	if routes := mgr.GetRoutesByChainPairAndSymbols(1, 42161, "usdt", "usdc"); len(routes) != 1 || routes[0].ID != 7 {
+		t.Fatalf("unexpected cross token routes: %+v", routes)
+	}
+
+	from, to := NewRouteNode(1, "USDT"), NewRouteNode(8453, "usdc")
+	paths, err := mgr.FindPaths(context.Background(), from, to, "500", 6, 2)
+	if err != nil {
+		t.Fatalf("FindPaths: %v", err)
+	}
+	if len(paths) != 3 {
+		t.Fatalf("expected 3 paths, got %d", len(paths))
+	}
+	// Across to Arbitrum then the native lp to Base beats the expensive direct route.
+	best := paths[0]
+	if len(best.Hops) != 2 || best.Hops[0].Edge.Route.ID != 7 || !best.Hops[1].Edge.Native {
+		t.Fatalf("unexpected best path: %+v", best.Hops)
+	}
+	if best.Hops[0].OutputUI != "499.000000" || best.Hops[1].InputUI != "499.000000" || best.OutputUI != best.Hops[1].OutputUI {
+		t.Fatalf("hop amounts are not chained: %s -> %s -> %s", best.Hops[0].OutputUI, best.Hops[1].InputUI, best.OutputUI)
+	}
+	// The Owlto fee comes out of the live output: 1 token kept by Across then 0.5 by Owlto on the same token,
+	// and on the direct route the 5 USDT Owlto fee takes 1% of the 495 USDC quoted.
+	if len(paths[1].Hops) != 2 || paths[1].Hops[1].Edge.Route.ID != 9 || paths[1].OutputUI != "497.500000" {
+		t.Fatalf("unexpected aggregator path: %d hops, output %s", len(paths[1].Hops), paths[1].OutputUI)
+	}
+	if len(paths[2].Hops) != 1 || paths[2].OutputUI != "490.050000" {
+		t.Fatalf("unexpected direct path: %d hops, output %s", len(paths[2].Hops), paths[2].OutputUI)
+	}
+
+	paths, err = mgr.FindPaths(context.Background(), from, to, "500", 6, 1)
+	if err != nil {
+		t.Fatalf("FindPaths: %v", err)
+	}
+	if len(paths) != 1 || paths[0].Hops[0].Edge.Route.ID != 8 {
+		t.Fatal("maxHops 1 should only find the direct route")
+	}
+
+	// Above the lp max value the native hop refuses the amount.
+	paths, err = mgr.FindPaths(context.Background(), from, to, "6000", 6, 2)
+	if err != nil {
+		t.Fatalf("FindPaths: %v", err)
+	}
+	if len(paths) != 2 || len(paths[0].Hops) != 2 || paths[0].Hops[1].Edge.Native || len(paths[1].Hops) != 1 {
+		t.Fatal("paths through a refusing hop should be dropped")
+	}
+
+	// Without live quote the fees in USDT say nothing of the USDC received.
+	mgr.SetQuoteClient(AggregatorAcross, nil)
+	if _, err := mgr.FindPaths(context.Background(), from, to, "500", 6, 2); err == nil {
+		t.Fatal("cross token hops need a live quote")
+	}
+}
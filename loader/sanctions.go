@@ -0,0 +1,292 @@
+package loader
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+// SanctionsEntry is a sanctioned address of a sanctions dataset.
+type SanctionsEntry struct {
+	Address  string
+	Currency string // Currency of the address as given by the dataset, e.g. XBT or ETH
+	EntryId  string // Id of the sanctioned party in the dataset
+	Name     string // Name of the sanctioned party
+}
+
+const ofacDigitalCurrencyPrefix = "Digital Currency Address - "
+
+type ofacSdnEntry struct {
+	Uid       string `xml:"uid"`
+	FirstName string `xml:"firstName"`
+	LastName  string `xml:"lastName"`
+	Ids       []struct {
+		IdType   string `xml:"idType"`
+		IdNumber string `xml:"idNumber"`
+	} `xml:"idList>id"`
+}
+
+// ParseOFACSdnXML reads the digital currency addresses of the OFAC SDN list in its sdn.xml format.
+func ParseOFACSdnXML(r io.Reader) ([]SanctionsEntry, error) {
+	decoder := xml.NewDecoder(r)
+	entries := make([]SanctionsEntry, 0)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse sdn xml: %w", err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "sdnEntry" {
+			continue
+		}
+		var sdn ofacSdnEntry
+		if err := decoder.DecodeElement(&sdn, &start); err != nil {
+			return nil, fmt.Errorf("parse sdn xml entry: %w", err)
+		}
+		name := strings.TrimSpace(strings.TrimSpace(sdn.FirstName) + " " + strings.TrimSpace(sdn.LastName))
+		for _, id := range sdn.Ids {
+			if !strings.HasPrefix(id.IdType, ofacDigitalCurrencyPrefix) {
+				continue
+			}
+			entries = append(entries, SanctionsEntry{
+				Address:  strings.TrimSpace(id.IdNumber),
+				Currency: strings.TrimSpace(strings.TrimPrefix(id.IdType, ofacDigitalCurrencyPrefix)),
+				EntryId:  strings.TrimSpace(sdn.Uid),
+				Name:     name,
+			})
+		}
+	}
+}
+
+var ofacRemarksAddress = regexp.MustCompile(`Digital Currency Address - ([A-Za-z0-9]+)\s+([^\s;]+)`)
+
+// ParseOFACSdnCSV reads the digital currency addresses of the OFAC SDN list in its sdn.csv format,
+// where they are listed in the remarks column.
+func ParseOFACSdnCSV(r io.Reader) ([]SanctionsEntry, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	entries := make([]SanctionsEntry, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse sdn csv: %w", err)
+		}
+		if len(record) < 12 {
+			continue
+		}
+		for _, match := range ofacRemarksAddress.FindAllStringSubmatch(record[11], -1) {
+			entries = append(entries, SanctionsEntry{
+				Address:  strings.TrimRight(match[2], "."),
+				Currency: match[1],
+				EntryId:  strings.TrimSpace(record[0]),
+				Name:     strings.TrimSpace(record[1]),
+			})
+		}
+	}
+}
+
+// LoadSanctionsFile reads a local sanctions dataset, an OFAC SDN .xml or .csv file.
+func LoadSanctionsFile(path string) ([]SanctionsEntry, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		return ParseOFACSdnXML(file)
+	case ".csv":
+		return ParseOFACSdnCSV(file)
+	default:
+		return nil, fmt.Errorf("unsupported sanctions file %s", path)
+	}
+}
+
+// SanctionsList is an immutable set of sanctioned addresses. Entries are kept in a single slice
+// sorted by canonical address and looked up by binary search.
+type SanctionsList struct {
+	Name    string
+	keys    []string
+	entries []SanctionsEntry
+}
+
+func NewSanctionsList(name string, entries []SanctionsEntry) *SanctionsList {
+	type keyedEntry struct {
+		key   string
+		entry SanctionsEntry
+	}
+	keyed := make([]keyedEntry, 0, len(entries))
+	for _, entry := range entries {
+		if key := CanonicalAddress(0, entry.Address); key != "" {
+			keyed = append(keyed, keyedEntry{key: key, entry: entry})
+		}
+	}
+	sort.Slice(keyed, func(i, j int) bool { return keyed[i].key < keyed[j].key })
+
+	list := &SanctionsList{Name: name, keys: make([]string, len(keyed)), entries: make([]SanctionsEntry, len(keyed))}
+	for i, k := range keyed {
+		list.keys[i] = k.key
+		list.entries[i] = k.entry
+	}
+	return list
+}
+
+// Len returns the number of sanctioned addresses.
+func (l *SanctionsList) Len() int {
+	return len(l.entries)
+}
+
+// Lookup returns the entries of a canonical address.
+func (l *SanctionsList) Lookup(canonicalAddr string) []SanctionsEntry {
+	i := sort.SearchStrings(l.keys, canonicalAddr)
+	j := i
+	for j < len(l.keys) && l.keys[j] == canonicalAddr {
+		j++
+	}
+	return l.entries[i:j]
+}
+
+// ScreenMatch is a screened address found on the blacklist or on a sanctions list.
+type ScreenMatch struct {
+	Address string
+	Source  string // "blacklist" or the name of the sanctions list
+	Reason  string
+}
+
+// ScreenResult is the outcome of screening the addresses of a chain.
+type ScreenResult struct {
+	ChainName string
+	Checked   bool // False when the blacklist check is disabled for the chain
+	Matches   []ScreenMatch
+}
+
+// IsBlocked reports whether an address matched.
+func (r *ScreenResult) IsBlocked() bool {
+	return len(r.Matches) > 0
+}
+
+// SanctionsScreener screens addresses against t_blacklist_address and the sanctions datasets
+// of local files, on the chains whose ChainInfo.EnableBlackListCheck is set.
+type SanctionsScreener struct {
+	chainInfoMgr *ChainInfoManager
+	blacklistMgr *BlacklistAddressManager // Optional
+	alerter      alert.Alerter
+
+	files map[string]string // List name to file path
+	lists []*SanctionsList
+	mutex *sync.RWMutex
+}
+
+func NewSanctionsScreener(chainInfoMgr *ChainInfoManager, blacklistMgr *BlacklistAddressManager, alerter alert.Alerter) *SanctionsScreener {
+	return &SanctionsScreener{
+		chainInfoMgr: chainInfoMgr,
+		blacklistMgr: blacklistMgr,
+		alerter:      alerter,
+		files:        make(map[string]string),
+		mutex:        &sync.RWMutex{},
+	}
+}
+
+// AddListFile registers the sanctions file read as the list name on the next Load.
+func (s *SanctionsScreener) AddListFile(name string, path string) {
+	s.mutex.Lock()
+	s.files[name] = path
+	s.mutex.Unlock()
+}
+
+// SetLists replaces the sanctions lists, e.g. with lists built from another source than files.
+func (s *SanctionsScreener) SetLists(lists ...*SanctionsList) {
+	s.mutex.Lock()
+	s.lists = lists
+	s.mutex.Unlock()
+}
+
+// Load rereads every sanctions file and returns the number of sanctioned addresses.
+// The previous lists are kept when a file cannot be read.
+func (s *SanctionsScreener) Load() (int, error) {
+	s.mutex.RLock()
+	files := make(map[string]string, len(s.files))
+	for name, path := range s.files {
+		files[name] = path
+	}
+	s.mutex.RUnlock()
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	lists := make([]*SanctionsList, 0, len(files))
+	count := 0
+	for _, name := range names {
+		entries, err := LoadSanctionsFile(files[name])
+		if err != nil {
+			s.alerter.AlertText(fmt.Sprintf("load sanctions list %s error, keeping the previous lists", name), err)
+			return 0, fmt.Errorf("load sanctions list %s: %w", name, err)
+		}
+		list := NewSanctionsList(name, entries)
+		lists = append(lists, list)
+		count += list.Len()
+	}
+	s.SetLists(lists...)
+	return count, nil
+}
+
+// Screen checks addresses of the chain named chainName. Nothing is checked when the chain
+// has the blacklist check disabled.
+func (s *SanctionsScreener) Screen(chainName string, addresses ...string) (*ScreenResult, error) {
+	chainInfo, ok := s.chainInfoMgr.GetChainInfoByName(chainName)
+	if !ok {
+		return nil, fmt.Errorf("chain %s not found", chainName)
+	}
+	result := &ScreenResult{ChainName: chainInfo.Name}
+	if chainInfo.EnableBlackListCheck == 0 {
+		return result, nil
+	}
+	result.Checked = true
+
+	s.mutex.RLock()
+	lists := s.lists
+	s.mutex.RUnlock()
+
+	for _, addr := range addresses {
+		keys := AddressKeys(chainInfo.Backend, addr)
+
+		if s.blacklistMgr != nil {
+			if blacklist, ok := s.blacklistMgr.GetBlacklistByBackendAddress(chainInfo.Backend, addr); ok {
+				result.Matches = append(result.Matches, ScreenMatch{Address: addr, Source: "blacklist", Reason: blacklist.RiskDesc})
+			}
+		}
+		for _, list := range lists {
+			for _, key := range keys {
+				for _, entry := range list.Lookup(key) {
+					result.Matches = append(result.Matches, ScreenMatch{
+						Address: addr,
+						Source:  list.Name,
+						Reason:  fmt.Sprintf("%s address of %s (entry %s)", entry.Currency, entry.Name, entry.EntryId),
+					})
+				}
+			}
+		}
+	}
+	return result, nil
+}
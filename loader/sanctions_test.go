@@ -0,0 +1,94 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+const testSdnXML = `<?xml version="1.0" standalone="yes"?>
+<sdnList xmlns="http://tempuri.org/sdnList.xsd">
+  <sdnEntry>
+    <uid>100</uid><lastName>MIXER LTD</lastName><sdnType>Entity</sdnType>
+    <idList>
+      <id><uid>1</uid><idType>Digital Currency Address - ETH</idType><idNumber>0x8589427373D6D84E98730D7795D8f6f8731FDA16</idNumber></id>
+      <id><uid>2</uid><idType>Registration ID</idType><idNumber>12345</idNumber></id>
+    </idList>
+  </sdnEntry>
+  <sdnEntry>
+    <uid>200</uid><firstName>John</firstName><lastName>DOE</lastName><sdnType>Individual</sdnType>
+    <idList>
+      <id><uid>3</uid><idType>Digital Currency Address - SOL</idType><idNumber>So11111111111111111111111111111111111111112</idNumber></id>
+    </idList>
+  </sdnEntry>
+</sdnList>`
+
+const testSdnCSV = `300,"EXCHANGE CORP","-0- ","CYBER2","-0- ","-0- ","-0- ","-0- ","-0- ","-0- ","-0- ","Digital Currency Address - XBT 12QtD5BFwRsdNsAZY76UVE1xyCGNTojH9h; alt. Digital Currency Address - XBT bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq."
+301,"NOBODY","-0- ","SDGT","-0- ","-0- ","-0- ","-0- ","-0- ","-0- ","-0- ","DOB 1970."
+`
+
+func TestSanctionsScreener(t *testing.T) {
+	dir := t.TempDir()
+	xmlPath := filepath.Join(dir, "sdn.xml")
+	csvPath := filepath.Join(dir, "sdn.csv")
+	if err := os.WriteFile(xmlPath, []byte(testSdnXML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(csvPath, []byte(testSdnCSV), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	chainInfoMgr := NewChainInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	chainInfoMgr.withoutClients = true
+	chainInfoMgr.setChains([]*ChainInfo{
+		{Id: 1, ChainId: "1", Name: "Ethereum", Backend: EthereumBackend, EnableBlackListCheck: 1},
+		{Id: 2, ChainId: "501", Name: "Solana", Backend: SolanaBackend, EnableBlackListCheck: 1},
+		{Id: 3, ChainId: "0", Name: "Bitcoin", Backend: BitcoinBackend, EnableBlackListCheck: 1},
+		{Id: 4, ChainId: "8453", Name: "Base", Backend: EthereumBackend},
+	})
+	blacklistMgr := NewBlacklistAddressManager(nil, nil)
+	blacklistMgr.setBlacklists([]*BlacklistAddress{{Id: 1, Address: "0x000000000000000000000000000000000000dEaD", RiskDesc: "phishing", Status: 1}})
+
+	screener := NewSanctionsScreener(chainInfoMgr, blacklistMgr, alert.NewCommonAlerter(0, 0))
+	screener.AddListFile("ofac_xml", xmlPath)
+	screener.AddListFile("ofac_csv", csvPath)
+	if n, err := screener.Load(); err != nil || n != 4 {
+		t.Fatalf("Load: %d addresses, %v", n, err)
+	}
+
+	result, err := screener.Screen("ethereum", "0x8589427373d6d84e98730d7795d8f6f8731fda16", "0x000000000000000000000000000000000000DEAD", "0x0000000000000000000000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Checked || len(result.Matches) != 2 || result.Matches[0].Source != "ofac_xml" || result.Matches[1].Reason != "phishing" {
+		t.Fatalf("unexpected ethereum matches: %+v", result.Matches)
+	}
+
+	if result, _ := screener.Screen("solana", "so11111111111111111111111111111111111111112"); result.IsBlocked() {
+		t.Fatal("solana keys differing in case must not match")
+	}
+	if result, _ := screener.Screen("solana", "So11111111111111111111111111111111111111112"); !result.IsBlocked() {
+		t.Fatal("sanctioned solana key should match")
+	}
+	if result, _ := screener.Screen("bitcoin", "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "12QtD5BFwRsdNsAZY76UVE1xyCGNTojH9h"); len(result.Matches) != 2 {
+		t.Fatalf("unexpected bitcoin matches: %+v", result.Matches)
+	}
+
+	result, err = screener.Screen("base", "0x8589427373d6d84e98730d7795d8f6f8731fda16")
+	if err != nil || result.Checked || result.IsBlocked() {
+		t.Fatalf("chains without blacklist check are not screened, got %+v, %v", result, err)
+	}
+	if _, err := screener.Screen("unknown", "0x1"); err == nil {
+		t.Fatal("unknown chains should fail")
+	}
+
+	screener.AddListFile("missing", filepath.Join(dir, "missing.csv"))
+	if _, err := screener.Load(); err == nil {
+		t.Fatal("Load should fail on a missing file")
+	}
+	if result, _ := screener.Screen("ethereum", "0x8589427373d6d84e98730d7795d8f6f8731fda16"); !result.IsBlocked() {
+		t.Fatal("a failed load must keep the previous lists")
+	}
+}
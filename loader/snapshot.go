@@ -0,0 +1,135 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot file format written by WriteSnapshotFile.
+const SnapshotVersion = 1
+
+// Snapshotter is implemented by managers whose in-memory state can be exported to and imported from a snapshot.
+type Snapshotter interface {
+	// SnapshotName is the key of the manager in the snapshot file.
+	SnapshotName() string
+	ExportSnapshot() (json.RawMessage, error)
+	// ImportSnapshot replaces the in-memory state, exactly as a load from the database would.
+	ImportSnapshot(data json.RawMessage) error
+}
+
+// Snapshot is the versioned content of a snapshot file.
+type Snapshot struct {
+	Version   int                        `json:"version"`
+	CreatedAt time.Time                  `json:"created_at"`
+	Managers  map[string]json.RawMessage `json:"managers"`
+}
+
+// ExportSnapshot collects the state of the given managers.
+func ExportSnapshot(managers ...Snapshotter) (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Version:   SnapshotVersion,
+		CreatedAt: time.Now().UTC(),
+		Managers:  make(map[string]json.RawMessage, len(managers)),
+	}
+	for _, mgr := range managers {
+		name := mgr.SnapshotName()
+		if _, ok := snapshot.Managers[name]; ok {
+			return nil, fmt.Errorf("duplicate snapshot name %s", name)
+		}
+		data, err := mgr.ExportSnapshot()
+		if err != nil {
+			return nil, fmt.Errorf("export %s snapshot: %w", name, err)
+		}
+		snapshot.Managers[name] = data
+	}
+	return snapshot, nil
+}
+
+// Import loads the state of the given managers from the snapshot.
+// Every manager must be present in the snapshot.
+func (s *Snapshot) Import(managers ...Snapshotter) error {
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d, expect %d", s.Version, SnapshotVersion)
+	}
+	for _, mgr := range managers {
+		name := mgr.SnapshotName()
+		data, ok := s.Managers[name]
+		if !ok {
+			return fmt.Errorf("snapshot has no %s", name)
+		}
+		if err := mgr.ImportSnapshot(data); err != nil {
+			return fmt.Errorf("import %s snapshot: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// WriteSnapshotFile exports the given managers to path.
+// The file is replaced atomically so a crash never leaves a truncated snapshot behind.
+func WriteSnapshotFile(path string, managers ...Snapshotter) error {
+	snapshot, err := ExportSnapshot(managers...)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create snapshot file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write snapshot file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close snapshot file: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// ReadSnapshotFile reads a snapshot written by WriteSnapshotFile.
+func ReadSnapshotFile(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("unmarshal snapshot %s: %w", path, err)
+	}
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d, expect %d", snapshot.Version, SnapshotVersion)
+	}
+	return &snapshot, nil
+}
+
+// ImportSnapshotFile loads the state of the given managers from the snapshot at path.
+func ImportSnapshotFile(path string, managers ...Snapshotter) error {
+	snapshot, err := ReadSnapshotFile(path)
+	if err != nil {
+		return err
+	}
+	return snapshot.Import(managers...)
+}
+
+func exportRows[T any](rows []T) (json.RawMessage, error) {
+	if rows == nil {
+		rows = []T{}
+	}
+	return json.Marshal(rows)
+}
+
+func importRows[T any](data json.RawMessage) ([]T, error) {
+	var rows []T
+	if err := json.Unmarshal(data, &rows); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
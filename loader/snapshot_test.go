@@ -0,0 +1,79 @@
+package loader
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/alert"
+)
+
+func TestSnapshotFileRoundTrip(t *testing.T) {
+	alerter := alert.NewCommonAlerter(0, 0)
+	fixture := &Snapshot{
+		Version: SnapshotVersion,
+		Managers: map[string]json.RawMessage{
+			"token_info": json.RawMessage(`[
+				{"Id": 1, "TokenName": "USDC", "ChainName": "Arbitrum", "ChainId": 42161, "TokenAddress": "0xAF88d065e77c8cC2239327C5EDb3A432268e5831", "Decimals": 6}
+			]`),
+			"bridge_fee": json.RawMessage(`[
+				{"TokenName": "USDC", "FromChainName": "Arbitrum", "ToChainName": "Base", "BridgeFeeRatioLv1": 30, "AmountLv1Str": "100", "KeepDecimal": 2}
+			]`),
+			"aggregator": json.RawMessage(`{
+				"Routes": [{"ID": 7, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 8453, "FromTokenSymbol": "USDC", "IsEnabled": true}],
+				"FeeSegments": [{"ID": 1, "RouteID": 7, "MinAmountUI": "0", "MaxAmountUI": "0", "IsEnabled": true}]
+			}`),
+		},
+	}
+
+	tokenInfoMgr := NewTokenInfoManager(nil, alerter)
+	bridgeFeeMgr := NewBridgeFeeManager(nil, alerter)
+	aggregatorMgr := NewAggregatorManager(nil, alerter)
+	if err := fixture.Import(tokenInfoMgr, bridgeFeeMgr, aggregatorMgr); err != nil {
+		t.Fatalf("Import fixture: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "loader.snapshot.json")
+	if err := WriteSnapshotFile(path, tokenInfoMgr, bridgeFeeMgr, aggregatorMgr); err != nil {
+		t.Fatalf("WriteSnapshotFile: %v", err)
+	}
+
+	restoredTokens := NewTokenInfoManager(nil, alerter)
+	restoredFees := NewBridgeFeeManager(nil, alerter)
+	restoredAggregator := NewAggregatorManager(nil, alerter)
+	if err := ImportSnapshotFile(path, restoredTokens, restoredFees, restoredAggregator); err != nil {
+		t.Fatalf("ImportSnapshotFile: %v", err)
+	}
+
+	token, ok := restoredTokens.GetByChainIdTokenAddr(42161, "0xaf88d065e77c8cc2239327c5edb3a432268e5831")
+	if !ok || token.Decimals != 6 {
+		t.Fatalf("unexpected token: %+v", token)
+	}
+	fee, ok := restoredFees.GetBridgeFee("usdc", "arbitrum", "base")
+	if !ok || fee.BridgeFeeRatioLv1 != 30 || fee.KeepDecimal != 2 {
+		t.Fatalf("unexpected bridge fee: %+v", fee)
+	}
+	if routes := restoredAggregator.GetRoutesByChainPair(42161, 8453); len(routes) != 1 {
+		t.Fatalf("unexpected routes: %v", routes)
+	}
+	if segments := restoredAggregator.GetFeeSegments(7); len(segments) != 1 {
+		t.Fatalf("unexpected fee segments: %v", segments)
+	}
+}
+
+func TestSnapshotImportErrors(t *testing.T) {
+	alerter := alert.NewCommonAlerter(0, 0)
+	snapshot := &Snapshot{Version: SnapshotVersion, Managers: map[string]json.RawMessage{}}
+	if err := snapshot.Import(NewDtcManager(nil, alerter)); err == nil {
+		t.Fatal("Import should fail when the manager is missing from the snapshot")
+	}
+
+	path := filepath.Join(t.TempDir(), "future.snapshot.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99, "managers": {}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadSnapshotFile(path); err == nil {
+		t.Fatal("ReadSnapshotFile should reject unknown versions")
+	}
+}
@@ -3,6 +3,7 @@ package loader
 import (
 	"database/sql"
 	"strings"
+	"sync"
 
 	"github.com/owlto-dao/utils-go/alert"
 )
@@ -28,26 +29,41 @@ type SrcTx struct {
 
 type SrcTxManager struct {
 	db      *sql.DB
+	dialect Dialect
 	alerter alert.Alerter
-	//mutex   *sync.RWMutex
+	mutex   *sync.RWMutex
 }
 
 func NewSrcTxManager(db *sql.DB, alerter alert.Alerter) *SrcTxManager {
 	return &SrcTxManager{
 		db:      db,
+		dialect: MySQLDialect,
 		alerter: alerter,
-		//mutex:   &sync.RWMutex{},
+		mutex:   &sync.RWMutex{},
 	}
 }
 
+// SetDialect sets the SQL dialect of db, MySQL by default.
+func (mgr *SrcTxManager) SetDialect(dialect Dialect) {
+	mgr.mutex.Lock()
+	mgr.dialect = dialect
+	mgr.mutex.Unlock()
+}
+
+func (mgr *SrcTxManager) getDialect() Dialect {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	return mgr.dialect
+}
+
 func (mgr *SrcTxManager) IsSrcTxExist(chainId int32, txHash string) bool {
 	var id int64
-	err := mgr.db.QueryRow("SELECT id FROM t_src_transaction where chainid = ? and tx_hash = ? ", chainId, strings.TrimSpace(txHash)).Scan(&id)
+	err := mgr.db.QueryRow(mgr.getDialect().Rebind("SELECT id FROM t_src_transaction where chainid = ? and tx_hash = ? "), chainId, strings.TrimSpace(txHash)).Scan(&id)
 	return err == nil
 }
 
 func (mgr *SrcTxManager) SetResult(txHash string, isInvalid int32, isVerified int32) error {
-	_, err := mgr.db.Exec("update t_src_transaction set is_invalid = ?, is_verified = ? where tx_hash = ? ", isInvalid, isVerified, txHash)
+	_, err := mgr.db.Exec(mgr.getDialect().Rebind("update t_src_transaction set is_invalid = ?, is_verified = ? where tx_hash = ? "), isInvalid, isVerified, txHash)
 	if err != nil {
 		mgr.alerter.AlertText("update t_transfer is_invalid error :", err)
 		return err
@@ -56,7 +72,7 @@ func (mgr *SrcTxManager) SetResult(txHash string, isInvalid int32, isVerified in
 }
 
 func (mgr *SrcTxManager) SetResultWithDstHash(txHash string, isInvalid int32, isVerified int32, dstHash string) error {
-	_, err := mgr.db.Exec("update t_src_transaction set is_invalid = ?, is_verified = ?, dst_tx_hash = ? where tx_hash = ? ", isInvalid, isVerified, dstHash, txHash)
+	_, err := mgr.db.Exec(mgr.getDialect().Rebind("update t_src_transaction set is_invalid = ?, is_verified = ?, dst_tx_hash = ? where tx_hash = ? "), isInvalid, isVerified, dstHash, txHash)
 	if err != nil {
 		mgr.alerter.AlertText("update t_transfer is_invalid error :", err)
 		return err
@@ -73,8 +89,7 @@ func (mgr *SrcTxManager) Save(tx *SrcTx) error {
 	tx.TargetAddress.String = strings.TrimSpace(tx.TargetAddress.String)
 	tx.SrcTokenName.String = strings.TrimSpace(tx.SrcTokenName.String)
 
-	query := `INSERT IGNORE INTO t_src_transaction (chainid, tx_hash, sender, receiver, target_address, token, value, dst_chainid, is_testnet, tx_timestamp, src_token_name, src_token_decimal, is_cctp, src_nonce, thirdparty_channel, to_exchange)
-              VALUES (?, ?, ?, ?, ?, ?, ? , ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	query := mgr.getDialect().InsertIgnore("t_src_transaction", "chainid", "tx_hash", "sender", "receiver", "target_address", "token", "value", "dst_chainid", "is_testnet", "tx_timestamp", "src_token_name", "src_token_decimal", "is_cctp", "src_nonce", "thirdparty_channel", "to_exchange")
 
 	// Execute the SQL statement with tx data
 	_, err := mgr.db.Exec(query, tx.ChainId, tx.TxHash, tx.Sender, tx.Receiver, tx.TargetAddress, tx.Token, tx.Value, tx.DstChainid, tx.IsTestnet, tx.TxTimestamp, tx.SrcTokenName, tx.SrcTokenDecimal, tx.IsCctp, tx.SrcNonce, tx.ThirdpartyChannel, tx.ToExchange)
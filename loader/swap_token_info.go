@@ -15,12 +15,20 @@ import (
 type SwapTokenInfoManager struct {
 	allTokens                  []*TokenInfo
 	db                         *sql.DB
+	dialect                    Dialect
 	alerter                    alert.Alerter
 	mutex                      *sync.RWMutex
 	chainNameTokenAddressCache asynccache.AsyncCache
 }
 
 func NewSwapTokenInfoManager(db *sql.DB, alerter alert.Alerter) *SwapTokenInfoManager {
+	mgr := &SwapTokenInfoManager{
+		db:      db,
+		dialect: MySQLDialect,
+		alerter: alerter,
+		mutex:   &sync.RWMutex{},
+	}
+
 	chainNameTokenAddressCacheOption := asynccache.Options{
 		RefreshDuration: 1 * time.Hour,
 		Fetcher: func(key string) (interface{}, error) {
@@ -28,7 +36,7 @@ func NewSwapTokenInfoManager(db *sql.DB, alerter alert.Alerter) *SwapTokenInfoMa
 			if len(s) != 2 {
 				return nil, fmt.Errorf("invalid key: %s", key)
 			}
-			token, err := GetByChainNameTokenAddrFromDb(db, s[0], s[1])
+			token, err := getByChainNameTokenAddrFromDb(db, mgr.getDialect(), s[0], s[1])
 			if err != nil {
 				log.Errorf("chain %v addr %v query db error: %v", s[0], s[1], err)
 				return nil, err
@@ -38,13 +46,21 @@ func NewSwapTokenInfoManager(db *sql.DB, alerter alert.Alerter) *SwapTokenInfoMa
 		EnableExpire:   true,
 		ExpireDuration: 30 * time.Minute,
 	}
+	mgr.chainNameTokenAddressCache = asynccache.NewAsyncCache(chainNameTokenAddressCacheOption)
+	return mgr
+}
 
-	return &SwapTokenInfoManager{
-		db:                         db,
-		alerter:                    alerter,
-		mutex:                      &sync.RWMutex{},
-		chainNameTokenAddressCache: asynccache.NewAsyncCache(chainNameTokenAddressCacheOption),
-	}
+// SetDialect sets the SQL dialect of db, MySQL by default.
+func (mgr *SwapTokenInfoManager) SetDialect(dialect Dialect) {
+	mgr.mutex.Lock()
+	mgr.dialect = dialect
+	mgr.mutex.Unlock()
+}
+
+func (mgr *SwapTokenInfoManager) getDialect() Dialect {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
+	return mgr.dialect
 }
 
 func (mgr *SwapTokenInfoManager) GetByChainNameTokenAddr(chainName string, tokenAddr string) (*TokenInfo, bool) {
@@ -63,8 +79,12 @@ func (mgr *SwapTokenInfoManager) GetByChainNameTokenAddr(chainName string, token
 }
 
 func GetByChainNameTokenAddrFromDb(db *sql.DB, chainName string, tokenAddr string) (*TokenInfo, error) {
+	return getByChainNameTokenAddrFromDb(db, MySQLDialect, chainName, tokenAddr)
+}
+
+func getByChainNameTokenAddrFromDb(db *sql.DB, dialect Dialect, chainName string, tokenAddr string) (*TokenInfo, error) {
 	var token TokenInfo
-	err := db.QueryRow("SELECT token_name, chain_name, token_address, decimals, icon FROM t_swap_token_info where chain_name = ? and token_address = ?", chainName, tokenAddr).
+	err := db.QueryRow(dialect.Rebind("SELECT token_name, chain_name, token_address, decimals, icon FROM t_swap_token_info where chain_name = ? and token_address = ?"), chainName, tokenAddr).
 		Scan(&token.TokenName, &token.ChainName, &token.TokenAddress, &token.Decimals, &token.Icon)
 	if err != nil {
 		return nil, fmt.Errorf("get token info by chainName %v token Addr err: %v", chainName, err)
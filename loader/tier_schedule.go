@@ -0,0 +1,108 @@
+package loader
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// TierSchedule maps an amount to the value of the tier it falls in, with exact decimal arithmetic.
+// Tier i covers the amounts up to Breakpoints[i] and the last tier every amount above them,
+// so a schedule has one more value than breakpoints. Tiers are tried in order, the first one
+// whose breakpoint is not exceeded wins.
+type TierSchedule struct {
+	Breakpoints []decimal.Decimal
+	Values      []decimal.Decimal
+	// InclusiveBounds keeps an amount equal to a breakpoint in the lower tier.
+	InclusiveBounds bool
+}
+
+func NewTierSchedule(breakpoints []decimal.Decimal, values []decimal.Decimal, inclusiveBounds bool) (*TierSchedule, error) {
+	if len(values) != len(breakpoints)+1 {
+		return nil, fmt.Errorf("tier schedule needs %d values for %d breakpoints, got %d", len(breakpoints)+1, len(breakpoints), len(values))
+	}
+	return &TierSchedule{Breakpoints: breakpoints, Values: values, InclusiveBounds: inclusiveBounds}, nil
+}
+
+// ParseTierSchedule is NewTierSchedule with decimal strings.
+func ParseTierSchedule(breakpoints []string, values []string, inclusiveBounds bool) (*TierSchedule, error) {
+	bps, err := parseDecimals(breakpoints)
+	if err != nil {
+		return nil, fmt.Errorf("tier breakpoint: %w", err)
+	}
+	vals, err := parseDecimals(values)
+	if err != nil {
+		return nil, fmt.Errorf("tier value: %w", err)
+	}
+	return NewTierSchedule(bps, vals, inclusiveBounds)
+}
+
+func parseDecimals(strs []string) ([]decimal.Decimal, error) {
+	decimals := make([]decimal.Decimal, 0, len(strs))
+	for _, str := range strs {
+		d, err := decimal.NewFromString(str)
+		if err != nil {
+			return nil, err
+		}
+		decimals = append(decimals, d)
+	}
+	return decimals, nil
+}
+
+// Len returns the number of tiers.
+func (s *TierSchedule) Len() int {
+	return len(s.Values)
+}
+
+func (s *TierSchedule) within(amount decimal.Decimal, tier int) bool {
+	if s.InclusiveBounds {
+		return amount.LessThanOrEqual(s.Breakpoints[tier])
+	}
+	return amount.LessThan(s.Breakpoints[tier])
+}
+
+// Tier returns the tier of amount, for a fee charged on top of amount.
+func (s *TierSchedule) Tier(amount decimal.Decimal) int {
+	for i := range s.Breakpoints {
+		if s.within(amount, i) {
+			return i
+		}
+	}
+	return len(s.Breakpoints)
+}
+
+// TierIncluded returns the tier of an amount the fee is already included in.
+// net returns that amount minus the fee computed from a tier value, the first tier whose net amount
+// stays within its breakpoint wins.
+func (s *TierSchedule) TierIncluded(net func(value decimal.Decimal) decimal.Decimal) int {
+	for i := range s.Breakpoints {
+		if s.within(net(s.Values[i]), i) {
+			return i
+		}
+	}
+	return len(s.Breakpoints)
+}
+
+// Value returns the value of a tier.
+func (s *TierSchedule) Value(tier int) decimal.Decimal {
+	return s.Values[tier]
+}
+
+// ValueOf returns the value of the tier of amount, see Tier.
+func (s *TierSchedule) ValueOf(amount decimal.Decimal) decimal.Decimal {
+	return s.Values[s.Tier(amount)]
+}
+
+// IncludedValueOf returns the value of the tier of an amount the fee is included in, see TierIncluded.
+func (s *TierSchedule) IncludedValueOf(net func(value decimal.Decimal) decimal.Decimal) decimal.Decimal {
+	return s.Values[s.TierIncluded(net)]
+}
+
+// tierAmount parses the string column of a legacy tier, falling back to its float column
+// for rows imported from snapshots lacking the string.
+func tierAmount(str string, f float64) (decimal.Decimal, error) {
+	if str == "" {
+		return decimal.NewFromFloat(f), nil
+	}
+	return decimal.NewFromString(str)
+}
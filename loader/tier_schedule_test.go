@@ -0,0 +1,72 @@
+package loader
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTierSchedule(t *testing.T) {
+	schedule, err := ParseTierSchedule([]string{"100", "1000"}, []string{"3", "2", "1"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for amount, want := range map[string]int{"99.99": 0, "100": 1, "999": 1, "1000": 2, "5000": 2} {
+		if got := schedule.Tier(decimal.RequireFromString(amount)); got != want {
+			t.Errorf("tier of %s = %d, want %d", amount, got, want)
+		}
+	}
+
+	schedule.InclusiveBounds = true
+	if got := schedule.Tier(decimal.RequireFromString("100")); got != 0 {
+		t.Errorf("inclusive tier of 100 = %d, want 0", got)
+	}
+	// 102 - 3 = 99 fits the first tier, 103.5 - 3 does not but 103.5 - 2 fits the second.
+	gross := decimal.RequireFromString("102")
+	if got := schedule.TierIncluded(func(fee decimal.Decimal) decimal.Decimal { return gross.Sub(fee) }); got != 0 {
+		t.Errorf("included tier of 102 = %d, want 0", got)
+	}
+	gross = decimal.RequireFromString("103.5")
+	if got := schedule.TierIncluded(func(fee decimal.Decimal) decimal.Decimal { return gross.Sub(fee) }); got != 1 {
+		t.Errorf("included tier of 103.5 = %d, want 1", got)
+	}
+
+	if _, err := ParseTierSchedule([]string{"100"}, []string{"1"}, false); err == nil {
+		t.Error("ParseTierSchedule should reject a value count not matching the breakpoints")
+	}
+}
+
+func TestDtcTiers(t *testing.T) {
+	mgr := NewDtcManager(nil, nil)
+	dtc := &Dtc{
+		TokenName: "USDC", FromChainName: "Arbitrum", ToChainName: "Base",
+		DtcLv1Str: "1", DtcLv2Str: "0.5", DtcLv3Str: "0.2", DtcLv4Str: "0",
+		AmountLv1Str: "100", AmountLv2Str: "1000", AmountLv3Str: "10000", AmountLv4Str: "0",
+	}
+	if err := dtc.buildSchedule(); err != nil {
+		t.Fatal(err)
+	}
+	mgr.setDtcs([]*Dtc{dtc})
+
+	cases := []struct {
+		value           int64
+		toInclude       int64
+		alreadyIncluded int64
+	}{
+		{100_000000, 1_000000, 1_000000},
+		{100_500000, 500000, 1_000000},
+		{101_000000, 500000, 1_000000},
+		{101_000001, 500000, 500000},
+		{20000_000000, 0, 0},
+	}
+	for _, c := range cases {
+		value := big.NewInt(c.value)
+		if got, ok := mgr.GetDtcToIncludeBigInt("usdc", "arbitrum", "base", value, 6); !ok || got.Int64() != c.toInclude {
+			t.Errorf("dtc to include of %d = %v, want %d", c.value, got, c.toInclude)
+		}
+		if got, ok := mgr.GetIncludedDtcBigInt("usdc", "arbitrum", "base", value, 6); !ok || got.Int64() != c.alreadyIncluded {
+			t.Errorf("included dtc of %d = %v, want %d", c.value, got, c.alreadyIncluded)
+		}
+	}
+}
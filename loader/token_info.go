@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"math/big"
 	"strings"
 	"sync"
@@ -27,10 +29,15 @@ type TokenInfoManager struct {
 	chainNameTokenNames map[string]map[string]*TokenInfo
 	chainIdTokenAddrs   map[int64]map[string]*TokenInfo
 	chainIdTokenNames   map[int64]map[string]*TokenInfo
+	chainNameBackends   map[string]Backend
+	chainIdBackends     map[int64]Backend
 	allTokens           []*TokenInfo
+	chainInfoMgr        *ChainInfoManager
 	db                  *sql.DB
 	alerter             alert.Alerter
 	mutex               *sync.RWMutex
+
+	changeSubscribers *subscribers[TokenInfoDiff]
 }
 
 func NewTokenInfoManager(db *sql.DB, alerter alert.Alerter) *TokenInfoManager {
@@ -39,18 +46,64 @@ func NewTokenInfoManager(db *sql.DB, alerter alert.Alerter) *TokenInfoManager {
 		chainNameTokenNames: make(map[string]map[string]*TokenInfo),
 		chainIdTokenAddrs:   make(map[int64]map[string]*TokenInfo),
 		chainIdTokenNames:   make(map[int64]map[string]*TokenInfo),
+		chainNameBackends:   make(map[string]Backend),
+		chainIdBackends:     make(map[int64]Backend),
 		db:                  db,
 		alerter:             alerter,
 		mutex:               &sync.RWMutex{},
+
+		changeSubscribers: newSubscribers[TokenInfoDiff](),
+	}
+}
+
+// TokenInfoKey identifies a token of a backend chain in a TokenInfoDiff.
+func TokenInfoKey(backend Backend, chainName string, tokenAddr string) string {
+	return strings.ToLower(strings.TrimSpace(chainName)) + "/" + CanonicalAddress(backend, tokenAddr)
+}
+
+// SubscribeChanges registers fn to be called with the added, removed and modified tokens after every reload
+// that changed something. The returned function unsubscribes fn.
+func (mgr *TokenInfoManager) SubscribeChanges(fn func(TokenInfoDiff)) func() {
+	return mgr.changeSubscribers.subscribe(fn)
+}
+
+func tokenInfoEqual(a *TokenInfo, b *TokenInfo) bool {
+	if a.Id != b.Id || a.TokenName != b.TokenName || a.ChainName != b.ChainName || a.ChainId != b.ChainId ||
+		a.TokenAddress != b.TokenAddress || a.Decimals != b.Decimals || a.FullName != b.FullName ||
+		a.Icon != b.Icon || a.Url != b.Url {
+		return false
 	}
+	if a.TotalSupply == nil || b.TotalSupply == nil {
+		return a.TotalSupply == b.TotalSupply
+	}
+	return a.TotalSupply.Cmp(b.TotalSupply) == 0
+}
+
+func keyTokens(tokens []*TokenInfo, chainInfoMgr *ChainInfoManager) map[string]*TokenInfo {
+	keyed := make(map[string]*TokenInfo, len(tokens))
+	for _, token := range tokens {
+		keyed[TokenInfoKey(backendByName(chainInfoMgr, token.ChainName), token.ChainName, token.TokenAddress)] = token
+	}
+	return keyed
+}
+
+// chainBackend returns the backend token addresses of chainName are keyed by. The caller holds the lock.
+func (mgr *TokenInfoManager) chainBackend(chainName string) Backend {
+	if backend, ok := mgr.chainNameBackends[strings.ToLower(chainName)]; ok {
+		return backend
+	}
+	backend := backendByName(mgr.chainInfoMgr, chainName)
+	mgr.chainNameBackends[strings.ToLower(chainName)] = backend
+	return backend
 }
 
 func (mgr *TokenInfoManager) GetByChainNameTokenAddr(chainName string, tokenAddr string) (*TokenInfo, bool) {
 	mgr.mutex.RLock()
 	defer mgr.mutex.RUnlock()
-	tokenAddrs, ok := mgr.chainNameTokenAddrs[strings.ToLower(strings.TrimSpace(chainName))]
+	chainName = strings.ToLower(strings.TrimSpace(chainName))
+	tokenAddrs, ok := mgr.chainNameTokenAddrs[chainName]
 	if ok {
-		token, ok := tokenAddrs[strings.ToLower(strings.TrimSpace(tokenAddr))]
+		token, ok := tokenAddrs[CanonicalAddress(mgr.chainNameBackends[chainName], tokenAddr)]
 		return token, ok
 	}
 	return nil, false
@@ -61,7 +114,7 @@ func (mgr *TokenInfoManager) GetByChainIdTokenAddr(chainId int64, tokenAddr stri
 	defer mgr.mutex.RUnlock()
 	tokenAddrs, ok := mgr.chainIdTokenAddrs[chainId]
 	if ok {
-		token, ok := tokenAddrs[strings.ToLower(strings.TrimSpace(tokenAddr))]
+		token, ok := tokenAddrs[CanonicalAddress(mgr.chainIdBackends[chainId], tokenAddr)]
 		return token, ok
 	}
 	return nil, false
@@ -76,7 +129,7 @@ func (mgr *TokenInfoManager) AddTokenInfo(token *TokenInfo) {
 		tokenAddrs = make(map[string]*TokenInfo)
 		mgr.chainNameTokenAddrs[strings.ToLower(token.ChainName)] = tokenAddrs
 	}
-	tokenAddrs[strings.ToLower(token.TokenAddress)] = token
+	tokenAddrs[CanonicalAddress(mgr.chainBackend(token.ChainName), token.TokenAddress)] = token
 
 	tokenNames, ok := mgr.chainNameTokenNames[strings.ToLower(token.ChainName)]
 	if !ok {
@@ -100,7 +153,7 @@ func (mgr *TokenInfoManager) AddToken(chainName string, tokenName string, tokenA
 		tokenAddrs = make(map[string]*TokenInfo)
 		mgr.chainNameTokenAddrs[strings.ToLower(token.ChainName)] = tokenAddrs
 	}
-	tokenAddrs[strings.ToLower(token.TokenAddress)] = &token
+	tokenAddrs[CanonicalAddress(mgr.chainBackend(token.ChainName), token.TokenAddress)] = &token
 
 	tokenNames, ok := mgr.chainNameTokenNames[strings.ToLower(token.ChainName)]
 	if !ok {
@@ -159,28 +212,38 @@ func (mgr *TokenInfoManager) GetTokenAddressesByChainId(chainId int64) []string
 }
 
 func (mgr *TokenInfoManager) GetAllTokens() []*TokenInfo {
+	mgr.mutex.RLock()
+	defer mgr.mutex.RUnlock()
 	return mgr.allTokens
 }
 
 func (mgr *TokenInfoManager) LoadAllToken(chainManager *ChainInfoManager) {
+	mgr.LoadTokens(chainManager)
+}
+
+// LoadTokens reloads t_token_info, adds the gas token of every chain known to chainManager,
+// and returns the number of rows loaded from the table.
+func (mgr *TokenInfoManager) LoadTokens(chainManager *ChainInfoManager) (int, error) {
 	if chainManager == nil {
 		panic("chainManager is required")
 	}
+	mgr.mutex.Lock()
+	mgr.chainInfoMgr = chainManager
+	mgr.mutex.Unlock()
+
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT id, token_name, chain_name, chain_id, token_address, decimals, icon FROM t_token_info")
 
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_token_info error", err)
-		return
+		return 0, fmt.Errorf("select t_token_info: %w", err)
 	}
 
 	defer rows.Close()
 
-	chainNameTokenAddrs := make(map[string]map[string]*TokenInfo)
-	chainNameTokenNames := make(map[string]map[string]*TokenInfo)
-	chainIdTokenAddrs := make(map[int64]map[string]*TokenInfo)
-	chainIdTokenNames := make(map[int64]map[string]*TokenInfo)
-	allTokens := make([]*TokenInfo, 0)
+	tokens := make([]*TokenInfo, 0)
+	chainTokenNames := make(map[string]bool)
 	counter := 0
 
 	// Iterate over the result set
@@ -195,38 +258,8 @@ func (mgr *TokenInfoManager) LoadAllToken(chainManager *ChainInfoManager) {
 			token.TokenName = strings.TrimSpace(token.TokenName)
 			token.Icon = strings.TrimSpace(token.Icon)
 
-			tokenAddrs, ok := chainNameTokenAddrs[strings.ToLower(token.ChainName)]
-			if !ok {
-				tokenAddrs = make(map[string]*TokenInfo)
-				chainNameTokenAddrs[strings.ToLower(token.ChainName)] = tokenAddrs
-			}
-			tokenAddrs[strings.ToLower(token.TokenAddress)] = &token
-
-			tokenNames, ok := chainNameTokenNames[strings.ToLower(token.ChainName)]
-			if !ok {
-				tokenNames = make(map[string]*TokenInfo)
-				chainNameTokenNames[strings.ToLower(token.ChainName)] = tokenNames
-			}
-			tokenNames[strings.ToLower(token.TokenName)] = &token
-
-			// Index by chainId
-			if token.ChainId > 0 {
-				tokenAddrsById, ok := chainIdTokenAddrs[token.ChainId]
-				if !ok {
-					tokenAddrsById = make(map[string]*TokenInfo)
-					chainIdTokenAddrs[token.ChainId] = tokenAddrsById
-				}
-				tokenAddrsById[strings.ToLower(token.TokenAddress)] = &token
-
-				tokenNamesById, ok := chainIdTokenNames[token.ChainId]
-				if !ok {
-					tokenNamesById = make(map[string]*TokenInfo)
-					chainIdTokenNames[token.ChainId] = tokenNamesById
-				}
-				tokenNamesById[strings.ToLower(token.TokenName)] = &token
-			}
-
-			allTokens = append(allTokens, &token)
+			tokens = append(tokens, &token)
+			chainTokenNames[strings.ToLower(token.ChainName)+"/"+strings.ToLower(token.TokenName)] = true
 			counter++
 		}
 	}
@@ -234,7 +267,7 @@ func (mgr *TokenInfoManager) LoadAllToken(chainManager *ChainInfoManager) {
 	// Check for errors from iterating over rows
 	if err = rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_token_info row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_token_info: %w", err)
 	}
 
 	allIDs := chainManager.GetChainInfoAutoIds()
@@ -250,29 +283,105 @@ func (mgr *TokenInfoManager) LoadAllToken(chainManager *ChainInfoManager) {
 		token.Decimals = chainInfo.GasTokenDecimal
 		token.Icon = chainInfo.GasTokenIcon
 
+		nameKey := strings.ToLower(token.ChainName) + "/" + strings.ToLower(token.TokenName)
+		if !chainTokenNames[nameKey] {
+			tokens = append(tokens, &token)
+			chainTokenNames[nameKey] = true
+		}
+	}
+
+	mgr.setTokens(tokens)
+	return counter, nil
+}
+
+// setTokens swaps the indexes for tokens and notifies subscribers.
+// Tokens without chain id, such as gas tokens, are only indexed by chain name. Addresses are keyed by the
+// canonical form of the backend of their chain, as known to the chains of the last LoadTokens.
+func (mgr *TokenInfoManager) setTokens(tokens []*TokenInfo) {
+	mgr.mutex.RLock()
+	chainInfoMgr := mgr.chainInfoMgr
+	mgr.mutex.RUnlock()
+
+	chainNameTokenAddrs := make(map[string]map[string]*TokenInfo)
+	chainNameTokenNames := make(map[string]map[string]*TokenInfo)
+	chainIdTokenAddrs := make(map[int64]map[string]*TokenInfo)
+	chainIdTokenNames := make(map[int64]map[string]*TokenInfo)
+	chainNameBackends := make(map[string]Backend)
+	chainIdBackends := make(map[int64]Backend)
+	allTokens := make([]*TokenInfo, 0, len(tokens))
+
+	for _, token := range tokens {
+		backend, ok := chainNameBackends[strings.ToLower(token.ChainName)]
+		if !ok {
+			backend = backendByName(chainInfoMgr, token.ChainName)
+			chainNameBackends[strings.ToLower(token.ChainName)] = backend
+		}
+
 		tokenAddrs, ok := chainNameTokenAddrs[strings.ToLower(token.ChainName)]
 		if !ok {
 			tokenAddrs = make(map[string]*TokenInfo)
 			chainNameTokenAddrs[strings.ToLower(token.ChainName)] = tokenAddrs
 		}
+		tokenAddrs[CanonicalAddress(backend, token.TokenAddress)] = token
+
 		tokenNames, ok := chainNameTokenNames[strings.ToLower(token.ChainName)]
 		if !ok {
 			tokenNames = make(map[string]*TokenInfo)
 			chainNameTokenNames[strings.ToLower(token.ChainName)] = tokenNames
 		}
-		_, ok = chainNameTokenNames[strings.ToLower(token.ChainName)][strings.ToLower(token.TokenName)]
-		if !ok {
-			tokenNames[strings.ToLower(token.TokenName)] = &token
-			tokenAddrs[strings.ToLower(token.TokenAddress)] = &token
-			allTokens = append(allTokens, &token)
+		tokenNames[strings.ToLower(token.TokenName)] = token
+
+		// Index by chainId
+		if token.ChainId > 0 {
+			tokenAddrsById, ok := chainIdTokenAddrs[token.ChainId]
+			if !ok {
+				tokenAddrsById = make(map[string]*TokenInfo)
+				chainIdTokenAddrs[token.ChainId] = tokenAddrsById
+			}
+			tokenAddrsById[CanonicalAddress(backend, token.TokenAddress)] = token
+			chainIdBackends[token.ChainId] = backend
+
+			tokenNamesById, ok := chainIdTokenNames[token.ChainId]
+			if !ok {
+				tokenNamesById = make(map[string]*TokenInfo)
+				chainIdTokenNames[token.ChainId] = tokenNamesById
+			}
+			tokenNamesById[strings.ToLower(token.TokenName)] = token
 		}
+
+		allTokens = append(allTokens, token)
 	}
 
 	mgr.mutex.Lock()
+	oldTokens := mgr.allTokens
 	mgr.chainNameTokenAddrs = chainNameTokenAddrs
 	mgr.chainNameTokenNames = chainNameTokenNames
 	mgr.chainIdTokenAddrs = chainIdTokenAddrs
 	mgr.chainIdTokenNames = chainIdTokenNames
+	mgr.chainNameBackends = chainNameBackends
+	mgr.chainIdBackends = chainIdBackends
 	mgr.allTokens = allTokens
 	mgr.mutex.Unlock()
+
+	if diff := computeDiff(keyTokens(oldTokens, chainInfoMgr), keyTokens(allTokens, chainInfoMgr), tokenInfoEqual); !diff.IsEmpty() {
+		mgr.changeSubscribers.notify(diff)
+	}
+}
+
+func (mgr *TokenInfoManager) SnapshotName() string {
+	return "token_info"
+}
+
+func (mgr *TokenInfoManager) ExportSnapshot() (json.RawMessage, error) {
+	return exportRows(mgr.GetAllTokens())
+}
+
+// ImportSnapshot replaces the tokens with the snapshot ones, gas tokens included.
+func (mgr *TokenInfoManager) ImportSnapshot(data json.RawMessage) error {
+	tokens, err := importRows[*TokenInfo](data)
+	if err != nil {
+		return err
+	}
+	mgr.setTokens(tokens)
+	return nil
 }
@@ -2,6 +2,8 @@ package loader
 
 import (
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"strings"
 	"sync"
 
@@ -41,11 +43,17 @@ func (mgr *UpdatePriceManager) GetUpdatePrice(tokenName string) (*UpdatePrice, b
 }
 
 func (mgr *UpdatePriceManager) LoadAllPrice() {
+	mgr.Load()
+}
+
+// Load reloads t_update_price and returns the number of prices loaded.
+func (mgr *UpdatePriceManager) Load() (int, error) {
 	// Query the database to select only id and name fields
 	rows, err := mgr.db.Query("SELECT token, price, update_timestamp FROM t_update_price")
 	if err != nil || rows == nil {
+		err = queryError(err)
 		mgr.alerter.AlertText("select t_update error", err)
-		return
+		return 0, fmt.Errorf("select t_update_price: %w", err)
 	}
 	defer rows.Close()
 
@@ -69,10 +77,42 @@ func (mgr *UpdatePriceManager) LoadAllPrice() {
 	// Check for errors from iterating over rows
 	if err := rows.Err(); err != nil {
 		mgr.alerter.AlertText("get next t_update_price row error", err)
-		return
+		return 0, fmt.Errorf("iterate t_update_price: %w", err)
+	}
+
+	mgr.mutex.Lock()
+	mgr.tokens = tokens
+	mgr.mutex.Unlock()
+	return counter, nil
+}
+
+func (mgr *UpdatePriceManager) SnapshotName() string {
+	return "update_price"
+}
+
+func (mgr *UpdatePriceManager) ExportSnapshot() (json.RawMessage, error) {
+	mgr.mutex.RLock()
+	prices := make([]*UpdatePrice, 0, len(mgr.tokens))
+	for _, price := range mgr.tokens {
+		prices = append(prices, price)
+	}
+	mgr.mutex.RUnlock()
+	return exportRows(prices)
+}
+
+func (mgr *UpdatePriceManager) ImportSnapshot(data json.RawMessage) error {
+	prices, err := importRows[*UpdatePrice](data)
+	if err != nil {
+		return err
+	}
+
+	tokens := make(map[string]*UpdatePrice)
+	for _, price := range prices {
+		tokens[strings.ToLower(price.TokenName)] = price
 	}
 
 	mgr.mutex.Lock()
 	mgr.tokens = tokens
 	mgr.mutex.Unlock()
+	return nil
 }
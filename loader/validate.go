@@ -0,0 +1,342 @@
+package loader
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// IssueKind classifies a configuration inconsistency found by a Validator.
+type IssueKind string
+
+const (
+	IssueDanglingReference       IssueKind = "dangling_reference"
+	IssueOverlappingSegments     IssueKind = "overlapping_segments"
+	IssueNonMonotonicTiers       IssueKind = "non_monotonic_tiers"
+	IssueDisabledChainReferenced IssueKind = "disabled_chain_referenced"
+	IssueMissingFeeSegments      IssueKind = "missing_fee_segments"
+	IssueInvalidAmount           IssueKind = "invalid_amount"
+)
+
+// ValidationIssue is a single inconsistency, Key identifies the offending row within Table.
+type ValidationIssue struct {
+	Kind    IssueKind
+	Table   string
+	Key     string
+	Message string
+}
+
+func (issue ValidationIssue) String() string {
+	return fmt.Sprintf("[%s] %s %s: %s", issue.Kind, issue.Table, issue.Key, issue.Message)
+}
+
+// ValidationReport lists the issues found by a Validator, sorted by table, key and kind.
+type ValidationReport struct {
+	Issues []ValidationIssue
+}
+
+func (r *ValidationReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+func (r *ValidationReport) String() string {
+	if !r.HasIssues() {
+		return "no issues"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d issues", len(r.Issues))
+	for _, issue := range r.Issues {
+		sb.WriteString("\n")
+		sb.WriteString(issue.String())
+	}
+	return sb.String()
+}
+
+func (r *ValidationReport) add(kind IssueKind, table string, key string, format string, args ...interface{}) {
+	r.Issues = append(r.Issues, ValidationIssue{Kind: kind, Table: table, Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validator cross-checks the data loaded by the managers. Nil managers are skipped,
+// checks referencing a nil manager are skipped too.
+type Validator struct {
+	Chains         *ChainInfoManager
+	Tokens         *TokenInfoManager
+	BridgeFees     *BridgeFeeManager
+	Dtcs           *DtcManager
+	LpInfos        *LpInfoManager
+	MakerAddresses *MakerAddressManager
+	Aggregator     *AggregatorManager
+}
+
+// Validate runs every check and returns the issues found.
+func (v *Validator) Validate() *ValidationReport {
+	report := &ValidationReport{Issues: make([]ValidationIssue, 0)}
+	v.validateTokens(report)
+	v.validateBridgeFees(report)
+	v.validateDtcs(report)
+	v.validateLpInfos(report)
+	v.validateAggregator(report)
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		a, b := report.Issues[i], report.Issues[j]
+		if a.Table != b.Table {
+			return a.Table < b.Table
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Message < b.Message
+	})
+	return report
+}
+
+// checkChainName reports a chain name missing from t_chain_info or disabled there.
+func (v *Validator) checkChainName(report *ValidationReport, table string, key string, name string) {
+	if v.Chains == nil {
+		return
+	}
+	chain, ok := v.Chains.GetChainInfoByName(name)
+	if !ok {
+		report.add(IssueDanglingReference, table, key, "chain %s not found in t_chain_info", name)
+	} else if chain.Disabled != 0 {
+		report.add(IssueDisabledChainReferenced, table, key, "chain %s is disabled", name)
+	}
+}
+
+// checkChainId is checkChainName for references by chain id.
+func (v *Validator) checkChainId(report *ValidationReport, table string, key string, chainId int64) {
+	if v.Chains == nil {
+		return
+	}
+	chain, ok := v.Chains.GetChainInfoByInt64ChainId(chainId)
+	if !ok {
+		report.add(IssueDanglingReference, table, key, "chain id %d not found in t_chain_info", chainId)
+	} else if chain.Disabled != 0 {
+		report.add(IssueDisabledChainReferenced, table, key, "chain %s (%d) is disabled", chain.Name, chainId)
+	}
+}
+
+func (v *Validator) validateTokens(report *ValidationReport) {
+	if v.Tokens == nil || v.Chains == nil {
+		return
+	}
+	for _, token := range v.Tokens.GetAllTokens() {
+		if _, ok := v.Chains.GetChainInfoByName(token.ChainName); !ok {
+			report.add(IssueDanglingReference, "t_token_info", fmt.Sprintf("%s/%s", token.ChainName, token.TokenAddress),
+				"chain %s not found in t_chain_info", token.ChainName)
+		}
+	}
+}
+
+func (v *Validator) validateBridgeFees(report *ValidationReport) {
+	if v.BridgeFees == nil {
+		return
+	}
+	for _, fee := range v.BridgeFees.GetAllBridgeFees() {
+		key := fmt.Sprintf("%s/%s/%s", fee.TokenName, fee.FromChainName, fee.ToChainName)
+		v.checkChainName(report, "t_dynamic_bridge_fee", key, fee.FromChainName)
+		v.checkChainName(report, "t_dynamic_bridge_fee", key, fee.ToChainName)
+		checkTierAmounts(report, "t_dynamic_bridge_fee", key, fee.AmountLv1, fee.AmountLv2, fee.AmountLv3)
+	}
+}
+
+func (v *Validator) validateDtcs(report *ValidationReport) {
+	if v.Dtcs == nil {
+		return
+	}
+	for _, fromTo := range v.Dtcs.GetDtcs() {
+		for _, to := range fromTo {
+			for _, dtc := range to {
+				key := fmt.Sprintf("%s/%s/%s", dtc.TokenName, dtc.FromChainName, dtc.ToChainName)
+				v.checkChainName(report, "t_dynamic_dtc", key, dtc.FromChainName)
+				v.checkChainName(report, "t_dynamic_dtc", key, dtc.ToChainName)
+				checkTierAmounts(report, "t_dynamic_dtc", key, dtc.AmountLv1, dtc.AmountLv2, dtc.AmountLv3)
+			}
+		}
+	}
+}
+
+// checkTierAmounts reports tier thresholds that do not strictly increase.
+func checkTierAmounts(report *ValidationReport, table string, key string, amounts ...float64) {
+	for i := 1; i < len(amounts); i++ {
+		if amounts[i] <= amounts[i-1] {
+			report.add(IssueNonMonotonicTiers, table, key, "amount lv%d %v is not above amount lv%d %v", i+1, amounts[i], i, amounts[i-1])
+		}
+	}
+}
+
+func (v *Validator) validateLpInfos(report *ValidationReport) {
+	if v.LpInfos == nil {
+		return
+	}
+	var makers map[Backend]map[string]bool
+	if v.MakerAddresses != nil {
+		makers = v.MakerAddresses.allAddresses()
+	}
+	for _, lpInfo := range v.LpInfos.GetAllLpInfos() {
+		key := fmt.Sprintf("v%d/%s/%s/%s/%s", lpInfo.Version, lpInfo.TokenName, lpInfo.FromChainName, lpInfo.ToChainName, lpInfo.MakerAddress)
+		if lpInfo.IsDisabled == 0 {
+			v.checkChainName(report, "t_lp_info", key, lpInfo.FromChainName)
+			v.checkChainName(report, "t_lp_info", key, lpInfo.ToChainName)
+		}
+		if makers != nil && !hasMakerAddress(makers, lpInfo.MakerAddress) {
+			report.add(IssueDanglingReference, "t_lp_info", key, "maker address %s not found in t_maker_addresses", lpInfo.MakerAddress)
+		}
+		if lpInfo.MaxValue < lpInfo.MinValue {
+			report.add(IssueInvalidAmount, "t_lp_info", key, "max value %v is below min value %v", lpInfo.MaxValue, lpInfo.MinValue)
+		}
+	}
+}
+
+// hasMakerAddress reports whether address is one of makers, by backend, in the canonical form of that backend.
+func hasMakerAddress(makers map[Backend]map[string]bool, address string) bool {
+	for backend, addresses := range makers {
+		if addresses[CanonicalAddress(backend, address)] {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Validator) validateAggregator(report *ValidationReport) {
+	if v.Aggregator == nil {
+		return
+	}
+	mgr := v.Aggregator
+	mgr.mutex.RLock()
+	aggregatorConfigs := mgr.aggregatorConfigs
+	routesByID := mgr.routesByID
+	feeSegmentsByRouteID := mgr.feeSegmentsByRouteID
+	mgr.mutex.RUnlock()
+
+	for routeID, route := range routesByID {
+		key := fmt.Sprintf("route %d", routeID)
+		if _, ok := aggregatorConfigs[route.AggregateID]; !ok && len(aggregatorConfigs) > 0 {
+			report.add(IssueDanglingReference, "t_aggregate_route_config", key, "aggregator %s not found in t_aggregate_config", route.AggregateID)
+		}
+		if route.IsEnabled {
+			v.checkChainId(report, "t_aggregate_route_config", key, route.FromChainID)
+			v.checkChainId(report, "t_aggregate_route_config", key, route.ToChainID)
+		}
+		if route.IsEnabled && len(feeSegmentsByRouteID[routeID]) == 0 {
+			report.add(IssueMissingFeeSegments, "t_aggregate_route_config", key, "enabled route has no fee segment")
+		}
+	}
+
+	for routeID, segments := range feeSegmentsByRouteID {
+		if _, ok := routesByID[routeID]; !ok {
+			for _, seg := range segments {
+				report.add(IssueDanglingReference, "t_aggregate_route_fee_segment", fmt.Sprintf("segment %d", seg.ID), "route %d not found", routeID)
+			}
+			continue
+		}
+		checkFeeSegmentOverlaps(report, segments)
+	}
+}
+
+// checkFeeSegmentOverlaps reports enabled segments of a route whose [min, max) ranges intersect.
+func checkFeeSegmentOverlaps(report *ValidationReport, segments []*FeeSegment) {
+	type segmentRange struct {
+		seg      *FeeSegment
+		min, max *big.Rat // max is nil when unbounded
+	}
+	ranges := make([]segmentRange, 0, len(segments))
+	for _, seg := range segments {
+		if !seg.IsEnabled {
+			continue
+		}
+		key := fmt.Sprintf("segment %d", seg.ID)
+		min, ok := new(big.Rat).SetString(strings.TrimSpace(seg.MinAmountUI))
+		if !ok {
+			report.add(IssueInvalidAmount, "t_aggregate_route_fee_segment", key, "min amount %q is not a number", seg.MinAmountUI)
+			continue
+		}
+		var max *big.Rat
+		if maxStr := strings.TrimSpace(seg.MaxAmountUI); maxStr != "" && maxStr != "0" {
+			if max, ok = new(big.Rat).SetString(maxStr); !ok {
+				report.add(IssueInvalidAmount, "t_aggregate_route_fee_segment", key, "max amount %q is not a number", seg.MaxAmountUI)
+				continue
+			}
+			if max.Cmp(min) <= 0 {
+				report.add(IssueInvalidAmount, "t_aggregate_route_fee_segment", key, "max amount %s is not above min amount %s", seg.MaxAmountUI, seg.MinAmountUI)
+				continue
+			}
+		}
+		ranges = append(ranges, segmentRange{seg: seg, min: min, max: max})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].min.Cmp(ranges[j].min) < 0 })
+	for i := 1; i < len(ranges); i++ {
+		prev, cur := ranges[i-1], ranges[i]
+		if prev.max == nil || prev.max.Cmp(cur.min) > 0 {
+			report.add(IssueOverlappingSegments, "t_aggregate_route_fee_segment", fmt.Sprintf("segment %d", cur.seg.ID),
+				"range starting at %s overlaps segment %d of route %d", cur.seg.MinAmountUI, prev.seg.ID, cur.seg.RouteID)
+		}
+	}
+}
+
+// ValidateSnapshotFile validates the managers found in the snapshot at path, without any database
+// or rpc connection. It is meant for CI runs against a dump of staging or production.
+func ValidateSnapshotFile(path string) (*ValidationReport, error) {
+	snapshot, err := ReadSnapshotFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &Validator{}
+	chains := NewChainInfoManager(nil, nil)
+	chains.withoutClients = true
+	if ok, err := importIfPresent(snapshot, chains); err != nil {
+		return nil, err
+	} else if ok {
+		v.Chains = chains
+	}
+	tokens := NewTokenInfoManager(nil, nil)
+	if ok, err := importIfPresent(snapshot, tokens); err != nil {
+		return nil, err
+	} else if ok {
+		v.Tokens = tokens
+	}
+	bridgeFees := NewBridgeFeeManager(nil, nil)
+	if ok, err := importIfPresent(snapshot, bridgeFees); err != nil {
+		return nil, err
+	} else if ok {
+		v.BridgeFees = bridgeFees
+	}
+	dtcs := NewDtcManager(nil, nil)
+	if ok, err := importIfPresent(snapshot, dtcs); err != nil {
+		return nil, err
+	} else if ok {
+		v.Dtcs = dtcs
+	}
+	lpInfos := NewLpInfoManager(nil, nil)
+	if ok, err := importIfPresent(snapshot, lpInfos); err != nil {
+		return nil, err
+	} else if ok {
+		v.LpInfos = lpInfos
+	}
+	makerAddresses := NewMakerAddressManager(nil)
+	if ok, err := importIfPresent(snapshot, makerAddresses); err != nil {
+		return nil, err
+	} else if ok {
+		v.MakerAddresses = makerAddresses
+	}
+	aggregator := NewAggregatorManager(nil, nil)
+	if ok, err := importIfPresent(snapshot, aggregator); err != nil {
+		return nil, err
+	} else if ok {
+		v.Aggregator = aggregator
+	}
+	return v.Validate(), nil
+}
+
+func importIfPresent(snapshot *Snapshot, mgr Snapshotter) (bool, error) {
+	if _, ok := snapshot.Managers[mgr.SnapshotName()]; !ok {
+		return false, nil
+	}
+	return true, snapshot.Import(mgr)
+}
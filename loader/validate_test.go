@@ -0,0 +1,76 @@
+package loader
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateSnapshotFile(t *testing.T) {
+	snapshot := &Snapshot{
+		Version: SnapshotVersion,
+		Managers: map[string]json.RawMessage{
+			"chain_info": json.RawMessage(`[
+				{"Id": 1, "ChainId": "42161", "Name": "Arbitrum", "Backend": 1},
+				{"Id": 2, "ChainId": "8453", "Name": "Base", "Backend": 1, "Disabled": 1}
+			]`),
+			"bridge_fee": json.RawMessage(`[
+				{"TokenName": "USDC", "FromChainName": "Arbitrum", "ToChainName": "Base", "AmountLv1": 100, "AmountLv2": 50, "AmountLv3": 1000},
+				{"TokenName": "USDC", "FromChainName": "Arbitrum", "ToChainName": "Linea", "AmountLv1": 100, "AmountLv2": 500, "AmountLv3": 1000}
+			]`),
+			"lp_info": json.RawMessage(`[
+				{"Version": 1, "TokenName": "USDC", "FromChainName": "Arbitrum", "ToChainName": "Arbitrum", "MakerAddress": "0xabc", "MinValue": 1, "MaxValue": 10}
+			]`),
+			"maker_address": json.RawMessage(`[
+				{"GroupId": 1, "Addresses": [{"GroupId": 1, "Backend": 1, "Address": "0xDEF"}]}
+			]`),
+			"aggregator": json.RawMessage(`{
+				"Routes": [
+					{"ID": 7, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 42161, "IsEnabled": true},
+					{"ID": 8, "AggregateID": 1, "FromChainID": 42161, "ToChainID": 42161, "IsEnabled": true}
+				],
+				"FeeSegments": [
+					{"ID": 1, "RouteID": 7, "MinAmountUI": "0", "MaxAmountUI": "100", "IsEnabled": true},
+					{"ID": 2, "RouteID": 7, "MinAmountUI": "50", "MaxAmountUI": "0", "IsEnabled": true}
+				]
+			}`),
+		},
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "staging.snapshot.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := ValidateSnapshotFile(path)
+	if err != nil {
+		t.Fatalf("ValidateSnapshotFile: %v", err)
+	}
+
+	want := []ValidationIssue{
+		{Kind: IssueDisabledChainReferenced, Table: "t_dynamic_bridge_fee", Key: "USDC/Arbitrum/Base"},
+		{Kind: IssueNonMonotonicTiers, Table: "t_dynamic_bridge_fee", Key: "USDC/Arbitrum/Base"},
+		{Kind: IssueDanglingReference, Table: "t_dynamic_bridge_fee", Key: "USDC/Arbitrum/Linea"},
+		{Kind: IssueMissingFeeSegments, Table: "t_aggregate_route_config", Key: "route 8"},
+		{Kind: IssueOverlappingSegments, Table: "t_aggregate_route_fee_segment", Key: "segment 2"},
+		{Kind: IssueDanglingReference, Table: "t_lp_info", Key: "v1/USDC/Arbitrum/Arbitrum/0xabc"},
+	}
+	if len(report.Issues) != len(want) {
+		t.Fatalf("got %s", report)
+	}
+	for _, w := range want {
+		found := false
+		for _, issue := range report.Issues {
+			if issue.Kind == w.Kind && issue.Table == w.Table && issue.Key == w.Key {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("missing %s %s %s in %s", w.Kind, w.Table, w.Key, report)
+		}
+	}
+}
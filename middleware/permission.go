@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/owlto-dao/utils-go/errors"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+	"github.com/owlto-dao/utils-go/response"
+)
+
+// AuditWriter records the privileged calls, CmsUserManager writes them to dev_audit_log.
+type AuditWriter interface {
+	WriteAudit(ctx context.Context, record *loader.CmsAuditRecord) error
+}
+
+// AuditTimeout bounds the audit write of a call, which delays its response.
+var AuditTimeout = 3 * time.Second
+
+// RequirePermission lets the call through when the roles of the address set by Auth grant every permission,
+// and responds UnauthorizedErr without address or ForbiddenErr otherwise. Allowed and denied calls are
+// both audited, through cmsUserMgr when audit is nil.
+func RequirePermission(cmsUserMgr *loader.CmsUserManager, audit AuditWriter, permissions ...string) gin.HandlerFunc {
+	if audit == nil {
+		audit = cmsUserMgr
+	}
+	required := strings.Join(permissions, ",")
+
+	return func(c *gin.Context) {
+		address, ok := GetUserAddress(c)
+		if !ok || address == "" {
+			response.RespondError(c, errors.UnauthorizedErr)
+			c.Abort()
+			return
+		}
+
+		record := &loader.CmsAuditRecord{
+			Address:    address,
+			UserName:   cmsUserMgr.GetAddressName(address),
+			Permission: required,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Allowed:    true,
+			CreatedAt:  time.Now(),
+		}
+		for _, permission := range permissions {
+			if !cmsUserMgr.HasPermission(address, permission) {
+				record.Allowed = false
+				break
+			}
+		}
+
+		if !record.Allowed {
+			writeAudit(c, audit, record)
+			response.RespondError(c, errors.ForbiddenErr)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+		record.Status = c.Writer.Status()
+		writeAudit(c, audit, record)
+	}
+}
+
+// writeAudit writes record within AuditTimeout, even when the client is gone.
+func writeAudit(c *gin.Context, audit AuditWriter, record *loader.CmsAuditRecord) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), AuditTimeout)
+	defer cancel()
+	if err := audit.WriteAudit(ctx, record); err != nil {
+		log.Errorf("write audit of %s %s by %s error: %v", record.Method, record.Path, record.Address, err)
+	}
+}
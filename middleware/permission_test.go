@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/response"
+)
+
+type auditRecorder struct {
+	records []*loader.CmsAuditRecord
+}
+
+func (a *auditRecorder) WriteAudit(ctx context.Context, record *loader.CmsAuditRecord) error {
+	a.records = append(a.records, record)
+	return nil
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cmsUserMgr := loader.NewCmsUserManager(nil)
+	if err := cmsUserMgr.ImportSnapshot([]byte(`[
+		{"Id": 1, "Name": "ops", "Address": "0xops", "Roles": [{"Id": 1, "Name": "lp_admin", "Permissions": ["lp:*"]}]}
+	]`)); err != nil {
+		t.Fatal(err)
+	}
+	audit := &auditRecorder{}
+
+	newRouter := func(address string) *gin.Engine {
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			if address != "" {
+				c.Set(UserAddressKey, address)
+			}
+		})
+		router.POST("/lp", RequirePermission(cmsUserMgr, audit, "lp:write"), func(c *gin.Context) {
+			response.RespondOK(c, nil)
+		})
+		return router
+	}
+	call := func(address string) int64 {
+		w := httptest.NewRecorder()
+		newRouter(address).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/lp", nil))
+		var resp response.Response
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Code
+	}
+
+	if code := call("0xOPS"); code != 0 {
+		t.Fatalf("ops should be allowed, got code %d", code)
+	}
+	if code := call("0xother"); code != 1005 {
+		t.Fatalf("expected forbidden, got code %d", code)
+	}
+	if code := call(""); code != 1004 {
+		t.Fatalf("expected unauthorized, got code %d", code)
+	}
+
+	if len(audit.records) != 2 {
+		t.Fatalf("expected 2 audit records, got %d", len(audit.records))
+	}
+	allowed, denied := audit.records[0], audit.records[1]
+	if !allowed.Allowed || allowed.UserName != "ops" || allowed.Status != http.StatusOK || allowed.Permission != "lp:write" || allowed.Path != "/lp" {
+		t.Fatalf("unexpected allowed record %+v", allowed)
+	}
+	if denied.Allowed || denied.Address != "0xother" {
+		t.Fatalf("unexpected denied record %+v", denied)
+	}
+}
+
+type blockedAudit struct{}
+
+func (blockedAudit) WriteAudit(ctx context.Context, record *loader.CmsAuditRecord) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRequirePermissionAuditTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defer func(timeout time.Duration) { AuditTimeout = timeout }(AuditTimeout)
+	AuditTimeout = 50 * time.Millisecond
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set(UserAddressKey, "0xother") })
+	router.POST("/lp", RequirePermission(loader.NewCmsUserManager(nil), blockedAudit{}, "lp:write"))
+
+	start := time.Now()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/lp", nil))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("a stuck audit write held the response for %s", elapsed)
+	}
+}
@@ -2,6 +2,7 @@ package network
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -112,3 +113,67 @@ func DoRequest(url string, timeoutms int, headers map[string]string, data interf
 	}
 	return nil
 }
+
+// DefaultRequestTimeout bounds the requests of DoRequestWithContext whose ctx has no deadline.
+const DefaultRequestTimeout = 30 * time.Second
+
+// requestClient sends the requests of DoRequestWithContext, leaving http.DefaultClient to the rest of the process.
+var requestClient = &http.Client{}
+
+// DoRequestWithContext sends a GET request to url, a POST of data as JSON when it is not nil, and decodes
+// the JSON response into result. The request is bound to ctx, and to DefaultRequestTimeout when ctx has no deadline.
+func DoRequestWithContext(ctx context.Context, url string, headers map[string]string, data interface{}, result interface{}) error {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultRequestTimeout)
+		defer cancel()
+	}
+
+	var method = "GET"
+	var dataBytes []byte
+	if data != nil {
+		method = "POST"
+		var err error
+		dataBytes, err = json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request body %v : %v", url, err)
+		}
+	}
+
+	// Create a new HTTP request
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(dataBytes))
+	if err != nil {
+		return fmt.Errorf("error creating request %v : %v", url, err)
+	}
+
+	// Set request headers
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	for key, header := range headers {
+		req.Header.Set(key, header)
+	}
+
+	// Send the HTTP request
+	resp, err := requestClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending request %v : %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	// Check response status code
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %v : %v", url, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error read body %v : %v - %v", url, err, body)
+	}
+
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return fmt.Errorf("error read body %v : %v - %v", url, err, body)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package rpc
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+
+	starknetrpc "github.com/NethermindEth/starknet.go/rpc"
+	"github.com/block-vision/sui-go-sdk/sui"
+	"github.com/ethereum/go-ethereum/ethclient"
+	solrpc "github.com/gagliardetto/solana-go/rpc"
+	"github.com/owlto-dao/utils-go/apollosdk"
+	"github.com/owlto-dao/utils-go/cosmos"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/sentioxyz/fuel-go"
+)
+
+// RpcConstructor builds the Rpc of a chain of the backend it is registered for.
+type RpcConstructor func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error)
+
+// UnknownBackendError is returned by GetRpc for a backend without registered constructor.
+type UnknownBackendError struct {
+	Backend loader.Backend
+}
+
+func (e *UnknownBackendError) Error() string {
+	return fmt.Sprintf("unsupport backend %v", e.Backend)
+}
+
+// UnconfiguredBackendError is returned by GetRpc when the chain lacks what its backend needs, such as its client.
+type UnconfiguredBackendError struct {
+	Backend   loader.Backend
+	ChainName string
+	Reason    string
+}
+
+func (e *UnconfiguredBackendError) Error() string {
+	return fmt.Sprintf("backend %v of chain %s is not configured: %s", e.Backend, e.ChainName, e.Reason)
+}
+
+var (
+	backends      = make(map[loader.Backend]RpcConstructor)
+	backendsMutex sync.RWMutex
+)
+
+func init() {
+	RegisterBackend(loader.EthereumBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		if err := requireClient[*ethclient.Client](chainInfo); err != nil {
+			return nil, err
+		}
+		return NewEvmRpc(chainInfo), nil
+	})
+	RegisterBackend(loader.StarknetBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		if err := requireClient[*starknetrpc.Provider](chainInfo); err != nil {
+			return nil, err
+		}
+		return NewStarknetRpc(chainInfo), nil
+	})
+	RegisterBackend(loader.SolanaBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		if err := requireClient[*solrpc.Client](chainInfo); err != nil {
+			return nil, err
+		}
+		return NewSolanaRpc(chainInfo), nil
+	})
+	RegisterBackend(loader.BitcoinBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		return NewBitcoinRpc(chainInfo, apolloSDK), nil
+	})
+	RegisterBackend(loader.ZksliteBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		return NewZksliteRpc(chainInfo), nil
+	})
+	RegisterBackend(loader.TonBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		return NewTonRpc(chainInfo), nil
+	})
+	RegisterBackend(loader.CosmosBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		if err := requireClient[*cosmos.Client](chainInfo); err != nil {
+			return nil, err
+		}
+		return NewCosmosRpc(chainInfo), nil
+	})
+	RegisterBackend(loader.NetworkTypeBfc, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		return NewBenfenRpc(chainInfo), nil
+	})
+	RegisterBackend(loader.SuiBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		if err := requireClient[sui.ISuiAPI](chainInfo); err != nil {
+			return nil, err
+		}
+		return NewSuiRpc(chainInfo), nil
+	})
+	RegisterBackend(loader.FuelBackend, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		if err := requireClient[*fuel.Client](chainInfo); err != nil {
+			return nil, err
+		}
+		return NewFuelRpc(chainInfo), nil
+	})
+}
+
+// RegisterBackend sets the constructor GetRpc uses for backend, replacing the previous one.
+// The backends of this package register themselves, other packages can add or override backends.
+func RegisterBackend(backend loader.Backend, constructor RpcConstructor) {
+	backendsMutex.Lock()
+	defer backendsMutex.Unlock()
+	backends[backend] = constructor
+}
+
+// RegisteredBackends returns the backends with a constructor, in ascending order.
+func RegisteredBackends() []loader.Backend {
+	backendsMutex.RLock()
+	defer backendsMutex.RUnlock()
+	registered := make([]loader.Backend, 0, len(backends))
+	for backend := range backends {
+		registered = append(registered, backend)
+	}
+	sort.Slice(registered, func(i, j int) bool { return registered[i] < registered[j] })
+	return registered
+}
+
+// requireClient checks that the client of chainInfo is a T.
+func requireClient[T any](chainInfo *loader.ChainInfo) error {
+	if _, ok := chainInfo.Client.(T); !ok {
+		return &UnconfiguredBackendError{
+			Backend:   chainInfo.Backend,
+			ChainName: chainInfo.Name,
+			Reason:    fmt.Sprintf("client is %T, want %s", chainInfo.Client, reflect.TypeOf((*T)(nil)).Elem()),
+		}
+	}
+	return nil
+}
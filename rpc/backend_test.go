@@ -0,0 +1,45 @@
+package rpc
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/owlto-dao/utils-go/apollosdk"
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+func TestGetRpcBackends(t *testing.T) {
+	client, err := ethclient.Dial("http://127.0.0.1:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rpc, err := GetRpc(&loader.ChainInfo{Name: "Base", Backend: loader.EthereumBackend, Client: client}, nil); err != nil || rpc.Backend() != int32(loader.EthereumBackend) {
+		t.Fatalf("GetRpc evm: %v", err)
+	}
+
+	var unconfigured *UnconfiguredBackendError
+	if _, err := GetRpc(&loader.ChainInfo{Name: "Sui", Backend: loader.SuiBackend}, nil); !errors.As(err, &unconfigured) || unconfigured.ChainName != "Sui" {
+		t.Fatalf("expected UnconfiguredBackendError, got %v", err)
+	}
+
+	var unknown *UnknownBackendError
+	if _, err := GetRpc(&loader.ChainInfo{Name: "Custom", Backend: 99}, nil); !errors.As(err, &unknown) || unknown.Backend != 99 {
+		t.Fatalf("expected UnknownBackendError, got %v", err)
+	}
+
+	RegisterBackend(99, func(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
+		return &blockNumberRpc{blockNumber: 7}, nil
+	})
+	defer func() {
+		backendsMutex.Lock()
+		delete(backends, 99)
+		backendsMutex.Unlock()
+	}()
+	if _, err := GetRpc(&loader.ChainInfo{Name: "Custom", Backend: 99}, nil); err != nil {
+		t.Fatalf("registered backend: %v", err)
+	}
+	if registered := RegisteredBackends(); registered[len(registered)-1] != 99 {
+		t.Fatalf("unexpected registered backends %v", registered)
+	}
+}
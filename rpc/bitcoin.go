@@ -3,6 +3,7 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/btcsuite/btcd/chaincfg"
 	"math/big"
@@ -12,13 +13,37 @@ import (
 	_ "github.com/gagliardetto/solana-go"
 	"github.com/ninja0404/go-unisat"
 	"github.com/owlto-dao/utils-go/apollosdk"
+	"github.com/owlto-dao/utils-go/esplora"
 	"github.com/owlto-dao/utils-go/loader"
 	"github.com/owlto-dao/utils-go/util"
 )
 
+// BitcoinRpc reads Bitcoin chains through the Esplora compatible API of the chain in esplora_api_config,
+// and reads BRC-20 balances, and native balances while Esplora is not configured, through Unisat.
 type BitcoinRpc struct {
 	chainInfo *loader.ChainInfo
 	apolloSDK *apollosdk.ApolloSDK
+	esplora   *esplora.Client
+}
+
+// errEsploraNotConfigured is returned by GetEsploraClient for a chain without Esplora server.
+var errEsploraNotConfigured = errors.New("no esplora server")
+
+// EsploraAPIConfig is the esplora_api_config of base_config, the Esplora server of each Bitcoin chain by name,
+// such as {"Bitcoin": {"Server": "https://mempool.space/api"}}.
+type EsploraAPIConfig map[string]*esploraServer
+
+type esploraServer struct {
+	Server string
+}
+
+func ParseEsploraAPIConfig(value string) (EsploraAPIConfig, error) {
+	var e EsploraAPIConfig
+	err := json.Unmarshal([]byte(value), &e)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
 }
 
 type UnisatAPIConfig map[string]*chainServerBearer
@@ -73,26 +98,82 @@ func (w *BitcoinRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr stri
 	return w.GetBalance(ctx, ownerAddr, tokenAddr)
 }
 
-func (w *BitcoinRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
-	ownerAddr = strings.TrimSpace(ownerAddr)
-	tokenAddr = strings.TrimSpace(tokenAddr)
-
+func (w *BitcoinRpc) unisatServer() (*chainServerBearer, error) {
+	if w.apolloSDK == nil {
+		return nil, fmt.Errorf("no apollo config for %v", w.chainInfo.Name)
+	}
 	unisatAPIConfig, err := apollosdk.GetConfig(w.apolloSDK, "base_config", "unisat_api_config", ParseUnisatAPIConfig)
 	if err != nil {
 		return nil, err
 	}
+	server, ok := unisatAPIConfig[w.chainInfo.Name]
+	if !ok || server == nil {
+		return nil, fmt.Errorf("no unisat api config for %v", w.chainInfo.Name)
+	}
+	return server, nil
+}
+
+// WithEsploraClient makes w use client instead of the server of esplora_api_config.
+func (w *BitcoinRpc) WithEsploraClient(client *esplora.Client) *BitcoinRpc {
+	w.esplora = client
+	return w
+}
+
+// GetEsploraClient returns the Esplora client of the chain, from esplora_api_config.
+func (w *BitcoinRpc) GetEsploraClient() (*esplora.Client, error) {
+	if w.esplora != nil {
+		return w.esplora, nil
+	}
+	if w.apolloSDK == nil {
+		return nil, fmt.Errorf("%w for %v", errEsploraNotConfigured, w.chainInfo.Name)
+	}
+	value, err := w.apolloSDK.GetString("base_config", "esplora_api_config")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(value) == "" {
+		// Apollo returns an empty value for a missing key
+		return nil, fmt.Errorf("%w for %v", errEsploraNotConfigured, w.chainInfo.Name)
+	}
+	esploraAPIConfig, err := ParseEsploraAPIConfig(value)
+	if err != nil {
+		return nil, err
+	}
+	server, ok := esploraAPIConfig[w.chainInfo.Name]
+	if !ok || server == nil || strings.TrimSpace(server.Server) == "" {
+		return nil, fmt.Errorf("%w for %v", errEsploraNotConfigured, w.chainInfo.Name)
+	}
+	return esplora.NewClient(server.Server), nil
+}
+
+// GetBalance returns the native balance in satoshi, or the BRC-20 balance of a "brc20_<tick>" token.
+// Native balances come from Esplora once the chain is in esplora_api_config, and are then confirmed only:
+// outputs received or spent by transactions still in the mempool are not counted until they are mined.
+// Until then they come from Unisat, whose satoshi balance may differ while transactions are pending.
+func (w *BitcoinRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	ownerAddr = strings.TrimSpace(ownerAddr)
+	tokenAddr = strings.TrimSpace(tokenAddr)
+
 	if util.IsHexStringZero(tokenAddr) {
-		resp, err := unisat.GetAddressBalance(ctx, unisatAPIConfig[w.chainInfo.Name].Server, unisatAPIConfig[w.chainInfo.Name].Bearer, ownerAddr)
+		client, err := w.GetEsploraClient()
+		if errors.Is(err, errEsploraNotConfigured) {
+			return w.getUnisatBalance(ctx, ownerAddr)
+		}
 		if err != nil {
 			return nil, err
 		}
-		if resp.Code != 0 {
-			return nil, fmt.Errorf("unisat GetAddressBalance error: %v", resp.Message)
+		info, err := client.Address(ctx, ownerAddr)
+		if err != nil {
+			return nil, err
 		}
-		return resp.Data.Satoshi, nil
+		return big.NewInt(info.ConfirmedBalance()), nil
 	} else if strings.HasPrefix(tokenAddr, "brc20_") && len(tokenAddr) > 6 {
+		server, err := w.unisatServer()
+		if err != nil {
+			return nil, err
+		}
 		brc20 := tokenAddr[6:]
-		resp, err := unisat.GetAddressBrc20TickInfo(ctx, unisatAPIConfig[w.chainInfo.Name].Server, unisatAPIConfig[w.chainInfo.Name].Bearer, ownerAddr, brc20)
+		resp, err := unisat.GetAddressBrc20TickInfo(ctx, server.Server, server.Bearer, ownerAddr, brc20)
 		if err != nil {
 			return nil, err
 		}
@@ -109,12 +190,66 @@ func (w *BitcoinRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr
 	}
 }
 
+func (w *BitcoinRpc) getUnisatBalance(ctx context.Context, ownerAddr string) (*big.Int, error) {
+	server, err := w.unisatServer()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := unisat.GetAddressBalance(ctx, server.Server, server.Bearer, ownerAddr)
+	if err != nil {
+		return nil, err
+	}
+	if resp.Code != 0 {
+		return nil, fmt.Errorf("unisat GetAddressBalance error: %v", resp.Message)
+	}
+	return resp.Data.Satoshi, nil
+}
+
+// GetUtxos returns the unspent outputs of an address.
+func (w *BitcoinRpc) GetUtxos(ctx context.Context, ownerAddr string) ([]esplora.Utxo, error) {
+	client, err := w.GetEsploraClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.Utxos(ctx, strings.TrimSpace(ownerAddr))
+}
+
+// GetRecommendedFees returns the recommended fee rates in sat/vB.
+func (w *BitcoinRpc) GetRecommendedFees(ctx context.Context) (*esplora.RecommendedFees, error) {
+	client, err := w.GetEsploraClient()
+	if err != nil {
+		return nil, err
+	}
+	return client.RecommendedFees(ctx)
+}
+
+// GetTxConfirmations returns the number of confirmations of a tx and its status, 0 while it is in the mempool.
+func (w *BitcoinRpc) GetTxConfirmations(ctx context.Context, hash string) (int64, *esplora.TxStatus, error) {
+	client, err := w.GetEsploraClient()
+	if err != nil {
+		return 0, nil, err
+	}
+	return client.Confirmations(ctx, hash)
+}
+
 func (w *BitcoinRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
 	return big.NewInt(0), fmt.Errorf("not impl")
 }
 
+// IsTxSuccess reports a confirmed tx as successful, and returns an error while it is in the mempool.
 func (w *BitcoinRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
-	return false, 0, fmt.Errorf("not impl")
+	client, err := w.GetEsploraClient()
+	if err != nil {
+		return false, 0, err
+	}
+	status, err := client.TxStatus(ctx, hash)
+	if err != nil {
+		return false, 0, err
+	}
+	if !status.Confirmed {
+		return false, 0, fmt.Errorf("tx %s not confirmed", hash)
+	}
+	return true, status.BlockHeight, nil
 }
 
 func (w *BitcoinRpc) Client() interface{} {
@@ -126,7 +261,11 @@ func (w *BitcoinRpc) Backend() int32 {
 }
 
 func (w *BitcoinRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
-	return 0, fmt.Errorf("not impl")
+	client, err := w.GetEsploraClient()
+	if err != nil {
+		return 0, err
+	}
+	return client.TipHeight(ctx)
 }
 
 //type unisatServer struct {
@@ -0,0 +1,113 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/apollosdk"
+	"github.com/owlto-dao/utils-go/esplora"
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+func TestBitcoinRpcEsplora(t *testing.T) {
+	ctx := context.Background()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/blocks/tip/height", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("850010"))
+	})
+	mux.HandleFunc("/tx/abc/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"confirmed":true,"block_height":850000}`))
+	})
+	mux.HandleFunc("/tx/def/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"confirmed":false}`))
+	})
+	mux.HandleFunc("/address/bc1qtest", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"address":"bc1qtest","chain_stats":{"funded_txo_sum":9000,"spent_txo_sum":4000},"mempool_stats":{"funded_txo_sum":100}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	chainRpc := NewBitcoinRpc(&loader.ChainInfo{Name: "Bitcoin", Backend: loader.BitcoinBackend}, nil).WithEsploraClient(esplora.NewClient(server.URL))
+
+	if height, err := chainRpc.GetLatestBlockNumber(ctx); err != nil || height != 850010 {
+		t.Fatalf("latest block number: %d %v", height, err)
+	}
+	if ok, height, err := chainRpc.IsTxSuccess(ctx, "abc"); err != nil || !ok || height != 850000 {
+		t.Fatalf("confirmed tx: %v %d %v", ok, height, err)
+	}
+	if _, _, err := chainRpc.IsTxSuccess(ctx, "def"); err == nil {
+		t.Fatal("expected unconfirmed tx error")
+	}
+	if balance, err := chainRpc.GetBalance(ctx, "bc1qtest", "0x0000000000000000000000000000000000000000"); err != nil || balance.Int64() != 5000 {
+		t.Fatalf("balance: %v %v", balance, err)
+	}
+	if _, err := chainRpc.GetBalance(ctx, "bc1qtest", "brc20_ordi"); err == nil {
+		t.Fatal("expected brc20 balance to need the unisat config")
+	}
+}
+
+func TestBitcoinRpcWithoutEsplora(t *testing.T) {
+	// rpc_end_point is not an Esplora server, native balances fall back to Unisat.
+	chainInfo := &loader.ChainInfo{Name: "Bitcoin", Backend: loader.BitcoinBackend, RpcEndPoint: "https://open-api.unisat.io"}
+	chainRpc, err := GetRpc(chainInfo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := chainRpc.GetLatestBlockNumber(context.Background()); !errors.Is(err, errEsploraNotConfigured) {
+		t.Fatalf("expected esplora not configured, got %v", err)
+	}
+	if _, err := chainRpc.GetBalance(context.Background(), "bc1qtest", "0x0000000000000000000000000000000000000000"); err == nil || errors.Is(err, errEsploraNotConfigured) {
+		t.Fatalf("expected the unisat fallback to need its config, got %v", err)
+	}
+
+	config, err := ParseEsploraAPIConfig(`{"Bitcoin": {"Server": "https://mempool.space/api"}}`)
+	if err != nil || config["Bitcoin"].Server != "https://mempool.space/api" {
+		t.Fatalf("unexpected esplora config %+v %v", config, err)
+	}
+}
+
+// newApolloServer serves the base_config namespace of a fake Apollo config service.
+func newApolloServer(t *testing.T, configurations map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/configfiles/json/"):
+			json.NewEncoder(w).Encode(configurations)
+		case strings.HasPrefix(r.URL.Path, "/configs/"):
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"appId": "utils", "cluster": "default", "namespaceName": "base_config",
+				"configurations": configurations, "releaseKey": "1",
+			})
+		default:
+			w.WriteHeader(http.StatusNotModified)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBitcoinRpcWithoutEsploraConfigKey(t *testing.T) {
+	unisatServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code": 0, "data": {"satoshi": 1234}}`))
+	}))
+	defer unisatServer.Close()
+	apolloServer := newApolloServer(t, map[string]string{
+		"unisat_api_config": `{"Bitcoin": {"Server": "` + unisatServer.URL + `"}}`,
+	})
+	apolloSDK, err := apollosdk.NewApolloSDK(apollosdk.SDKConfig{AppID: "utils", Cluster: "default", MetaAddr: apolloServer.URL, Namespaces: []string{"base_config"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chainRpc := NewBitcoinRpc(&loader.ChainInfo{Name: "Bitcoin", Backend: loader.BitcoinBackend}, apolloSDK)
+	if _, err := chainRpc.GetEsploraClient(); !errors.Is(err, errEsploraNotConfigured) {
+		t.Fatalf("a missing esplora_api_config key should mean esplora is not configured, got %v", err)
+	}
+	if balance, err := chainRpc.GetBalance(context.Background(), "bc1qtest", "0x0000000000000000000000000000000000000000"); err != nil || balance.Int64() != 1234 {
+		t.Fatalf("native balances should come from unisat: %v %v", balance, err)
+	}
+}
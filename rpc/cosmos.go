@@ -0,0 +1,128 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/owlto-dao/utils-go/cosmos"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+)
+
+// CosmosRpc reads Cosmos SDK chains. Token addresses are either bank denoms, native or IBC,
+// or bech32 CW20 contract addresses.
+type CosmosRpc struct {
+	chainInfo *loader.ChainInfo
+}
+
+func NewCosmosRpc(chainInfo *loader.ChainInfo) *CosmosRpc {
+	return &CosmosRpc{
+		chainInfo: chainInfo,
+	}
+}
+
+func (w *CosmosRpc) GetClient() *cosmos.Client {
+	return w.chainInfo.Client.(*cosmos.Client)
+}
+
+func (w *CosmosRpc) Client() interface{} {
+	return w.chainInfo.Client
+}
+
+func (w *CosmosRpc) Backend() int32 {
+	return int32(loader.CosmosBackend)
+}
+
+func (w *CosmosRpc) IsAddressValid(addr string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	prefix, err := w.GetClient().Prefix(ctx)
+	if err != nil {
+		log.Errorf("%v get bech32 prefix error %v", w.chainInfo.Name, err)
+		return false
+	}
+	return cosmos.ValidateAddress(strings.TrimSpace(addr), prefix) == nil
+}
+
+func (w *CosmosRpc) GetChecksumAddress(addr string) string {
+	return strings.ToLower(strings.TrimSpace(addr))
+}
+
+func (w *CosmosRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (*loader.TokenInfo, error) {
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if cosmos.IsBech32(tokenAddr) {
+		info, err := w.GetClient().Cw20TokenInfo(ctx, tokenAddr)
+		if err != nil {
+			return nil, err
+		}
+		totalSupply, ok := new(big.Int).SetString(info.TotalSupply, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid total supply %s of %s", info.TotalSupply, tokenAddr)
+		}
+		return &loader.TokenInfo{
+			TokenName:    info.Symbol,
+			ChainName:    w.chainInfo.Name,
+			TokenAddress: tokenAddr,
+			Decimals:     info.Decimals,
+			FullName:     info.Name,
+			TotalSupply:  totalSupply,
+		}, nil
+	}
+
+	metadata, err := w.GetClient().DenomMetadata(ctx, tokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	return &loader.TokenInfo{
+		TokenName:    metadata.Symbol,
+		ChainName:    w.chainInfo.Name,
+		TokenAddress: tokenAddr,
+		Decimals:     metadata.Decimals(),
+		FullName:     metadata.Name,
+		TotalSupply:  big.NewInt(0),
+	}, nil
+}
+
+func (w *CosmosRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if !cosmos.IsBech32(tokenAddr) {
+		return nil, fmt.Errorf("bank denom %s has no allowance", tokenAddr)
+	}
+	return w.GetClient().Cw20Allowance(ctx, tokenAddr, strings.TrimSpace(ownerAddr), strings.TrimSpace(spenderAddr))
+}
+
+func (w *CosmosRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
+	ownerAddr = strings.TrimSpace(ownerAddr)
+	tokenAddr = strings.TrimSpace(tokenAddr)
+	if tokenAddr == "" {
+		return nil, fmt.Errorf("empty denom")
+	}
+	if cosmos.IsBech32(tokenAddr) {
+		return w.GetClient().Cw20Balance(ctx, tokenAddr, ownerAddr, blockNumber)
+	}
+	return w.GetClient().Balance(ctx, ownerAddr, tokenAddr, blockNumber)
+}
+
+func (w *CosmosRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	return w.GetBalanceAtBlockNumber(ctx, ownerAddr, tokenAddr, 0)
+}
+
+func (w *CosmosRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
+	tx, err := w.GetClient().Tx(ctx, hash)
+	if err != nil {
+		return false, 0, err
+	}
+	return tx.Code == 0, tx.Height, nil
+}
+
+func (w *CosmosRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	height, err := w.GetClient().LatestHeight(ctx)
+	if err != nil {
+		log.Errorf("%v get latest block number error %v", w.chainInfo.Name, err)
+		return 0, err
+	}
+	return height, nil
+}
@@ -0,0 +1,153 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil/bech32"
+	"github.com/owlto-dao/utils-go/cosmos"
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+func testBech32(t *testing.T, prefix string, size int) string {
+	data, err := bech32.ConvertBits(bytes.Repeat([]byte{byte(size)}, size), 8, 5, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr, err := bech32.Encode(prefix, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return addr
+}
+
+func newCosmosFixture(t *testing.T, owner string, contract string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cosmos/auth/v1beta1/bech32", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"bech32_prefix":"osmo"}`))
+	})
+	mux.HandleFunc("/cosmos/bank/v1beta1/balances/"+owner+"/by_denom", func(w http.ResponseWriter, r *http.Request) {
+		amount := map[string]string{"uosmo": "1500", "ibc/27394FB0": "42"}[r.URL.Query().Get("denom")]
+		if r.Header.Get("x-cosmos-block-height") == "100" {
+			amount = "7"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"balance": cosmos.Coin{Denom: r.URL.Query().Get("denom"), Amount: amount}})
+	})
+	mux.HandleFunc("/cosmwasm/wasm/v1/contract/"+contract+"/smart/", func(w http.ResponseWriter, r *http.Request) {
+		query, err := base64.URLEncoding.DecodeString(strings.TrimPrefix(r.URL.Path, "/cosmwasm/wasm/v1/contract/"+contract+"/smart/"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch {
+		case strings.Contains(string(query), `"balance"`):
+			w.Write([]byte(`{"data":{"balance":"900"}}`))
+		case strings.Contains(string(query), `"allowance"`):
+			w.Write([]byte(`{"data":{"allowance":"300","expires":{"never":{}}}}`))
+		case strings.Contains(string(query), `"token_info"`):
+			w.Write([]byte(`{"data":{"name":"Test","symbol":"TST","decimals":6,"total_supply":"1000000"}}`))
+		default:
+			http.Error(w, "unknown query", http.StatusBadRequest)
+		}
+	})
+	mux.HandleFunc("/cosmos/base/tendermint/v1beta1/blocks/latest", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"block":{"header":{"height":"1234"}}}`))
+	})
+	mux.HandleFunc("/cosmos/tx/v1beta1/txs/", func(w http.ResponseWriter, r *http.Request) {
+		code := 0
+		if strings.HasSuffix(r.URL.Path, "/FF") {
+			code = 5
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"tx_response": map[string]interface{}{"height": "1200", "code": code}})
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"result":{"sync_info":{"latest_block_height":"1250"}}}`))
+	})
+	mux.HandleFunc("/tx", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("hash") != "0xAB" {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"error":{"code":-32603,"message":"Internal error","data":"tx not found"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":-1,"result":{"hash":"AB","height":"1201","tx_result":{"code":0}}}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCosmosRpc(t *testing.T) {
+	ctx := context.Background()
+	owner := testBech32(t, "osmo", 20)
+	contract := testBech32(t, "osmo", 32)
+	server := newCosmosFixture(t, owner, contract)
+
+	chainInfo := &loader.ChainInfo{Name: "Osmosis", Backend: loader.CosmosBackend}
+	client, err := loader.NewChainClient(loader.CosmosBackend, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chainInfo.Client = client
+	chainRpc, err := GetRpc(chainInfo, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !chainRpc.IsAddressValid(owner) || !chainRpc.IsAddressValid(contract) {
+		t.Fatalf("expected %s and %s to be valid", owner, contract)
+	}
+	if chainRpc.IsAddressValid(testBech32(t, "cosmos", 20)) || chainRpc.IsAddressValid("osmo1invalid") {
+		t.Fatal("expected foreign prefix and bad checksum to be invalid")
+	}
+
+	for denom, want := range map[string]int64{"uosmo": 1500, "ibc/27394FB0": 42, contract: 900} {
+		balance, err := chainRpc.GetBalance(ctx, owner, denom)
+		if err != nil || balance.Int64() != want {
+			t.Fatalf("balance of %s: %v %v, want %d", denom, balance, err, want)
+		}
+	}
+	if balance, err := chainRpc.GetBalanceAtBlockNumber(ctx, owner, "uosmo", 100); err != nil || balance.Int64() != 7 {
+		t.Fatalf("balance at height: %v %v", balance, err)
+	}
+
+	if allowance, err := chainRpc.GetAllowance(ctx, owner, contract, owner); err != nil || allowance.Int64() != 300 {
+		t.Fatalf("allowance: %v %v", allowance, err)
+	}
+	if _, err := chainRpc.GetAllowance(ctx, owner, "uosmo", owner); err == nil {
+		t.Fatal("expected no allowance for a bank denom")
+	}
+	if info, err := chainRpc.GetTokenInfo(ctx, contract); err != nil || info.TokenName != "TST" || info.Decimals != 6 {
+		t.Fatalf("token info: %+v %v", info, err)
+	}
+
+	if height, err := chainRpc.GetLatestBlockNumber(ctx); err != nil || height != 1234 {
+		t.Fatalf("lcd height: %d %v", height, err)
+	}
+	if ok, height, err := chainRpc.IsTxSuccess(ctx, "0xab"); err != nil || !ok || height != 1200 {
+		t.Fatalf("lcd tx: %v %d %v", ok, height, err)
+	}
+	if ok, _, err := chainRpc.IsTxSuccess(ctx, "ff"); err != nil || ok {
+		t.Fatalf("expected failed tx, got %v %v", ok, err)
+	}
+}
+
+func TestCosmosRpcTendermint(t *testing.T) {
+	ctx := context.Background()
+	server := newCosmosFixture(t, testBech32(t, "osmo", 20), testBech32(t, "osmo", 32))
+
+	chainRpc := NewCosmosRpc(&loader.ChainInfo{Name: "Osmosis", Backend: loader.CosmosBackend, Client: cosmos.NewClient(server.URL + "," + server.URL + ",osmo")})
+	if height, err := chainRpc.GetLatestBlockNumber(ctx); err != nil || height != 1250 {
+		t.Fatalf("tendermint height: %d %v", height, err)
+	}
+	if ok, height, err := chainRpc.IsTxSuccess(ctx, "AB"); err != nil || !ok || height != 1201 {
+		t.Fatalf("tendermint tx: %v %d %v", ok, height, err)
+	}
+	if _, _, err := chainRpc.IsTxSuccess(ctx, "CD"); err == nil {
+		t.Fatal("expected tx not found")
+	}
+}
@@ -0,0 +1,309 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	starknetrpc "github.com/NethermindEth/starknet.go/rpc"
+	"github.com/ethereum/go-ethereum/ethclient"
+	ethrpc "github.com/ethereum/go-ethereum/rpc"
+	solrpc "github.com/gagliardetto/solana-go/rpc"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/log"
+)
+
+// FailoverOptions tunes a FailoverClient, zero values use the defaults.
+type FailoverOptions struct {
+	NodeType   int32         // t_node_info type of the nodes to use, 0 means every type
+	TripAfter  int           // Consecutive failures after which an endpoint is tripped out, 3 by default
+	Cooldown   time.Duration // How long a tripped endpoint is skipped, 30s by default
+	CloseDelay time.Duration // How long the client of an endpoint no longer listed stays open for calls in flight, 1m by default
+
+	// IsEndpointError tells the errors of the endpoint, worth trying the next one, from those of the call itself,
+	// returned at once. IsTransportError by default.
+	IsEndpointError func(err error) bool
+}
+
+// EndpointStatus describes the health of an endpoint of a FailoverClient.
+type EndpointStatus struct {
+	URL          string
+	Failures     int       // Consecutive failures
+	TrippedUntil time.Time // Zero unless the endpoint is tripped out
+}
+
+type failoverEndpoint[T any] struct {
+	url          string
+	client       T
+	dialed       bool
+	failures     int
+	trippedUntil time.Time
+}
+
+// FailoverClient spreads the calls of a chain over its t_node_info nodes, best usability first,
+// falling back to the chain's RpcEndPoint. Endpoints failing TripAfter times in a row are skipped for Cooldown,
+// then given another chance. When every endpoint is tripped, the one recovering first is still tried.
+type FailoverClient[T any] struct {
+	chainInfo   *loader.ChainInfo
+	nodeInfoMgr *loader.NodeInfoManager
+	dial        func(endpoint string) (T, error)
+	options     FailoverOptions
+
+	endpoints map[string]*failoverEndpoint[T]
+	mutex     *sync.Mutex
+	now       func() time.Time
+}
+
+func NewFailoverClient[T any](chainInfo *loader.ChainInfo, nodeInfoMgr *loader.NodeInfoManager, dial func(endpoint string) (T, error), options FailoverOptions) *FailoverClient[T] {
+	if options.TripAfter <= 0 {
+		options.TripAfter = 3
+	}
+	if options.Cooldown <= 0 {
+		options.Cooldown = 30 * time.Second
+	}
+	if options.CloseDelay <= 0 {
+		options.CloseDelay = time.Minute
+	}
+	if options.IsEndpointError == nil {
+		options.IsEndpointError = IsTransportError
+	}
+	return &FailoverClient[T]{
+		chainInfo:   chainInfo,
+		nodeInfoMgr: nodeInfoMgr,
+		dial:        dial,
+		options:     options,
+		endpoints:   make(map[string]*failoverEndpoint[T]),
+		mutex:       &sync.Mutex{},
+		now:         time.Now,
+	}
+}
+
+func NewEvmFailoverClient(chainInfo *loader.ChainInfo, nodeInfoMgr *loader.NodeInfoManager, options FailoverOptions) *FailoverClient[*ethclient.Client] {
+	return NewFailoverClient(chainInfo, nodeInfoMgr, ethclient.Dial, options)
+}
+
+func NewSolanaFailoverClient(chainInfo *loader.ChainInfo, nodeInfoMgr *loader.NodeInfoManager, options FailoverOptions) *FailoverClient[*solrpc.Client] {
+	return NewFailoverClient(chainInfo, nodeInfoMgr, func(endpoint string) (*solrpc.Client, error) {
+		return solrpc.New(endpoint), nil
+	}, options)
+}
+
+func NewStarknetFailoverClient(chainInfo *loader.ChainInfo, nodeInfoMgr *loader.NodeInfoManager, options FailoverOptions) *FailoverClient[*starknetrpc.Provider] {
+	return NewFailoverClient(chainInfo, nodeInfoMgr, func(endpoint string) (*starknetrpc.Provider, error) {
+		return starknetrpc.NewProvider(endpoint)
+	}, options)
+}
+
+// IsTransportError reports whether err comes from reaching the endpoint rather than from the call:
+// network errors, timeouts, dropped connections, and HTTP 429 or 5xx responses.
+func IsTransportError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	var httpErr ethrpc.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// Do calls fn with the client of each endpoint in turn until one succeeds.
+// It stops early when ctx is done, or when fn fails with an error that is not an endpoint error.
+func (c *FailoverClient[T]) Do(ctx context.Context, fn func(ctx context.Context, client T) error) error {
+	urls := c.endpointURLs()
+	if len(urls) == 0 {
+		return fmt.Errorf("%s has no rpc endpoint", c.chainInfo.Name)
+	}
+
+	var errs []error
+	for _, endpoint := range c.candidates(urls) {
+		client, err := c.clientOf(endpoint)
+		if err == nil {
+			err = fn(ctx, client)
+			if err == nil {
+				c.recordSuccess(endpoint)
+				return nil
+			}
+			if ctx.Err() == nil && !c.options.IsEndpointError(err) {
+				// The call itself failed, another endpoint would fail it too
+				return err
+			}
+		}
+		if ctx.Err() != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		c.recordFailure(endpoint)
+		log.Errorf("%v rpc endpoint %v error: %v", c.chainInfo.Name, endpoint.url, err)
+		errs = append(errs, fmt.Errorf("%s: %w", endpoint.url, err))
+	}
+	return errors.Join(errs...)
+}
+
+// Statuses returns the status of the endpoints used so far and still listed.
+func (c *FailoverClient[T]) Statuses() []EndpointStatus {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	statuses := make([]EndpointStatus, 0, len(c.endpoints))
+	for _, endpoint := range c.endpoints {
+		statuses = append(statuses, EndpointStatus{URL: endpoint.url, Failures: endpoint.failures, TrippedUntil: endpoint.trippedUntil})
+	}
+	return statuses
+}
+
+// Close closes the clients dialed so far.
+func (c *FailoverClient[T]) Close() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, endpoint := range c.endpoints {
+		if endpoint.dialed {
+			closeClient(endpoint.client)
+		}
+	}
+	c.endpoints = make(map[string]*failoverEndpoint[T])
+}
+
+func closeClient(client any) {
+	switch client := client.(type) {
+	case interface{ Close() }:
+		client.Close()
+	case interface{ Close() error }:
+		if err := client.Close(); err != nil {
+			log.Errorf("close rpc client error: %v", err)
+		}
+	}
+}
+
+// endpointURLs returns the usable nodes of the chain in usability order, then its RpcEndPoint.
+func (c *FailoverClient[T]) endpointURLs() []string {
+	urls := make([]string, 0)
+	seen := make(map[string]bool)
+	add := func(url string) {
+		url = strings.TrimSpace(url)
+		if url != "" && !seen[url] {
+			seen[url] = true
+			urls = append(urls, url)
+		}
+	}
+
+	if c.nodeInfoMgr != nil {
+		realChainId, err := strconv.ParseInt(strings.TrimSpace(c.chainInfo.RealChainId), 10, 64)
+		if err == nil {
+			var nodes []*loader.NodeInfo
+			if c.options.NodeType != 0 {
+				nodes = c.nodeInfoMgr.GetNodeInfosByRealChainIdAndType(realChainId, c.options.NodeType)
+			} else {
+				nodes = c.nodeInfoMgr.GetNodeInfosByRealChainId(realChainId)
+			}
+			for _, node := range nodes {
+				if node.Usability > 0 {
+					add(node.RpcURL)
+				}
+			}
+		}
+	}
+	add(c.chainInfo.RpcEndPoint)
+	return urls
+}
+
+// candidates returns the endpoints to try, healthy ones first in the given order. Endpoints no longer in urls
+// are dropped and their clients closed after CloseDelay.
+func (c *FailoverClient[T]) candidates(urls []string) []*failoverEndpoint[T] {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	listed := make(map[string]bool, len(urls))
+	for _, url := range urls {
+		listed[url] = true
+	}
+	for url, endpoint := range c.endpoints {
+		if listed[url] {
+			continue
+		}
+		delete(c.endpoints, url)
+		if endpoint.dialed {
+			client := endpoint.client
+			time.AfterFunc(c.options.CloseDelay, func() { closeClient(client) })
+		}
+	}
+
+	now := c.now()
+	healthy := make([]*failoverEndpoint[T], 0, len(urls))
+	var recovering *failoverEndpoint[T]
+	for _, url := range urls {
+		endpoint, ok := c.endpoints[url]
+		if !ok {
+			endpoint = &failoverEndpoint[T]{url: url}
+			c.endpoints[url] = endpoint
+		}
+		if now.Before(endpoint.trippedUntil) {
+			if recovering == nil || endpoint.trippedUntil.Before(recovering.trippedUntil) {
+				recovering = endpoint
+			}
+			continue
+		}
+		healthy = append(healthy, endpoint)
+	}
+	if len(healthy) == 0 && recovering != nil {
+		healthy = append(healthy, recovering)
+	}
+	return healthy
+}
+
+// clientOf returns the client of endpoint, dialing it outside the lock on first use.
+func (c *FailoverClient[T]) clientOf(endpoint *failoverEndpoint[T]) (T, error) {
+	c.mutex.Lock()
+	if endpoint.dialed {
+		client := endpoint.client
+		c.mutex.Unlock()
+		return client, nil
+	}
+	c.mutex.Unlock()
+
+	client, err := c.dial(endpoint.url)
+	if err != nil {
+		return client, err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if endpoint.dialed {
+		// Dialed concurrently, keep the client installed first
+		closeClient(client)
+		return endpoint.client, nil
+	}
+	if c.endpoints[endpoint.url] != endpoint {
+		// Dropped while dialing, the client only serves this call
+		time.AfterFunc(c.options.CloseDelay, func() { closeClient(client) })
+		return client, nil
+	}
+	endpoint.client = client
+	endpoint.dialed = true
+	return client, nil
+}
+
+func (c *FailoverClient[T]) recordSuccess(endpoint *failoverEndpoint[T]) {
+	c.mutex.Lock()
+	endpoint.failures = 0
+	endpoint.trippedUntil = time.Time{}
+	c.mutex.Unlock()
+}
+
+func (c *FailoverClient[T]) recordFailure(endpoint *failoverEndpoint[T]) {
+	c.mutex.Lock()
+	endpoint.failures++
+	if endpoint.failures >= c.options.TripAfter {
+		endpoint.trippedUntil = c.now().Add(c.options.Cooldown)
+	}
+	c.mutex.Unlock()
+}
@@ -0,0 +1,238 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/owlto-dao/utils-go/alert"
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+func newBlockNumberServer(t *testing.T, healthy *atomic.Bool, calls *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x10"}`, req.ID)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFailoverClientTripsAndRecovers(t *testing.T) {
+	var primaryHealthy, backupHealthy atomic.Bool
+	var primaryCalls, backupCalls atomic.Int32
+	backupHealthy.Store(true)
+	primary := newBlockNumberServer(t, &primaryHealthy, &primaryCalls)
+	backup := newBlockNumberServer(t, &backupHealthy, &backupCalls)
+
+	nodeInfoMgr := loader.NewNodeInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	nodes, _ := json.Marshal([]*loader.NodeInfo{
+		{Id: 1, RealChainId: 8453, RpcURL: primary.URL, Usability: 90},
+		{Id: 2, RealChainId: 8453, RpcURL: backup.URL, Usability: 10},
+	})
+	if err := nodeInfoMgr.ImportSnapshot(nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	chainInfo := &loader.ChainInfo{Name: "Base", RealChainId: "8453"}
+	client := NewEvmFailoverClient(chainInfo, nodeInfoMgr, FailoverOptions{TripAfter: 2, Cooldown: time.Minute})
+	defer client.Close()
+	now := time.Now()
+	client.now = func() time.Time { return now }
+
+	blockNumber := func() uint64 {
+		var number uint64
+		err := client.Do(context.Background(), func(ctx context.Context, c *ethclient.Client) error {
+			var err error
+			number, err = c.BlockNumber(ctx)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		return number
+	}
+
+	for i := 0; i < 3; i++ {
+		if number := blockNumber(); number != 16 {
+			t.Fatalf("block number = %d", number)
+		}
+	}
+	if primaryCalls.Load() != 2 {
+		t.Fatalf("primary should be tripped after 2 failures, got %d calls", primaryCalls.Load())
+	}
+
+	primaryHealthy.Store(true)
+	now = now.Add(2 * time.Minute)
+	blockNumber()
+	if primaryCalls.Load() != 3 || backupCalls.Load() != 3 {
+		t.Fatalf("primary should be retried after the cooldown, calls = %d/%d", primaryCalls.Load(), backupCalls.Load())
+	}
+}
+
+func TestFailoverClientStopsOnContextDone(t *testing.T) {
+	chainInfo := &loader.ChainInfo{Name: "Base", RpcEndPoint: "http://127.0.0.1:1"}
+	client := NewEvmFailoverClient(chainInfo, nil, FailoverOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := client.Do(ctx, func(ctx context.Context, c *ethclient.Client) error {
+		return ctx.Err()
+	})
+	if err == nil {
+		t.Fatal("Do should fail")
+	}
+	if statuses := client.Statuses(); len(statuses) != 1 || statuses[0].Failures != 0 {
+		t.Fatalf("a cancelled call should not count as an endpoint failure: %+v", statuses)
+	}
+}
+
+type closableClient struct {
+	url    string
+	closed atomic.Bool
+}
+
+func (c *closableClient) Close() {
+	c.closed.Store(true)
+}
+
+func TestFailoverClientPrunesRemovedEndpoints(t *testing.T) {
+	nodeInfoMgr := loader.NewNodeInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	setNodes := func(nodes ...*loader.NodeInfo) {
+		data, _ := json.Marshal(nodes)
+		if err := nodeInfoMgr.ImportSnapshot(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setNodes(&loader.NodeInfo{Id: 1, RealChainId: 8453, RpcURL: "http://old", Usability: 90})
+
+	dialed := make(map[string]*closableClient)
+	client := NewFailoverClient(&loader.ChainInfo{Name: "Base", RealChainId: "8453"}, nodeInfoMgr, func(endpoint string) (*closableClient, error) {
+		dialed[endpoint] = &closableClient{url: endpoint}
+		return dialed[endpoint], nil
+	}, FailoverOptions{CloseDelay: time.Millisecond})
+	defer client.Close()
+
+	call := func() string {
+		var url string
+		if err := client.Do(context.Background(), func(ctx context.Context, c *closableClient) error {
+			url = c.url
+			return nil
+		}); err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		return url
+	}
+	if url := call(); url != "http://old" {
+		t.Fatalf("called %s", url)
+	}
+
+	setNodes(&loader.NodeInfo{Id: 2, RealChainId: 8453, RpcURL: "http://new", Usability: 90})
+	if url := call(); url != "http://new" {
+		t.Fatalf("called %s", url)
+	}
+	if statuses := client.Statuses(); len(statuses) != 1 || statuses[0].URL != "http://new" {
+		t.Fatalf("the removed endpoint should be dropped: %+v", statuses)
+	}
+	deadline := time.Now().Add(time.Second)
+	for !dialed["http://old"].closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("the client of the removed endpoint was not closed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if dialed["http://new"].closed.Load() {
+		t.Fatal("the listed endpoint should stay open")
+	}
+}
+
+func TestFailoverClientReturnsCallErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"error":{"code":3,"message":"execution reverted"}}`, req.ID)
+	}))
+	defer server.Close()
+	var backupHealthy atomic.Bool
+	var backupCalls atomic.Int32
+	backup := newBlockNumberServer(t, &backupHealthy, &backupCalls)
+
+	nodeInfoMgr := loader.NewNodeInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	nodes, _ := json.Marshal([]*loader.NodeInfo{
+		{Id: 1, RealChainId: 8453, RpcURL: server.URL, Usability: 90},
+		{Id: 2, RealChainId: 8453, RpcURL: backup.URL, Usability: 10},
+	})
+	if err := nodeInfoMgr.ImportSnapshot(nodes); err != nil {
+		t.Fatal(err)
+	}
+	client := NewEvmFailoverClient(&loader.ChainInfo{Name: "Base", RealChainId: "8453"}, nodeInfoMgr, FailoverOptions{TripAfter: 1})
+	defer client.Close()
+
+	for i := 0; i < 2; i++ {
+		err := client.Do(context.Background(), func(ctx context.Context, c *ethclient.Client) error {
+			_, err := c.BlockNumber(ctx)
+			return err
+		})
+		if err == nil || IsTransportError(err) {
+			t.Fatalf("expected the call error, got %v", err)
+		}
+	}
+	if calls.Load() != 2 || backupCalls.Load() != 0 {
+		t.Fatalf("a call error should not fail over, calls = %d/%d", calls.Load(), backupCalls.Load())
+	}
+	for _, status := range client.Statuses() {
+		if status.Failures != 0 {
+			t.Fatalf("a call error should not count as an endpoint failure: %+v", status)
+		}
+	}
+}
+
+func TestFailoverClientDialsOnce(t *testing.T) {
+	var dials atomic.Int32
+	release := make(chan struct{})
+	client := NewFailoverClient(&loader.ChainInfo{Name: "Base", RpcEndPoint: "http://node"}, nil, func(endpoint string) (*closableClient, error) {
+		dials.Add(1)
+		<-release
+		return &closableClient{url: endpoint}, nil
+	}, FailoverOptions{})
+	defer client.Close()
+
+	clients := make(chan *closableClient, 2)
+	for i := 0; i < 2; i++ {
+		go client.Do(context.Background(), func(ctx context.Context, c *closableClient) error {
+			clients <- c
+			return nil
+		})
+	}
+	for dials.Load() < 2 {
+		// Statuses takes the lock, so dialing must not hold it
+		client.Statuses()
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	first, second := <-clients, <-clients
+	if first != second {
+		t.Fatal("concurrent dials should end up sharing the client installed first")
+	}
+	if first.closed.Load() {
+		t.Fatal("the installed client should stay open")
+	}
+}
@@ -0,0 +1,376 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-metrics"
+	"github.com/owlto-dao/utils-go/alert"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/task"
+	"github.com/owlto-dao/utils-go/telemetry"
+	"github.com/shopspring/decimal"
+)
+
+// ChainRpcFactory builds the Rpc of a chain.
+type ChainRpcFactory func(chainInfo *loader.ChainInfo) (Rpc, error)
+
+// LiquidityLevel grades a maker balance against its threshold.
+type LiquidityLevel int32
+
+const (
+	LiquidityOk LiquidityLevel = iota
+	LiquidityLow
+	LiquidityCritical
+	LiquidityUnknown // The balance could not be read
+)
+
+func (l LiquidityLevel) String() string {
+	switch l {
+	case LiquidityOk:
+		return "ok"
+	case LiquidityLow:
+		return "low"
+	case LiquidityCritical:
+		return "critical"
+	default:
+		return "unknown"
+	}
+}
+
+// LiquidityThreshold sets the UI balances under which a maker is low or critical on a chain.
+type LiquidityThreshold struct {
+	ChainName string
+	TokenName string // Empty applies to the tokens of the chain without their own threshold
+	Low       decimal.Decimal
+	Critical  decimal.Decimal
+}
+
+// LiquidityOptions tunes a LiquidityMonitor, zero values use the defaults.
+type LiquidityOptions struct {
+	Env             string        // Env of the maker address groups to check
+	Interval        time.Duration // Time between check rounds, 5m by default
+	Timeout         time.Duration // Timeout of a single balance query, 10s by default
+	Concurrency     int           // Balance queries in flight at once, 16 by default
+	RealertInterval time.Duration // Time before the same critical balances are alerted again, 1h by default
+	Thresholds      []LiquidityThreshold
+}
+
+// LiquidityStatus is the last measured balance of a maker address for a token.
+type LiquidityStatus struct {
+	GroupId      int64
+	MakerAddress string
+	ChainName    string
+	TokenName    string
+	Balance      *big.Int
+	BalanceUI    decimal.Decimal
+	Level        LiquidityLevel
+	Err          error
+	CheckedAt    time.Time
+}
+
+type liquidityCheck struct {
+	chainInfo *loader.ChainInfo
+	token     *loader.TokenInfo
+	maker     *loader.MakerAddressPO
+	threshold LiquidityThreshold
+}
+
+// LiquidityMonitor periodically reads the balances of the maker addresses of an env for every
+// token with a threshold, on the chains of their backend, and alerts when they run low.
+type LiquidityMonitor struct {
+	chainInfoMgr    *loader.ChainInfoManager
+	tokenInfoMgr    *loader.TokenInfoManager
+	makerAddressMgr *loader.MakerAddressManager
+	newRpc          ChainRpcFactory
+	alerter         alert.Alerter
+	options         LiquidityOptions
+
+	rpcs     map[liquidityRpcKey]*liquidityRpc
+	rpcMutex *sync.Mutex
+
+	statuses       []LiquidityStatus
+	lastCritical   string // The critical balances last alerted, see report
+	lastCriticalAt time.Time
+	mutex          *sync.Mutex
+}
+
+// liquidityRpcKey identifies the Rpc of a chain as loaded, a reload changing its endpoint or client gets a new one.
+type liquidityRpcKey struct {
+	chainId  int64
+	endpoint string
+	client   uintptr // Address of the chain client, 0 when there is none
+}
+
+func newLiquidityRpcKey(chainInfo *loader.ChainInfo) liquidityRpcKey {
+	key := liquidityRpcKey{chainId: chainInfo.Id, endpoint: chainInfo.RpcEndPoint}
+	if client := reflect.ValueOf(chainInfo.Client); client.Kind() == reflect.Pointer {
+		key.client = client.Pointer()
+	}
+	return key
+}
+
+// liquidityRpc is built once, outside of the monitor lock, by the first check of its chain.
+type liquidityRpc struct {
+	once sync.Once
+	rpc  Rpc
+	err  error
+}
+
+func NewLiquidityMonitor(chainInfoMgr *loader.ChainInfoManager, tokenInfoMgr *loader.TokenInfoManager, makerAddressMgr *loader.MakerAddressManager,
+	newRpc ChainRpcFactory, alerter alert.Alerter, options LiquidityOptions) *LiquidityMonitor {
+	if newRpc == nil {
+		newRpc = func(chainInfo *loader.ChainInfo) (Rpc, error) {
+			return GetRpc(chainInfo, nil)
+		}
+	}
+	if options.Interval <= 0 {
+		options.Interval = 5 * time.Minute
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 10 * time.Second
+	}
+	if options.Concurrency <= 0 {
+		options.Concurrency = 16
+	}
+	if options.RealertInterval <= 0 {
+		options.RealertInterval = time.Hour
+	}
+	return &LiquidityMonitor{
+		chainInfoMgr:    chainInfoMgr,
+		tokenInfoMgr:    tokenInfoMgr,
+		makerAddressMgr: makerAddressMgr,
+		newRpc:          newRpc,
+		alerter:         alerter,
+		options:         options,
+		rpcs:            make(map[liquidityRpcKey]*liquidityRpc),
+		rpcMutex:        &sync.Mutex{},
+		mutex:           &sync.Mutex{},
+	}
+}
+
+// Start checks every interval until ctx is done.
+func (m *LiquidityMonitor) Start(ctx context.Context) {
+	go task.PeriodicTask(ctx, func() { m.CheckOnce(ctx) }, m.options.Interval)
+}
+
+// Statuses returns the statuses of the last check round.
+func (m *LiquidityMonitor) Statuses() []LiquidityStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	statuses := make([]LiquidityStatus, len(m.statuses))
+	copy(statuses, m.statuses)
+	return statuses
+}
+
+// CheckOnce reads every balance once, at most Concurrency at a time, then exports the gauges and sends the alerts.
+// The Rpcs of chains that were not checked, or whose Rpc could not be built, are dropped after the round.
+func (m *LiquidityMonitor) CheckOnce(ctx context.Context) []LiquidityStatus {
+	checks := m.checks()
+	statuses := make([]LiquidityStatus, len(checks))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, m.options.Concurrency)
+	for i, check := range checks {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, check *liquidityCheck) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			statuses[i] = m.check(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+	m.pruneRpcs(checks)
+
+	m.mutex.Lock()
+	m.statuses = statuses
+	m.mutex.Unlock()
+
+	m.report(statuses)
+	return statuses
+}
+
+func (m *LiquidityMonitor) threshold(chainName string, tokenName string) (LiquidityThreshold, bool) {
+	var chainDefault *LiquidityThreshold
+	for i, threshold := range m.options.Thresholds {
+		if !strings.EqualFold(threshold.ChainName, chainName) {
+			continue
+		}
+		if strings.EqualFold(threshold.TokenName, tokenName) {
+			return threshold, true
+		}
+		if threshold.TokenName == "" {
+			chainDefault = &m.options.Thresholds[i]
+		}
+	}
+	if chainDefault != nil {
+		return *chainDefault, true
+	}
+	return LiquidityThreshold{}, false
+}
+
+func (m *LiquidityMonitor) checks() []*liquidityCheck {
+	makers := make(map[loader.Backend][]*loader.MakerAddressPO)
+	for _, group := range m.makerAddressMgr.GetMakerAddressesByEnv(m.options.Env) {
+		for _, address := range group.Addresses {
+			makers[address.Backend] = append(makers[address.Backend], address)
+		}
+	}
+
+	checks := make([]*liquidityCheck, 0)
+	for _, token := range m.tokenInfoMgr.GetAllTokens() {
+		chainInfo, ok := m.chainInfoMgr.GetChainInfoByName(token.ChainName)
+		if !ok || chainInfo.Disabled != 0 {
+			continue
+		}
+		threshold, ok := m.threshold(token.ChainName, token.TokenName)
+		if !ok {
+			continue
+		}
+		for _, maker := range makers[chainInfo.Backend] {
+			checks = append(checks, &liquidityCheck{chainInfo: chainInfo, token: token, maker: maker, threshold: threshold})
+		}
+	}
+	return checks
+}
+
+func (m *LiquidityMonitor) chainRpc(chainInfo *loader.ChainInfo) (Rpc, error) {
+	key := newLiquidityRpcKey(chainInfo)
+	m.rpcMutex.Lock()
+	chainRpc, ok := m.rpcs[key]
+	if !ok {
+		chainRpc = &liquidityRpc{}
+		m.rpcs[key] = chainRpc
+	}
+	m.rpcMutex.Unlock()
+
+	chainRpc.once.Do(func() {
+		built, err := m.newRpc(chainInfo)
+		m.rpcMutex.Lock()
+		chainRpc.rpc, chainRpc.err = built, err
+		m.rpcMutex.Unlock()
+	})
+	return chainRpc.rpc, chainRpc.err
+}
+
+// pruneRpcs drops the Rpcs not used by checks, those of removed or reloaded chains, and the failed ones to retry them.
+func (m *LiquidityMonitor) pruneRpcs(checks []*liquidityCheck) {
+	used := make(map[liquidityRpcKey]bool)
+	for _, check := range checks {
+		used[newLiquidityRpcKey(check.chainInfo)] = true
+	}
+	m.rpcMutex.Lock()
+	defer m.rpcMutex.Unlock()
+	for key, chainRpc := range m.rpcs {
+		if !used[key] || chainRpc.err != nil {
+			delete(m.rpcs, key)
+		}
+	}
+}
+
+func (m *LiquidityMonitor) check(ctx context.Context, check *liquidityCheck) LiquidityStatus {
+	status := LiquidityStatus{
+		GroupId:      check.maker.GroupId,
+		MakerAddress: check.maker.Address,
+		ChainName:    check.chainInfo.Name,
+		TokenName:    check.token.TokenName,
+		Level:        LiquidityUnknown,
+		CheckedAt:    time.Now(),
+	}
+
+	chainRpc, err := m.chainRpc(check.chainInfo)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+	balanceCtx, cancel := context.WithTimeout(ctx, m.options.Timeout)
+	defer cancel()
+	balance, err := chainRpc.GetBalance(balanceCtx, check.maker.Address, check.token.TokenAddress)
+	if err != nil {
+		status.Err = err
+		return status
+	}
+
+	status.Balance = balance
+	status.BalanceUI = decimal.NewFromBigInt(balance, -check.token.Decimals)
+	switch {
+	case status.BalanceUI.LessThan(check.threshold.Critical):
+		status.Level = LiquidityCritical
+	case status.BalanceUI.LessThan(check.threshold.Low):
+		status.Level = LiquidityLow
+	default:
+		status.Level = LiquidityOk
+	}
+	return status
+}
+
+// report exports the gauges and alerts the breaches. Critical balances are alerted once, then again when the set of
+// critical makers changes or after RealertInterval, low and unknown ones go through lazy alert groups.
+func (m *LiquidityMonitor) report(statuses []LiquidityStatus) {
+	breaches := make(map[LiquidityLevel][]string)
+	critical := make([]string, 0)
+	for _, status := range statuses {
+		labels := []metrics.Label{
+			telemetry.NewLabel("chain", status.ChainName),
+			telemetry.NewLabel("token", status.TokenName),
+			telemetry.NewLabel("maker", status.MakerAddress),
+		}
+		telemetry.SetGaugeWithLabels([]string{"maker", "liquidity", "level"}, float32(status.Level), labels)
+		if status.Level != LiquidityUnknown {
+			telemetry.SetGaugeWithLabels([]string{"maker", "liquidity", "balance"}, float32(status.BalanceUI.InexactFloat64()), labels)
+		}
+
+		switch status.Level {
+		case LiquidityLow, LiquidityCritical:
+			if status.Level == LiquidityCritical {
+				critical = append(critical, fmt.Sprintf("%s %s %s", status.ChainName, status.TokenName, status.MakerAddress))
+			}
+			breaches[status.Level] = append(breaches[status.Level], fmt.Sprintf("%s %s %s: %s", status.ChainName, status.TokenName, status.MakerAddress, status.BalanceUI.String()))
+		case LiquidityUnknown:
+			breaches[status.Level] = append(breaches[status.Level], fmt.Sprintf("%s %s %s: %v", status.ChainName, status.TokenName, status.MakerAddress, status.Err))
+		}
+	}
+
+	if len(critical) == 0 {
+		// Makers running critical again are alerted at once
+		m.mutex.Lock()
+		m.lastCritical = ""
+		m.mutex.Unlock()
+	}
+	for level, lines := range breaches {
+		sort.Strings(lines)
+		msg := fmt.Sprintf("maker liquidity %s on %d balances:\n%s", level, len(lines), strings.Join(lines, "\n"))
+		if level == LiquidityCritical {
+			if m.shouldAlertCritical(critical) {
+				m.alerter.AlertText(msg, nil)
+			}
+		} else {
+			m.alerter.AlertTextLazyGroup("maker_liquidity_"+level.String(), msg, nil)
+		}
+	}
+}
+
+// shouldAlertCritical reports whether the critical makers, which are not empty, differ from the ones last alerted
+// or were alerted more than RealertInterval ago, and records them as alerted if so.
+func (m *LiquidityMonitor) shouldAlertCritical(critical []string) bool {
+	sort.Strings(critical)
+	key := strings.Join(critical, "\n")
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if key == m.lastCritical && time.Since(m.lastCriticalAt) < m.options.RealertInterval {
+		return false
+	}
+	m.lastCritical = key
+	m.lastCriticalAt = time.Now()
+	return true
+}
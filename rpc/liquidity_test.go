@@ -0,0 +1,193 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/owlto-dao/utils-go/alert"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/shopspring/decimal"
+)
+
+type balanceRpc struct {
+	Rpc
+	balances map[string]*big.Int
+}
+
+func (r *balanceRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	balance, ok := r.balances[ownerAddr]
+	if !ok {
+		return nil, errors.New("rpc timeout")
+	}
+	return balance, nil
+}
+
+type groupAlerter struct {
+	alert.Alerter
+	mutex  sync.Mutex
+	alerts []string
+	groups []string
+}
+
+func (a *groupAlerter) AlertText(msg string, err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.alerts = append(a.alerts, msg)
+}
+
+func (a *groupAlerter) AlertTextLazyGroup(group string, msg string, err error) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.groups = append(a.groups, group)
+}
+
+func TestLiquidityMonitorGradesBalances(t *testing.T) {
+	chainInfoMgr := loader.NewChainInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	if err := chainInfoMgr.ImportSnapshot([]byte(`[{"Id": 1, "ChainId": "8453", "Name": "Base", "Backend": 1, "RpcEndPoint": "http://127.0.0.1:1"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	tokenInfoMgr := loader.NewTokenInfoManager(nil, nil)
+	if err := tokenInfoMgr.ImportSnapshot([]byte(`[
+		{"Id": 1, "TokenName": "USDC", "ChainName": "Base", "ChainId": 8453, "TokenAddress": "0xusdc", "Decimals": 6},
+		{"Id": 2, "TokenName": "DEGEN", "ChainName": "Base", "ChainId": 8453, "TokenAddress": "0xdegen", "Decimals": 18}
+	]`)); err != nil {
+		t.Fatal(err)
+	}
+	makerAddressMgr := loader.NewMakerAddressManager(nil)
+	if err := makerAddressMgr.ImportSnapshot([]byte(`[{"GroupId": 1, "Env": "prod", "Addresses": [
+		{"Id": 1, "GroupId": 1, "Backend": 1, "Address": "0xrich"},
+		{"Id": 2, "GroupId": 1, "Backend": 1, "Address": "0xlow"},
+		{"Id": 3, "GroupId": 1, "Backend": 1, "Address": "0xempty"},
+		{"Id": 4, "GroupId": 1, "Backend": 1, "Address": "0xunreachable"},
+		{"Id": 5, "GroupId": 1, "Backend": 3, "Address": "SolanaMaker"}
+	]}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	chainRpc := &balanceRpc{balances: map[string]*big.Int{
+		"0xrich":  big.NewInt(50_000_000000),
+		"0xlow":   big.NewInt(500_000000),
+		"0xempty": big.NewInt(10_000000),
+	}}
+	alerter := &groupAlerter{}
+	monitor := NewLiquidityMonitor(chainInfoMgr, tokenInfoMgr, makerAddressMgr, func(chainInfo *loader.ChainInfo) (Rpc, error) {
+		return chainRpc, nil
+	}, alerter, LiquidityOptions{
+		Env: "prod",
+		Thresholds: []LiquidityThreshold{
+			{ChainName: "base", TokenName: "usdc", Low: decimal.NewFromInt(1000), Critical: decimal.NewFromInt(100)},
+		},
+	})
+
+	statuses := monitor.CheckOnce(context.Background())
+	if len(statuses) != 4 {
+		t.Fatalf("expected the 4 evm makers checked for USDC only, got %d statuses", len(statuses))
+	}
+	levels := make(map[string]LiquidityLevel)
+	for _, status := range statuses {
+		levels[status.MakerAddress] = status.Level
+	}
+	if levels["0xrich"] != LiquidityOk || levels["0xlow"] != LiquidityLow || levels["0xempty"] != LiquidityCritical || levels["0xunreachable"] != LiquidityUnknown {
+		t.Fatalf("unexpected levels %v", levels)
+	}
+
+	if len(alerter.alerts) != 1 || !strings.Contains(alerter.alerts[0], "0xempty") {
+		t.Fatalf("expected one critical alert, got %v", alerter.alerts)
+	}
+	if len(alerter.groups) != 2 {
+		t.Fatalf("expected grouped low and unknown alerts, got %v", alerter.groups)
+	}
+	if len(monitor.Statuses()) != 4 {
+		t.Fatal("Statuses should return the last round")
+	}
+}
+
+type slowBalanceRpc struct {
+	Rpc
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+}
+
+func (r *slowBalanceRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	n := r.inFlight.Add(1)
+	defer r.inFlight.Add(-1)
+	for {
+		max := r.maxInFlight.Load()
+		if n <= max || r.maxInFlight.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+	return big.NewInt(10_000000), nil
+}
+
+func TestLiquidityMonitorRpcsAndAlerts(t *testing.T) {
+	chainInfoMgr := loader.NewChainInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	if err := chainInfoMgr.ImportSnapshot([]byte(`[{"Id": 1, "ChainId": "8453", "Name": "Base", "Backend": 1, "RpcEndPoint": "http://127.0.0.1:1"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	tokenInfoMgr := loader.NewTokenInfoManager(nil, nil)
+	if err := tokenInfoMgr.ImportSnapshot([]byte(`[{"Id": 1, "TokenName": "USDC", "ChainName": "Base", "ChainId": 8453, "TokenAddress": "0xusdc", "Decimals": 6}]`)); err != nil {
+		t.Fatal(err)
+	}
+	makerAddressMgr := loader.NewMakerAddressManager(nil)
+	if err := makerAddressMgr.ImportSnapshot([]byte(`[{"GroupId": 1, "Env": "prod", "Addresses": [
+		{"Id": 1, "GroupId": 1, "Backend": 1, "Address": "0xa"},
+		{"Id": 2, "GroupId": 1, "Backend": 1, "Address": "0xb"},
+		{"Id": 3, "GroupId": 1, "Backend": 1, "Address": "0xc"},
+		{"Id": 4, "GroupId": 1, "Backend": 1, "Address": "0xd"},
+		{"Id": 5, "GroupId": 1, "Backend": 1, "Address": "0xe"}
+	]}]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	chainRpc := &slowBalanceRpc{}
+	var built atomic.Int32
+	alerter := &groupAlerter{}
+	monitor := NewLiquidityMonitor(chainInfoMgr, tokenInfoMgr, makerAddressMgr, func(chainInfo *loader.ChainInfo) (Rpc, error) {
+		built.Add(1)
+		return chainRpc, nil
+	}, alerter, LiquidityOptions{
+		Env:         "prod",
+		Concurrency: 2,
+		Thresholds:  []LiquidityThreshold{{ChainName: "base", Low: decimal.NewFromInt(1000), Critical: decimal.NewFromInt(100)}},
+	})
+
+	monitor.CheckOnce(context.Background())
+	if built.Load() != 1 {
+		t.Fatalf("the chain rpc should be built once per round, built %d", built.Load())
+	}
+	if max := chainRpc.maxInFlight.Load(); max > 2 {
+		t.Fatalf("%d balance queries in flight, above the concurrency of 2", max)
+	}
+
+	monitor.CheckOnce(context.Background())
+	if built.Load() != 1 {
+		t.Fatal("the chain rpc should be reused while the chain is unchanged")
+	}
+	if len(alerter.alerts) != 1 {
+		t.Fatalf("unchanged critical balances should be alerted once, got %d alerts", len(alerter.alerts))
+	}
+
+	if err := chainInfoMgr.ImportSnapshot([]byte(`[{"Id": 1, "ChainId": "8453", "Name": "Base", "Backend": 1, "RpcEndPoint": "http://127.0.0.1:2"}]`)); err != nil {
+		t.Fatal(err)
+	}
+	monitor.CheckOnce(context.Background())
+	if built.Load() != 2 || len(monitor.rpcs) != 1 {
+		t.Fatalf("a new endpoint should get a new rpc and drop the old one: built %d, cached %d", built.Load(), len(monitor.rpcs))
+	}
+
+	if err := makerAddressMgr.ImportSnapshot([]byte(`[{"GroupId": 1, "Env": "prod", "Addresses": [{"Id": 1, "GroupId": 1, "Backend": 1, "Address": "0xa"}]}]`)); err != nil {
+		t.Fatal(err)
+	}
+	monitor.CheckOnce(context.Background())
+	if len(alerter.alerts) != 2 {
+		t.Fatalf("a changed set of critical makers should be alerted again, got %d alerts", len(alerter.alerts))
+	}
+}
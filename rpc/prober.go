@@ -0,0 +1,367 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-metrics"
+	"github.com/owlto-dao/utils-go/alert"
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/owlto-dao/utils-go/task"
+	"github.com/owlto-dao/utils-go/telemetry"
+)
+
+// NodeRpcFactory builds the Rpc used to probe a node.
+type NodeRpcFactory func(node *loader.NodeInfo) (Rpc, error)
+
+// ProberOptions tunes a NodeProber, zero values use the defaults.
+type ProberOptions struct {
+	Interval  time.Duration // Time between probe rounds, 30s by default
+	Timeout   time.Duration // Timeout of a single probe, 5s by default
+	Window    int           // Number of recent probes the score is computed on, 10 by default
+	FailAfter int           // Consecutive failed probes after which a node is scored 0, 3 by default
+	Persist   bool          // Write the scores back to t_node_info, otherwise they are only applied in memory
+}
+
+// NodeHealth is the measured health of a node over the probe window.
+type NodeHealth struct {
+	NodeId      int64
+	RealChainId int64
+	RpcURL      string
+	Samples     int
+	ErrorRate   float64
+	AvgLatency  time.Duration
+	BlockNumber int64 // Of the last successful probe
+	Lag         int64 // Blocks behind the highest peer of the same chain
+	Usability   int32 // Score in [0, 100], 0 means unusable
+	Failures    int   // Consecutive failed probes
+	LastError   error
+	LastProbeAt time.Time
+}
+
+type probeSample struct {
+	latency time.Duration
+	err     error
+}
+
+type nodeProbeState struct {
+	rpc         Rpc
+	rpcURL      string
+	samples     []probeSample
+	blockNumber int64
+	failures    int
+	health      NodeHealth
+}
+
+// NodeProber periodically calls GetLatestBlockNumber on every node of NodeInfoManager
+// and scores them on error rate, latency and block lag versus their peers.
+// Nodes disabled in t_node_info (usability 0) are not probed, unless the prober disabled them itself,
+// so a node it scored 0 and persisted before a restart has to be enabled again by hand.
+type NodeProber struct {
+	nodeInfoMgr *loader.NodeInfoManager
+	newRpc      NodeRpcFactory
+	alerter     alert.Alerter
+	options     ProberOptions
+
+	states map[int64]*nodeProbeState
+	stopCh chan struct{}
+	doneCh chan struct{} // Closed when the probe loop of Start has exited
+	mutex  *sync.Mutex
+}
+
+func NewNodeProber(nodeInfoMgr *loader.NodeInfoManager, newRpc NodeRpcFactory, alerter alert.Alerter, options ProberOptions) *NodeProber {
+	if options.Interval <= 0 {
+		options.Interval = 30 * time.Second
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 5 * time.Second
+	}
+	if options.Window <= 0 {
+		options.Window = 10
+	}
+	if options.FailAfter <= 0 {
+		options.FailAfter = 3
+	}
+	return &NodeProber{
+		nodeInfoMgr: nodeInfoMgr,
+		newRpc:      newRpc,
+		alerter:     alerter,
+		options:     options,
+		states:      make(map[int64]*nodeProbeState),
+		mutex:       &sync.Mutex{},
+	}
+}
+
+// ChainNodeRpcFactory builds node Rpcs from the chain of the node, with the node url as endpoint.
+// Nodes are matched to chains by t_node_info.chain_id.
+func ChainNodeRpcFactory(chainInfoMgr *loader.ChainInfoManager) NodeRpcFactory {
+	return func(node *loader.NodeInfo) (Rpc, error) {
+		chainInfo, ok := chainInfoMgr.GetChainInfoByInt64ChainId(node.ChainId)
+		if !ok {
+			return nil, fmt.Errorf("chain %d of node %d not found", node.ChainId, node.Id)
+		}
+		nodeChain := *chainInfo
+		nodeChain.RpcEndPoint = node.RpcURL
+		if nodeChain.Backend != loader.TonBackend {
+			client, err := loader.NewChainClient(nodeChain.Backend, node.RpcURL)
+			if err != nil {
+				return nil, err
+			}
+			nodeChain.Client = client
+		}
+		return GetRpc(&nodeChain, nil)
+	}
+}
+
+// Start probes every interval until ctx is done or Stop is called. Start does nothing when already started.
+func (p *NodeProber) Start(ctx context.Context) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.stopCh != nil {
+		return
+	}
+	stopCh, doneCh := make(chan struct{}), make(chan struct{})
+	p.stopCh, p.doneCh = stopCh, doneCh
+
+	task.RunTask(func() {
+		defer close(doneCh)
+		ticker := time.NewTicker(p.options.Interval)
+		defer ticker.Stop()
+		for {
+			p.ProbeOnce(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// Stop stops the probes of Start and waits for an in-flight round to end. It can be started again afterwards.
+func (p *NodeProber) Stop() {
+	p.mutex.Lock()
+	stopCh, doneCh := p.stopCh, p.doneCh
+	p.stopCh, p.doneCh = nil, nil
+	p.mutex.Unlock()
+	if stopCh != nil {
+		close(stopCh)
+		<-doneCh
+	}
+}
+
+// ProbeOnce probes every node once, concurrently, then scores them and applies the scores.
+func (p *NodeProber) ProbeOnce(ctx context.Context) {
+	nodes := p.probedNodes()
+
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(node *loader.NodeInfo) {
+			defer wg.Done()
+			p.probe(ctx, node)
+		}(node)
+	}
+	wg.Wait()
+
+	p.score(nodes)
+	p.apply(nodes)
+}
+
+// probedNodes returns the nodes to probe. The states of the other nodes, no longer listed or disabled,
+// are dropped and their clients closed.
+func (p *NodeProber) probedNodes() []*loader.NodeInfo {
+	allNodes := p.nodeInfoMgr.GetAllNodes()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	nodes := make([]*loader.NodeInfo, 0, len(allNodes))
+	probed := make(map[int64]bool, len(allNodes))
+	for _, node := range allNodes {
+		state, ok := p.states[node.Id]
+		if node.Usability == 0 && (!ok || state.health.Usability != 0) {
+			// Disabled in t_node_info, not by the prober
+			continue
+		}
+		nodes = append(nodes, node)
+		probed[node.Id] = true
+	}
+	for nodeId, state := range p.states {
+		if !probed[nodeId] {
+			if state.rpc != nil {
+				closeClient(state.rpc.Client())
+			}
+			delete(p.states, nodeId)
+		}
+	}
+	return nodes
+}
+
+// Health returns the health of a node measured so far.
+func (p *NodeProber) Health(nodeId int64) (NodeHealth, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	state, ok := p.states[nodeId]
+	if !ok {
+		return NodeHealth{}, false
+	}
+	return state.health, true
+}
+
+func (p *NodeProber) probe(ctx context.Context, node *loader.NodeInfo) {
+	p.mutex.Lock()
+	state, ok := p.states[node.Id]
+	if !ok || state.rpcURL != node.RpcURL {
+		if ok && state.rpc != nil {
+			closeClient(state.rpc.Client())
+		}
+		state = &nodeProbeState{rpcURL: node.RpcURL}
+		p.states[node.Id] = state
+	}
+	nodeRpc := state.rpc
+	p.mutex.Unlock()
+
+	var err error
+	if nodeRpc == nil {
+		nodeRpc, err = p.newRpc(node)
+	}
+
+	var blockNumber int64
+	start := time.Now()
+	if err == nil {
+		probeCtx, cancel := context.WithTimeout(ctx, p.options.Timeout)
+		blockNumber, err = nodeRpc.GetLatestBlockNumber(probeCtx)
+		cancel()
+	}
+	sample := probeSample{latency: time.Since(start), err: err}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if nodeRpc != nil {
+		state.rpc = nodeRpc
+	}
+	state.samples = append(state.samples, sample)
+	if len(state.samples) > p.options.Window {
+		state.samples = state.samples[len(state.samples)-p.options.Window:]
+	}
+	if err == nil {
+		state.blockNumber = blockNumber
+		state.failures = 0
+	} else {
+		state.failures++
+	}
+	state.health.LastError = err
+	state.health.LastProbeAt = start
+}
+
+func (p *NodeProber) score(nodes []*loader.NodeInfo) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	highest := make(map[int64]int64)
+	for _, node := range nodes {
+		if state, ok := p.states[node.Id]; ok && state.blockNumber > highest[node.RealChainId] {
+			highest[node.RealChainId] = state.blockNumber
+		}
+	}
+
+	for _, node := range nodes {
+		state, ok := p.states[node.Id]
+		if !ok {
+			continue
+		}
+		failures := 0
+		var latency time.Duration
+		for _, sample := range state.samples {
+			if sample.err != nil {
+				failures++
+			} else {
+				latency += sample.latency
+			}
+		}
+
+		health := &state.health
+		health.NodeId = node.Id
+		health.RealChainId = node.RealChainId
+		health.RpcURL = node.RpcURL
+		health.Samples = len(state.samples)
+		health.ErrorRate = float64(failures) / float64(len(state.samples))
+		health.AvgLatency = 0
+		if successes := len(state.samples) - failures; successes > 0 {
+			health.AvgLatency = latency / time.Duration(successes)
+		}
+		health.BlockNumber = state.blockNumber
+		health.Lag = highest[node.RealChainId] - state.blockNumber
+		health.Failures = state.failures
+		health.Usability = usabilityScore(health.ErrorRate, health.AvgLatency, health.Lag, state.failures >= p.options.FailAfter)
+	}
+}
+
+// usabilityScore starts from the success rate and deducts up to 30 points for latency, one per 100ms,
+// and up to 50 points for lag, five per block. A node failing FailAfter probes in a row is unusable.
+func usabilityScore(errorRate float64, latency time.Duration, lag int64, failing bool) int32 {
+	if failing {
+		return 0
+	}
+	score := 100 * (1 - errorRate)
+	score -= math.Min(30, float64(latency.Milliseconds())/100)
+	score -= math.Min(50, float64(lag)*5)
+	if score < 1 {
+		return 1
+	}
+	return int32(score)
+}
+
+func (p *NodeProber) apply(nodes []*loader.NodeInfo) {
+	healthyNodes := make(map[int64]int)
+	lastErrs := make(map[int64]error)
+	usabilities := make(map[int64]int32)
+	for _, node := range nodes {
+		health, ok := p.Health(node.Id)
+		if !ok {
+			continue
+		}
+		if _, ok := healthyNodes[node.RealChainId]; !ok {
+			healthyNodes[node.RealChainId] = 0
+		}
+		if health.Usability > 0 {
+			healthyNodes[node.RealChainId]++
+		} else if health.LastError != nil {
+			lastErrs[node.RealChainId] = fmt.Errorf("node %d: %w", node.Id, health.LastError)
+		}
+
+		labels := []metrics.Label{
+			telemetry.NewLabel("real_chain_id", strconv.FormatInt(node.RealChainId, 10)),
+			telemetry.NewLabel("node_id", strconv.FormatInt(node.Id, 10)),
+		}
+		telemetry.SetGaugeWithLabels([]string{"rpc", "node", "usability"}, float32(health.Usability), labels)
+		telemetry.SetGaugeWithLabels([]string{"rpc", "node", "latency_ms"}, float32(health.AvgLatency.Milliseconds()), labels)
+		telemetry.SetGaugeWithLabels([]string{"rpc", "node", "lag"}, float32(health.Lag), labels)
+
+		if health.Usability != node.Usability {
+			usabilities[node.Id] = health.Usability
+		}
+	}
+	if len(usabilities) > 0 {
+		if p.options.Persist {
+			p.nodeInfoMgr.UpdateUsabilities(usabilities)
+		} else {
+			p.nodeInfoMgr.SetUsabilities(usabilities)
+		}
+	}
+
+	for realChainId, count := range healthyNodes {
+		labels := []metrics.Label{telemetry.NewLabel("real_chain_id", strconv.FormatInt(realChainId, 10))}
+		telemetry.SetGaugeWithLabels([]string{"rpc", "chain", "healthy_nodes"}, float32(count), labels)
+		if count == 0 {
+			p.alerter.AlertTextLazyGroup(fmt.Sprintf("rpc_prober_%d", realChainId),
+				fmt.Sprintf("no healthy rpc node for real chain id %d", realChainId), lastErrs[realChainId])
+		}
+	}
+}
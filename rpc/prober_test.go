@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/owlto-dao/utils-go/alert"
+	"github.com/owlto-dao/utils-go/loader"
+)
+
+type blockNumberRpc struct {
+	Rpc
+	blockNumber int64
+	err         error
+	closed      int
+}
+
+func (r *blockNumberRpc) Client() interface{} {
+	return r
+}
+
+func (r *blockNumberRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
+	return r.blockNumber, r.err
+}
+
+func (r *blockNumberRpc) Close() {
+	r.closed++
+}
+
+func TestNodeProberScoresNodes(t *testing.T) {
+	nodeInfoMgr := loader.NewNodeInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	nodes, _ := json.Marshal([]*loader.NodeInfo{
+		{Id: 1, RealChainId: 1, RpcURL: "http://synced", Usability: 50},
+		{Id: 2, RealChainId: 1, RpcURL: "http://lagging", Usability: 50},
+		{Id: 3, RealChainId: 1, RpcURL: "http://down", Usability: 50},
+	})
+	if err := nodeInfoMgr.ImportSnapshot(nodes); err != nil {
+		t.Fatal(err)
+	}
+
+	rpcs := map[string]Rpc{
+		"http://synced":  &blockNumberRpc{blockNumber: 100},
+		"http://lagging": &blockNumberRpc{blockNumber: 96},
+		"http://down":    &blockNumberRpc{err: errors.New("connection refused")},
+	}
+	prober := NewNodeProber(nodeInfoMgr, func(node *loader.NodeInfo) (Rpc, error) {
+		return rpcs[node.RpcURL], nil
+	}, alert.NewCommonAlerter(0, 0), ProberOptions{})
+	prober.ProbeOnce(context.Background())
+
+	if down, _ := prober.Health(3); down.Usability == 0 || down.Failures != 1 {
+		t.Fatalf("a single failure should not disable a node: %+v", down)
+	}
+	prober.ProbeOnce(context.Background())
+	prober.ProbeOnce(context.Background())
+
+	synced, _ := prober.Health(1)
+	lagging, _ := prober.Health(2)
+	down, _ := prober.Health(3)
+	if lagging.Lag != 4 || synced.Lag != 0 {
+		t.Fatalf("unexpected lag: synced %d, lagging %d", synced.Lag, lagging.Lag)
+	}
+	if !(synced.Usability > lagging.Usability && lagging.Usability > 0 && down.Usability == 0) {
+		t.Fatalf("unexpected scores: synced %d, lagging %d, down %d", synced.Usability, lagging.Usability, down.Usability)
+	}
+
+	best, ok := nodeInfoMgr.GetAvailableNodeByRealChainId(1)
+	if !ok || best.Id != 1 {
+		t.Fatalf("scores should be applied to NodeInfoManager, best node %+v", best)
+	}
+	if node, _ := nodeInfoMgr.GetNodeInfoById(3); node.Usability != 0 {
+		t.Fatalf("down node usability = %d", node.Usability)
+	}
+}
+
+func TestNodeProberSkipsDisabledNodes(t *testing.T) {
+	nodeInfoMgr := loader.NewNodeInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	importNodes := func(nodes []*loader.NodeInfo) {
+		data, _ := json.Marshal(nodes)
+		if err := nodeInfoMgr.ImportSnapshot(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	importNodes([]*loader.NodeInfo{
+		{Id: 1, RealChainId: 1, RpcURL: "http://a", Usability: 50},
+		{Id: 2, RealChainId: 1, RpcURL: "http://b", Usability: 50},
+		{Id: 3, RealChainId: 1, RpcURL: "http://disabled", Usability: 0},
+	})
+
+	var rpcs []*blockNumberRpc
+	prober := NewNodeProber(nodeInfoMgr, func(node *loader.NodeInfo) (Rpc, error) {
+		nodeRpc := &blockNumberRpc{blockNumber: 100}
+		rpcs = append(rpcs, nodeRpc)
+		return nodeRpc, nil
+	}, alert.NewCommonAlerter(0, 0), ProberOptions{})
+	prober.ProbeOnce(context.Background())
+
+	if _, ok := prober.Health(3); ok {
+		t.Fatal("a node disabled in t_node_info should not be probed")
+	}
+	if node, _ := nodeInfoMgr.GetNodeInfoById(3); node.Usability != 0 {
+		t.Fatalf("disabled node usability = %d", node.Usability)
+	}
+
+	// Node 1 moves to another url, node 2 is no longer listed
+	importNodes([]*loader.NodeInfo{
+		{Id: 1, RealChainId: 1, RpcURL: "http://a2", Usability: 50},
+	})
+	prober.ProbeOnce(context.Background())
+
+	if _, ok := prober.Health(2); ok {
+		t.Fatal("the state of a node no longer listed should be dropped")
+	}
+	if len(rpcs) != 3 || rpcs[0].closed != 1 || rpcs[1].closed != 1 || rpcs[2].closed != 0 {
+		t.Fatalf("replaced and dropped clients should be closed once, the new one kept open")
+	}
+}
+
+func TestNodeProberStop(t *testing.T) {
+	nodeInfoMgr := loader.NewNodeInfoManager(nil, alert.NewCommonAlerter(0, 0))
+	nodes, _ := json.Marshal([]*loader.NodeInfo{{Id: 1, RealChainId: 1, RpcURL: "http://a", Usability: 50}})
+	if err := nodeInfoMgr.ImportSnapshot(nodes); err != nil {
+		t.Fatal(err)
+	}
+	var probes atomic.Int32
+	prober := NewNodeProber(nodeInfoMgr, func(node *loader.NodeInfo) (Rpc, error) {
+		probes.Add(1)
+		return nil, errors.New("unreachable")
+	}, alert.NewCommonAlerter(0, 0), ProberOptions{Interval: time.Millisecond})
+
+	prober.Start(context.Background())
+	for probes.Load() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+	prober.Stop()
+	stopped := probes.Load()
+	time.Sleep(10 * time.Millisecond)
+	if probes.Load() != stopped {
+		t.Fatal("Stop should end the probe loop")
+	}
+
+	prober.Start(context.Background())
+	for probes.Load() == stopped {
+		time.Sleep(time.Millisecond)
+	}
+	prober.Stop()
+}
@@ -2,7 +2,6 @@ package rpc
 
 import (
 	"context"
-	"fmt"
 	"math/big"
 
 	"github.com/owlto-dao/utils-go/apollosdk"
@@ -22,25 +21,15 @@ type Rpc interface {
 	GetChecksumAddress(addr string) string
 }
 
+// GetRpc builds the Rpc of chainInfo with the constructor registered for its backend.
+// It returns an *UnknownBackendError when there is none, or an *UnconfiguredBackendError
+// when the chain is missing what the backend needs.
 func GetRpc(chainInfo *loader.ChainInfo, apolloSDK *apollosdk.ApolloSDK) (Rpc, error) {
-	if chainInfo.Backend == 1 {
-		return NewEvmRpc(chainInfo), nil
-	} else if chainInfo.Backend == 2 {
-		return NewStarknetRpc(chainInfo), nil
-	} else if chainInfo.Backend == 3 {
-		return NewSolanaRpc(chainInfo), nil
-	} else if chainInfo.Backend == 4 {
-		return NewBitcoinRpc(chainInfo, apolloSDK), nil
-	} else if chainInfo.Backend == 5 {
-		return NewZksliteRpc(chainInfo), nil
-	} else if chainInfo.Backend == 6 {
-		return NewTonRpc(chainInfo), nil
-	} else if chainInfo.Backend == 8 {
-		return NewBenfenRpc(chainInfo), nil
-	} else if chainInfo.Backend == 9 {
-		return NewSuiRpc(chainInfo), nil
-	} else if chainInfo.Backend == 10 {
-		return NewFuelRpc(chainInfo), nil
+	backendsMutex.RLock()
+	constructor, ok := backends[chainInfo.Backend]
+	backendsMutex.RUnlock()
+	if !ok {
+		return nil, &UnknownBackendError{Backend: chainInfo.Backend}
 	}
-	return nil, fmt.Errorf("unsupport backend %v", chainInfo.Backend)
+	return constructor(chainInfo, apolloSDK)
 }
@@ -1,18 +1,51 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"math"
 	"math/big"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/owlto-dao/utils-go/loader"
 	"github.com/owlto-dao/utils-go/util"
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/ton"
 	"github.com/xssnick/tonutils-go/ton/jetton"
+	"github.com/xssnick/tonutils-go/ton/nft"
 )
 
+const (
+	tonTxScanLimit  = 256 // Transactions scanned back from the last one when a tx id has no lt
+	tonTxPageSize   = 16
+	tonCommitDepth  = 16 // Masterchain blocks searched after the one a shard block references for the one committing it
+	tonNativeSymbol = "TON"
+	tonDecimals     = 9
+
+	tonMetadataTimeout = 10 * time.Second
+	tonMetadataMaxSize = 256 << 10
+	tonIpfsGateway     = "https://ipfs.io/ipfs/"
+)
+
+// maxUint256 is the allowance of TON tokens, which are moved by their owner without approvals.
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
 type TonRpc struct {
 	chainInfo *loader.ChainInfo
 }
@@ -23,7 +56,9 @@ func NewTonRpc(chainInfo *loader.ChainInfo) *TonRpc {
 	}
 }
 
-func (t *TonRpc) GetClient() (*ton.APIClient, error) {
+// GetClient returns the ton.APIClientWrapped shared by ChainInfoManager, or connects with the
+// global config at RpcEndPoint when the chain has no client.
+func (t *TonRpc) GetClient() (ton.APIClientWrapped, error) {
 	if t.chainInfo.Client == nil {
 		client := liteclient.NewConnectionPool()
 		err := client.AddConnectionsFromConfigUrl(context.Background(), t.chainInfo.RpcEndPoint)
@@ -32,7 +67,11 @@ func (t *TonRpc) GetClient() (*ton.APIClient, error) {
 		}
 		t.chainInfo.Client = ton.NewAPIClient(client).WithRetry()
 	}
-	return t.chainInfo.Client.(*ton.APIClient), nil
+	client, ok := t.chainInfo.Client.(ton.APIClientWrapped)
+	if !ok {
+		return nil, fmt.Errorf("ton client is %T", t.chainInfo.Client)
+	}
+	return client, nil
 }
 
 func (t *TonRpc) Client() interface{} {
@@ -55,15 +94,219 @@ func (t *TonRpc) GetLatestBlockNumber(ctx context.Context) (int64, error) {
 	return int64(masterchainInfo.SeqNo), nil
 }
 
+// tonTxId identifies a transaction by its account, its logical time when known, and its hash.
+type tonTxId struct {
+	account *address.Address
+	lt      uint64
+	hash    []byte
+}
+
+// parseTonTxId parses "account/lt/hash" or "account/hash", the hash in hex or base64.
+func parseTonTxId(id string) (*tonTxId, error) {
+	parts := strings.SplitN(strings.TrimSpace(id), "/", 3)
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid ton tx id %s, want account/lt/hash or account/hash", id)
+	}
+	account, err := parseTonAddr(parts[0])
+	if err != nil {
+		return nil, err
+	}
+	txId := &tonTxId{account: account}
+	hash := parts[1]
+	if len(parts) == 3 {
+		if txId.lt, err = strconv.ParseUint(parts[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid lt of ton tx id %s: %w", id, err)
+		}
+		hash = parts[2]
+	}
+	if txId.hash, err = decodeTonHash(hash); err != nil {
+		return nil, fmt.Errorf("invalid hash of ton tx id %s: %w", id, err)
+	}
+	return txId, nil
+}
+
+func parseTonAddr(addr string) (*address.Address, error) {
+	addr = strings.TrimSpace(addr)
+	if strings.Contains(addr, ":") {
+		return address.ParseRawAddr(addr)
+	}
+	return address.ParseAddr(addr)
+}
+
+func decodeTonHash(hash string) ([]byte, error) {
+	hash = strings.TrimPrefix(hash, "0x")
+	if decoded, err := hex.DecodeString(hash); err == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	for _, encoding := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding} {
+		if decoded, err := encoding.DecodeString(hash); err == nil && len(decoded) == 32 {
+			return decoded, nil
+		}
+	}
+	return nil, fmt.Errorf("want 32 bytes in hex or base64")
+}
+
+func (t *TonRpc) findTransaction(ctx context.Context, client ton.APIClientWrapped, txId *tonTxId) (*tlb.Transaction, error) {
+	if txId.lt > 0 {
+		txs, err := client.ListTransactions(ctx, txId.account, 1, txId.lt, txId.hash)
+		if err != nil {
+			return nil, err
+		}
+		return txs[len(txs)-1], nil
+	}
+
+	block, err := client.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	account, err := client.GetAccount(ctx, block, txId.account)
+	if err != nil {
+		return nil, err
+	}
+	lt, hash := account.LastTxLT, account.LastTxHash
+	for scanned := 0; lt > 0 && scanned < tonTxScanLimit; {
+		txs, err := client.ListTransactions(ctx, txId.account, tonTxPageSize, lt, hash)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(txs) - 1; i >= 0; i-- {
+			if bytes.Equal(txs[i].Hash, txId.hash) {
+				return txs[i], nil
+			}
+		}
+		scanned += len(txs)
+		lt, hash = txs[0].PrevTxLT, txs[0].PrevTxHash
+	}
+	return nil, fmt.Errorf("ton tx %x not found in the last %d txs of %s", txId.hash, tonTxScanLimit, txId.account)
+}
+
+// tonTxSuccess reports whether an ordinary transaction was not aborted and both its compute
+// and action phases succeeded.
+func tonTxSuccess(tx *tlb.Transaction) bool {
+	description, ok := tx.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		return false
+	}
+	if description.Aborted {
+		return false
+	}
+	compute, ok := description.ComputePhase.Phase.(tlb.ComputePhaseVM)
+	if !ok || !compute.Success {
+		return false
+	}
+	return description.ActionPhase == nil || description.ActionPhase.Success
+}
+
+// tonTxBlock returns the block of the shard of account holding the transaction at lt.
+func tonTxBlock(ctx context.Context, client ton.APIClientWrapped, account *address.Address, lt uint64) (*ton.BlockIDExt, error) {
+	var resp tl.Serializable
+	err := client.Client().QueryLiteserver(ctx, ton.LookupBlock{
+		Mode: 2, // By lt, the shard is the prefix of the account
+		ID: &ton.BlockInfoShort{
+			Workchain: account.Workchain(),
+			Shard:     int64(binary.BigEndian.Uint64(account.Data())),
+		},
+		LT: lt,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	switch t := resp.(type) {
+	case ton.BlockHeader:
+		return t.ID, nil
+	case ton.LSError:
+		return nil, t
+	}
+	return nil, fmt.Errorf("unexpected lookup block response %T", resp)
+}
+
+// tonShardsOverlap reports whether two shard ids of the same workchain cover common accounts,
+// that is whether one is a prefix of the other.
+func tonShardsOverlap(a, b int64) bool {
+	bitA, bitB := uint64(a)&-uint64(a), uint64(b)&-uint64(b)
+	mask := ^(max(bitA, bitB)<<1 - 1)
+	return uint64(a)&mask == uint64(b)&mask
+}
+
+// tonMasterchainSeqno returns the seqno of the first masterchain block committing block. A shard block
+// is committed by a masterchain block following the one it references, once its shard top reaches it.
+func tonMasterchainSeqno(ctx context.Context, client ton.APIClientWrapped, block *ton.BlockIDExt) (uint32, error) {
+	if block.Workchain == address.MasterchainID {
+		return block.SeqNo, nil
+	}
+	data, err := client.GetBlockData(ctx, block)
+	if err != nil {
+		return 0, err
+	}
+	if data.BlockInfo.MasterRef == nil {
+		return 0, fmt.Errorf("shard block %d has no masterchain reference", block.SeqNo)
+	}
+	last, err := client.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for seqno := data.BlockInfo.MasterRef.SeqNo + 1; seqno <= last.SeqNo && seqno <= data.BlockInfo.MasterRef.SeqNo+tonCommitDepth; seqno++ {
+		master, err := client.LookupBlock(ctx, address.MasterchainID, math.MinInt64, seqno)
+		if err != nil {
+			return 0, err
+		}
+		shards, err := client.GetBlockShardsInfo(ctx, master)
+		if err != nil {
+			return 0, err
+		}
+		for _, shard := range shards {
+			if shard.Workchain == block.Workchain && tonShardsOverlap(shard.Shard, block.Shard) && shard.SeqNo >= block.SeqNo {
+				return seqno, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("shard block %d is not committed to the masterchain yet", block.SeqNo)
+}
+
+// IsTxSuccess looks a transaction up by "account/lt/hash", or "account/hash" among the last
+// transactions of the account, and returns its success and the seqno of the masterchain block
+// committing it, comparable with GetLatestBlockNumber.
 func (t *TonRpc) IsTxSuccess(ctx context.Context, hash string) (bool, int64, error) {
-	return false, 0, fmt.Errorf("not implement")
+	txId, err := parseTonTxId(hash)
+	if err != nil {
+		return false, 0, err
+	}
+	client, err := t.GetClient()
+	if err != nil {
+		return false, 0, err
+	}
+	tx, err := t.findTransaction(ctx, client, txId)
+	if err != nil {
+		return false, 0, err
+	}
+	block, err := tonTxBlock(ctx, client, txId.account, tx.LT)
+	if err != nil {
+		return false, 0, fmt.Errorf("block of ton tx %x: %w", tx.Hash, err)
+	}
+	seqno, err := tonMasterchainSeqno(ctx, client, block)
+	if err != nil {
+		return false, 0, fmt.Errorf("masterchain block of ton tx %x: %w", tx.Hash, err)
+	}
+	return tonTxSuccess(tx), int64(seqno), nil
 }
 
+// GetAllowance returns an unlimited allowance, jettons are transferred by the wallet of their owner
+// and have no approvals.
 func (t *TonRpc) GetAllowance(ctx context.Context, ownerAddr string, tokenAddr string, spenderAddr string) (*big.Int, error) {
-	return nil, fmt.Errorf("not implement")
+	if _, err := address.ParseAddr(ownerAddr); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Set(maxUint256), nil
 }
 
 func (t *TonRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr string) (*big.Int, error) {
+	return t.GetBalanceAtBlockNumber(ctx, ownerAddr, tokenAddr, 0)
+}
+
+// GetBalanceAtBlockNumber returns the balance at the masterchain block of seqno blockNumber,
+// or at the last one when blockNumber is not positive.
+func (t *TonRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
 	addr, err := address.ParseAddr(ownerAddr)
 	if err != nil {
 		return nil, err
@@ -72,15 +315,25 @@ func (t *TonRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr str
 	if err != nil {
 		return nil, err
 	}
+
+	var block *ton.BlockIDExt
+	if blockNumber > 0 {
+		block, err = client.LookupBlock(ctx, address.MasterchainID, math.MinInt64, uint32(blockNumber))
+	} else {
+		block, err = client.GetMasterchainInfo(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	if util.IsNativeAddress(tokenAddr) {
-		block, err := client.GetMasterchainInfo(ctx)
-		if err != nil {
-			return nil, err
-		}
 		res, err := client.GetAccount(ctx, block, addr)
 		if err != nil {
 			return nil, err
 		}
+		if res.State == nil {
+			return big.NewInt(0), nil
+		}
 		return res.State.Balance.Nano(), nil
 	}
 
@@ -90,12 +343,12 @@ func (t *TonRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr str
 	}
 
 	jettonClient := jetton.NewJettonMasterClient(client, minterAddr)
-	walletClient, err := jettonClient.GetJettonWallet(ctx, addr)
+	walletClient, err := jettonClient.GetJettonWalletAtBlock(ctx, addr, block)
 	if err != nil {
 		return nil, err
 	}
 
-	balance, err := walletClient.GetBalance(ctx)
+	balance, err := walletClient.GetBalanceAtBlock(ctx, block)
 	if err != nil {
 		return nil, err
 	}
@@ -103,12 +356,203 @@ func (t *TonRpc) GetBalance(ctx context.Context, ownerAddr string, tokenAddr str
 	return balance, nil
 }
 
-func (t *TonRpc) GetBalanceAtBlockNumber(ctx context.Context, ownerAddr string, tokenAddr string, blockNumber int64) (*big.Int, error) {
-	return t.GetBalance(ctx, ownerAddr, tokenAddr)
+// tonMetadata is the TEP-64 metadata of a jetton.
+type tonMetadata struct {
+	Name        string          `json:"name"`
+	Symbol      string          `json:"symbol"`
+	Decimals    json.RawMessage `json:"decimals"` // A string by the standard, a number for some jettons
+	Image       string          `json:"image"`
+	Description string          `json:"description"`
+}
+
+func (m *tonMetadata) decimals() (int32, error) {
+	value := strings.Trim(strings.TrimSpace(string(m.Decimals)), `"`)
+	if value == "" {
+		return tonDecimals, nil
+	}
+	decimals, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid jetton decimals %s", value)
+	}
+	return int32(decimals), nil
+}
+
+// merge fills the empty fields of m with those of other.
+func (m *tonMetadata) merge(other *tonMetadata) {
+	if m.Name == "" {
+		m.Name = other.Name
+	}
+	if m.Symbol == "" {
+		m.Symbol = other.Symbol
+	}
+	if len(m.Decimals) == 0 {
+		m.Decimals = other.Decimals
+	}
+	if m.Image == "" {
+		m.Image = other.Image
+	}
+	if m.Description == "" {
+		m.Description = other.Description
+	}
+}
+
+func onchainTonMetadata(content *nft.ContentOnchain) *tonMetadata {
+	metadata := &tonMetadata{
+		Name:        content.GetAttribute("name"),
+		Symbol:      content.GetAttribute("symbol"),
+		Image:       content.GetAttribute("image"),
+		Description: content.GetAttribute("description"),
+	}
+	if decimals := content.GetAttribute("decimals"); decimals != "" {
+		metadata.Decimals = json.RawMessage(strconv.Quote(decimals))
+	}
+	return metadata
+}
+
+// tonMetadataClient fetches off-chain metadata. Their uri is chosen by whoever deploys the jetton,
+// so only public addresses are dialed, redirects included, and slow servers are cut off.
+var tonMetadataClient = &http.Client{
+	Timeout: tonMetadataTimeout,
+	Transport: &http.Transport{
+		DialContext:            (&net.Dialer{Timeout: tonMetadataTimeout, Control: dialPublicOnly}).DialContext,
+		TLSHandshakeTimeout:    tonMetadataTimeout,
+		ResponseHeaderTimeout:  tonMetadataTimeout,
+		MaxResponseHeaderBytes: 64 << 10,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 3 {
+			return fmt.Errorf("too many redirects")
+		}
+		if req.URL.Scheme != "https" {
+			return fmt.Errorf("redirect to %s, only https is allowed", req.URL.Scheme)
+		}
+		return nil
+	},
+}
+
+// dialPublicOnly refuses to connect to loopback, private, link-local and other non public addresses.
+func dialPublicOnly(network string, addr string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if !ip.IsGlobalUnicast() || ip.IsPrivate() || cgnatPrefix.Contains(ip) {
+		return fmt.Errorf("address %s is not public", ip)
+	}
+	return nil
+}
+
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// offchainTonMetadata fetches metadata over https, ipfs uris through a public gateway,
+// and reads at most tonMetadataMaxSize bytes of it.
+func offchainTonMetadata(ctx context.Context, client *http.Client, uri string) (*tonMetadata, error) {
+	if strings.HasPrefix(uri, "ipfs://") {
+		uri = tonIpfsGateway + strings.TrimPrefix(uri, "ipfs://")
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jetton metadata uri %s: %w", uri, err)
+	}
+	if parsed.Scheme != "https" {
+		return nil, fmt.Errorf("jetton metadata uri %s is not https nor ipfs", uri)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("jetton metadata %s: %w", uri, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("jetton metadata %s: unexpected status code %d", uri, resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, tonMetadataMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("jetton metadata %s: %w", uri, err)
+	}
+	if len(body) > tonMetadataMaxSize {
+		return nil, fmt.Errorf("jetton metadata %s is larger than %d bytes", uri, tonMetadataMaxSize)
+	}
+
+	var metadata tonMetadata
+	if err := json.Unmarshal(body, &metadata); err != nil {
+		return nil, fmt.Errorf("jetton metadata %s: %w", uri, err)
+	}
+	return &metadata, nil
+}
+
+// tonContentMetadata reads on-chain, off-chain and semi-chain TEP-64 content, where on-chain
+// attributes take precedence over the off-chain ones fetched with client.
+func tonContentMetadata(ctx context.Context, client *http.Client, content nft.ContentAny) (*tonMetadata, error) {
+	switch c := content.(type) {
+	case *nft.ContentOnchain:
+		return onchainTonMetadata(c), nil
+	case *nft.ContentOffchain:
+		return offchainTonMetadata(ctx, client, c.URI)
+	case *nft.ContentSemichain:
+		metadata := onchainTonMetadata(&c.ContentOnchain)
+		offchain, err := offchainTonMetadata(ctx, client, c.URI)
+		if err != nil {
+			return nil, err
+		}
+		metadata.merge(offchain)
+		return metadata, nil
+	default:
+		return nil, fmt.Errorf("unsupported jetton content %T", content)
+	}
 }
 
 func (t *TonRpc) GetTokenInfo(ctx context.Context, tokenAddr string) (*loader.TokenInfo, error) {
-	return nil, fmt.Errorf("not implement")
+	if util.IsNativeAddress(tokenAddr) {
+		return &loader.TokenInfo{
+			TokenName:    tonNativeSymbol,
+			ChainName:    t.chainInfo.Name,
+			TokenAddress: tokenAddr,
+			Decimals:     tonDecimals,
+			FullName:     "Toncoin",
+			TotalSupply:  big.NewInt(0),
+		}, nil
+	}
+
+	minterAddr, err := address.ParseAddr(tokenAddr)
+	if err != nil {
+		return nil, err
+	}
+	client, err := t.GetClient()
+	if err != nil {
+		return nil, err
+	}
+	data, err := jetton.NewJettonMasterClient(client, minterAddr).GetJettonData(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := tonContentMetadata(ctx, tonMetadataClient, data.Content)
+	if err != nil {
+		return nil, err
+	}
+	decimals, err := metadata.decimals()
+	if err != nil {
+		return nil, err
+	}
+	return &loader.TokenInfo{
+		TokenName:    metadata.Symbol,
+		ChainName:    t.chainInfo.Name,
+		TokenAddress: tokenAddr,
+		Decimals:     decimals,
+		FullName:     metadata.Name,
+		Icon:         metadata.Image,
+		TotalSupply:  data.TotalSupply,
+	}, nil
 }
 
 func (t *TonRpc) IsAddressValid(addr string) bool {
@@ -0,0 +1,224 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/owlto-dao/utils-go/loader"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/nft"
+)
+
+func TestTonGetClientWrapped(t *testing.T) {
+	client := ton.NewAPIClient(liteclient.NewConnectionPool()).WithRetry()
+	tonRpc := NewTonRpc(&loader.ChainInfo{Name: "Ton", Backend: loader.TonBackend, Client: client})
+	if got, err := tonRpc.GetClient(); err != nil || got != client {
+		t.Fatalf("expected the wrapped client, got %v %v", got, err)
+	}
+	if _, err := NewTonRpc(&loader.ChainInfo{Name: "Ton", Backend: loader.TonBackend, Client: "bad"}).GetClient(); err == nil {
+		t.Fatal("expected an error for a foreign client")
+	}
+}
+
+func TestParseTonTxId(t *testing.T) {
+	account := address.NewAddress(0, 0, bytes.Repeat([]byte{7}, 32))
+	hash := bytes.Repeat([]byte{9}, 32)
+
+	txId, err := parseTonTxId(fmt.Sprintf("%s/123/%s", account.String(), hex.EncodeToString(hash)))
+	if err != nil || txId.lt != 123 || !bytes.Equal(txId.hash, hash) || !txId.account.Equals(account) {
+		t.Fatalf("unexpected tx id %+v %v", txId, err)
+	}
+	txId, err = parseTonTxId(fmt.Sprintf("%s/%s", fmt.Sprintf("0:%x", account.Data()), base64.StdEncoding.EncodeToString(hash)))
+	if err != nil || txId.lt != 0 || !bytes.Equal(txId.hash, hash) {
+		t.Fatalf("unexpected tx id without lt %+v %v", txId, err)
+	}
+	for _, id := range []string{hex.EncodeToString(hash), account.String() + "/x/" + hex.EncodeToString(hash), account.String() + "/abcd"} {
+		if _, err := parseTonTxId(id); err == nil {
+			t.Fatalf("expected %s to be invalid", id)
+		}
+	}
+}
+
+func TestTonTxSuccess(t *testing.T) {
+	tx := func(aborted bool, compute any, action *tlb.ActionPhase) *tlb.Transaction {
+		return &tlb.Transaction{Description: tlb.TransactionDescription{Description: tlb.TransactionDescriptionOrdinary{
+			Aborted:      aborted,
+			ComputePhase: tlb.ComputePhase{Phase: compute},
+			ActionPhase:  action,
+		}}}
+	}
+	cases := []struct {
+		name string
+		tx   *tlb.Transaction
+		want bool
+	}{
+		{"success", tx(false, tlb.ComputePhaseVM{Success: true}, &tlb.ActionPhase{Success: true}), true},
+		{"no action phase", tx(false, tlb.ComputePhaseVM{Success: true}, nil), true},
+		{"compute failed", tx(true, tlb.ComputePhaseVM{Success: false}, nil), false},
+		{"compute skipped", tx(true, tlb.ComputePhaseSkipped{}, nil), false},
+		{"action failed", tx(false, tlb.ComputePhaseVM{Success: true}, &tlb.ActionPhase{Success: false}), false},
+		{"tick tock", &tlb.Transaction{Description: tlb.TransactionDescription{Description: tlb.TransactionDescriptionTickTock{}}}, false},
+	}
+	for _, c := range cases {
+		if got := tonTxSuccess(c.tx); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+type fakeTonLiteClient struct {
+	ton.LiteClient
+	lookups []ton.LookupBlock
+}
+
+func (c *fakeTonLiteClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	lookup, ok := payload.(ton.LookupBlock)
+	if !ok {
+		return fmt.Errorf("unexpected query %T", payload)
+	}
+	c.lookups = append(c.lookups, lookup)
+	*result.(*tl.Serializable) = ton.BlockHeader{ID: &ton.BlockIDExt{Workchain: lookup.ID.Workchain, Shard: lookup.ID.Shard, SeqNo: 4242}}
+	return nil
+}
+
+func TestTonTxBlock(t *testing.T) {
+	liteClient := &fakeTonLiteClient{}
+	account := address.NewAddress(0, 0, append([]byte{0xc0, 1, 2, 3, 4, 5, 6, 7}, bytes.Repeat([]byte{9}, 24)...))
+	block, err := tonTxBlock(context.Background(), ton.NewAPIClient(liteClient), account, 123)
+	if err != nil || block.SeqNo != 4242 {
+		t.Fatalf("unexpected block %+v %v", block, err)
+	}
+	lookup := liteClient.lookups[0]
+	if lookup.Mode != 2 || lookup.LT != 123 || lookup.ID.Workchain != 0 || uint64(lookup.ID.Shard) != 0xc001020304050607 {
+		t.Fatalf("block should be looked up by lt in the shard of the account: %+v %+v", lookup, lookup.ID)
+	}
+}
+
+func TestTonContentMetadata(t *testing.T) {
+	ctx := context.Background()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/large" {
+			w.Write(bytes.Repeat([]byte(" "), tonMetadataMaxSize+1))
+			return
+		}
+		w.Write([]byte(`{"name":"Tether USD","symbol":"USDT","decimals":6,"image":"https://example.com/usdt.png"}`))
+	}))
+	defer server.Close()
+	client := server.Client()
+
+	onchain := &nft.ContentOnchain{}
+	for name, value := range map[string]string{"name": "Notcoin", "symbol": "NOT", "decimals": "9"} {
+		if err := onchain.SetAttribute(name, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	metadata, err := tonContentMetadata(ctx, client, onchain)
+	if decimals, _ := metadata.decimals(); err != nil || metadata.Symbol != "NOT" || decimals != 9 {
+		t.Fatalf("unexpected on-chain metadata %+v %v", metadata, err)
+	}
+
+	metadata, err = tonContentMetadata(ctx, client, &nft.ContentOffchain{URI: server.URL})
+	if decimals, _ := metadata.decimals(); err != nil || metadata.Symbol != "USDT" || decimals != 6 {
+		t.Fatalf("unexpected off-chain metadata %+v %v", metadata, err)
+	}
+
+	semichain := &nft.ContentSemichain{ContentOffchain: nft.ContentOffchain{URI: server.URL}}
+	if err := semichain.ContentOnchain.SetAttribute("symbol", "USD₮"); err != nil {
+		t.Fatal(err)
+	}
+	metadata, err = tonContentMetadata(ctx, client, semichain)
+	if decimals, _ := metadata.decimals(); err != nil || metadata.Symbol != "USD₮" || metadata.Name != "Tether USD" || decimals != 6 {
+		t.Fatalf("unexpected semi-chain metadata %+v %v", metadata, err)
+	}
+
+	if _, err := offchainTonMetadata(ctx, client, server.URL+"/large"); err == nil {
+		t.Fatal("expected oversized metadata to be refused")
+	}
+	if _, err := offchainTonMetadata(ctx, client, "http://example.com/usdt.json"); err == nil {
+		t.Fatal("expected plain http metadata to be refused")
+	}
+	if _, err := offchainTonMetadata(ctx, tonMetadataClient, server.URL); err == nil {
+		t.Fatal("expected metadata on a loopback address to be refused")
+	}
+}
+
+func TestDialPublicOnly(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"1.1.1.1:443":            true,
+		"[2606:4700::1111]:443":  true,
+		"127.0.0.1:443":          false,
+		"10.0.0.1:443":           false,
+		"192.168.1.1:443":        false,
+		"169.254.169.254:80":     false,
+		"100.64.0.1:443":         false,
+		"0.0.0.0:443":            false,
+		"[::1]:443":              false,
+		"[fd00::1]:443":          false,
+		"[::ffff:127.0.0.1]:443": false,
+		"[fe80::1%eth0]:443":     false,
+	} {
+		if err := dialPublicOnly("tcp", addr, nil); (err == nil) != public {
+			t.Errorf("%s: got %v, want public %v", addr, err, public)
+		}
+	}
+}
+
+func TestTonShardsOverlap(t *testing.T) {
+	root := int64(math.MinInt64)                                         // 0x8000000000000000
+	left, right := int64(0x4000000000000000), int64(-0x4000000000000000) // 0x4..., 0xc...
+	if !tonShardsOverlap(root, left) || !tonShardsOverlap(right, root) || !tonShardsOverlap(left, left) {
+		t.Fatal("a shard should overlap its children and itself")
+	}
+	if tonShardsOverlap(left, right) || tonShardsOverlap(int64(0x6000000000000000), right) {
+		t.Fatal("disjoint shards should not overlap")
+	}
+}
+
+// fakeTonMasterchain serves the masterchain blocks from 100 to 103, the shard top of workchain 0
+// reaching seqno 500 at 102.
+type fakeTonMasterchain struct {
+	ton.APIClientWrapped
+}
+
+func (c *fakeTonMasterchain) GetBlockData(ctx context.Context, block *ton.BlockIDExt) (*tlb.Block, error) {
+	return &tlb.Block{BlockInfo: tlb.BlockHeader{MasterRef: &tlb.ExtBlkRef{SeqNo: 100}}}, nil
+}
+
+func (c *fakeTonMasterchain) CurrentMasterchainInfo(ctx context.Context) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{Workchain: address.MasterchainID, SeqNo: 103}, nil
+}
+
+func (c *fakeTonMasterchain) LookupBlock(ctx context.Context, workchain int32, shard int64, seqno uint32) (*ton.BlockIDExt, error) {
+	return &ton.BlockIDExt{Workchain: workchain, Shard: shard, SeqNo: seqno}, nil
+}
+
+func (c *fakeTonMasterchain) GetBlockShardsInfo(ctx context.Context, master *ton.BlockIDExt) ([]*ton.BlockIDExt, error) {
+	return []*ton.BlockIDExt{{Workchain: 0, Shard: math.MinInt64, SeqNo: 498 + master.SeqNo - 100}}, nil
+}
+
+func TestTonMasterchainSeqno(t *testing.T) {
+	client := &fakeTonMasterchain{}
+	shardBlock := &ton.BlockIDExt{Workchain: 0, Shard: 0x4000000000000000, SeqNo: 500}
+	if seqno, err := tonMasterchainSeqno(context.Background(), client, shardBlock); err != nil || seqno != 102 {
+		t.Fatalf("expected the masterchain block committing the shard block, got %d %v", seqno, err)
+	}
+	shardBlock.SeqNo = 600
+	if _, err := tonMasterchainSeqno(context.Background(), client, shardBlock); err == nil {
+		t.Fatal("expected an error for a shard block not committed yet")
+	}
+	masterBlock := &ton.BlockIDExt{Workchain: address.MasterchainID, SeqNo: 90}
+	if seqno, err := tonMasterchainSeqno(context.Background(), client, masterBlock); err != nil || seqno != 90 {
+		t.Fatalf("masterchain blocks are their own seqno, got %d %v", seqno, err)
+	}
+}
@@ -0,0 +1,223 @@
+package btc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/owlto-dao/utils-go/esplora"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrDustAmount        = errors.New("amount below dust limit")
+)
+
+const (
+	txOverheadVSize  = 11 // Version, locktime, input and output counts, segwit marker and flag
+	p2wpkhInputVSize = 68
+	p2trInputVSize   = 58 // Key path spend
+	rbfSequence      = wire.MaxTxInSequenceNum - 2
+)
+
+// Utxo is an output a transfer can spend.
+type Utxo struct {
+	TxId           string
+	Vout           uint32
+	Value          int64  // Satoshi
+	Address        string // Used to derive PkScript when it is empty
+	PkScript       []byte
+	TapInternalKey []byte // Optional x-only internal key of P2TR outputs, for the signer
+}
+
+// FromEsploraUtxos returns the utxos of addr listed by an Esplora server.
+func FromEsploraUtxos(addr string, utxos []esplora.Utxo) []Utxo {
+	result := make([]Utxo, 0, len(utxos))
+	for _, utxo := range utxos {
+		result = append(result, Utxo{TxId: utxo.TxId, Vout: utxo.Vout, Value: utxo.Value, Address: addr})
+	}
+	return result
+}
+
+// TransferParams describes a transfer of Amount satoshi to Receiver, funded by Utxos.
+type TransferParams struct {
+	Net           *chaincfg.Params // MainNetParams or TestNet3Params, see NetParams
+	Utxos         []Utxo           // P2WPKH or P2TR outputs
+	Receiver      string
+	Amount        *big.Int
+	ChangeAddress string
+	FeeRate       float64 // Sat/vB
+	Memo          []byte  // Optional, added as an OP_RETURN output
+}
+
+// TransferPsbt is an unsigned transfer.
+type TransferPsbt struct {
+	Packet *psbt.Packet
+	Inputs []Utxo // The selected utxos, in input order
+	Fee    int64
+	Change int64 // 0 when the change was below the dust limit and left to the fee
+	VSize  int64 // Estimated virtual size once signed
+}
+
+// B64Encode returns the base64 encoded PSBT, the format wallets sign.
+func (t *TransferPsbt) B64Encode() (string, error) {
+	return t.Packet.B64Encode()
+}
+
+// NetParams returns the params of the bitcoin network, as BitcoinRpc picks them.
+func NetParams(isTestnet bool) *chaincfg.Params {
+	if isTestnet {
+		return &chaincfg.TestNet3Params
+	}
+	return &chaincfg.MainNetParams
+}
+
+// BuildTransferPsbt selects utxos, largest first, until they pay the amount and the fee at FeeRate,
+// and builds the unsigned PSBT of the transfer with a change output when the change is not dust.
+// Inputs signal replace-by-fee.
+func BuildTransferPsbt(params *TransferParams) (*TransferPsbt, error) {
+	if params.Net == nil {
+		return nil, fmt.Errorf("missing net params")
+	}
+	if params.Amount == nil || !params.Amount.IsInt64() || params.Amount.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid amount %v", params.Amount)
+	}
+	if params.FeeRate <= 0 {
+		return nil, fmt.Errorf("invalid fee rate %v", params.FeeRate)
+	}
+	amount := params.Amount.Int64()
+
+	receiverScript, err := addressScript(params.Receiver, params.Net)
+	if err != nil {
+		return nil, fmt.Errorf("receiver: %w", err)
+	}
+	if amount < dustLimit(receiverScript) {
+		return nil, ErrDustAmount
+	}
+	changeScript, err := addressScript(params.ChangeAddress, params.Net)
+	if err != nil {
+		return nil, fmt.Errorf("change address: %w", err)
+	}
+	outputs := []*wire.TxOut{wire.NewTxOut(amount, receiverScript)}
+	if len(params.Memo) > 0 {
+		memoScript, err := txscript.NullDataScript(params.Memo)
+		if err != nil {
+			return nil, fmt.Errorf("memo: %w", err)
+		}
+		outputs = append(outputs, wire.NewTxOut(0, memoScript))
+	}
+
+	utxos := make([]Utxo, 0, len(params.Utxos))
+	for _, utxo := range params.Utxos {
+		if len(utxo.PkScript) == 0 {
+			if utxo.PkScript, err = addressScript(utxo.Address, params.Net); err != nil {
+				return nil, fmt.Errorf("utxo %s:%d: %w", utxo.TxId, utxo.Vout, err)
+			}
+		}
+		utxos = append(utxos, utxo)
+	}
+	sort.SliceStable(utxos, func(i, j int) bool { return utxos[i].Value > utxos[j].Value })
+
+	vsize := int64(txOverheadVSize)
+	for _, output := range outputs {
+		vsize += outputVSize(output.PkScript)
+	}
+	changeVSize := outputVSize(changeScript)
+
+	var selected []Utxo
+	var total int64
+	for _, utxo := range utxos {
+		inputVSize, err := inputVSize(utxo.PkScript)
+		if err != nil {
+			return nil, fmt.Errorf("utxo %s:%d: %w", utxo.TxId, utxo.Vout, err)
+		}
+		selected = append(selected, utxo)
+		total += utxo.Value
+		vsize += inputVSize
+
+		if fee := feeFor(vsize+changeVSize, params.FeeRate); total-amount-fee >= dustLimit(changeScript) {
+			outputs = append(outputs, wire.NewTxOut(total-amount-fee, changeScript))
+			return newTransferPsbt(selected, outputs, fee, total-amount-fee, vsize+changeVSize)
+		}
+		if fee := feeFor(vsize, params.FeeRate); total-amount >= fee {
+			return newTransferPsbt(selected, outputs, total-amount, 0, vsize)
+		}
+	}
+	return nil, fmt.Errorf("%w: have %d, need %d plus fee", ErrInsufficientFunds, total, amount)
+}
+
+func newTransferPsbt(inputs []Utxo, outputs []*wire.TxOut, fee int64, change int64, vsize int64) (*TransferPsbt, error) {
+	tx := wire.NewMsgTx(wire.TxVersion)
+	for _, input := range inputs {
+		hash, err := chainhash.NewHashFromStr(input.TxId)
+		if err != nil {
+			return nil, fmt.Errorf("utxo %s: %w", input.TxId, err)
+		}
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, input.Vout), nil, nil)
+		txIn.Sequence = rbfSequence
+		tx.AddTxIn(txIn)
+	}
+	for _, output := range outputs {
+		tx.AddTxOut(output)
+	}
+
+	packet, err := psbt.NewFromUnsignedTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	for i, input := range inputs {
+		packet.Inputs[i].WitnessUtxo = wire.NewTxOut(input.Value, input.PkScript)
+		if len(input.TapInternalKey) > 0 {
+			packet.Inputs[i].TaprootInternalKey = input.TapInternalKey
+		}
+	}
+	return &TransferPsbt{Packet: packet, Inputs: inputs, Fee: fee, Change: change, VSize: vsize}, nil
+}
+
+func addressScript(addr string, net *chaincfg.Params) ([]byte, error) {
+	decoded, err := btcutil.DecodeAddress(strings.TrimSpace(addr), net)
+	if err != nil {
+		return nil, err
+	}
+	if !decoded.IsForNet(net) {
+		return nil, fmt.Errorf("address %s is not for %s", addr, net.Name)
+	}
+	return txscript.PayToAddrScript(decoded)
+}
+
+func inputVSize(pkScript []byte) (int64, error) {
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.WitnessV0PubKeyHashTy:
+		return p2wpkhInputVSize, nil
+	case txscript.WitnessV1TaprootTy:
+		return p2trInputVSize, nil
+	default:
+		return 0, fmt.Errorf("unsupported input script %s", txscript.GetScriptClass(pkScript))
+	}
+}
+
+func outputVSize(pkScript []byte) int64 {
+	return int64(8 + wire.VarIntSerializeSize(uint64(len(pkScript))) + len(pkScript))
+}
+
+// dustLimit is the dust threshold of Bitcoin Core at its default 3 sat/vB dust relay fee.
+func dustLimit(pkScript []byte) int64 {
+	spendVSize := int64(148)
+	if txscript.IsWitnessProgram(pkScript) {
+		spendVSize = 67
+	}
+	return 3 * (outputVSize(pkScript) + spendVSize)
+}
+
+func feeFor(vsize int64, feeRate float64) int64 {
+	return int64(math.Ceil(float64(vsize) * feeRate))
+}
@@ -0,0 +1,128 @@
+package btc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func testAddresses(t *testing.T, net *chaincfg.Params) (string, string) {
+	p2wpkh, err := btcutil.NewAddressWitnessPubKeyHash(bytes.Repeat([]byte{1}, 20), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2tr, err := btcutil.NewAddressTaproot(bytes.Repeat([]byte{2}, 32), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p2wpkh.EncodeAddress(), p2tr.EncodeAddress()
+}
+
+// generatorX is the x-only key of the secp256k1 generator point
+var generatorX, _ = hex.DecodeString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798")
+
+func testTxId(b byte) string {
+	return strings.Repeat(string("0123456789abcdef"[b%16]), 64)
+}
+
+func TestBuildTransferPsbt(t *testing.T) {
+	net := NetParams(false)
+	p2wpkh, p2tr := testAddresses(t, net)
+
+	transfer, err := BuildTransferPsbt(&TransferParams{
+		Net: net,
+		Utxos: []Utxo{
+			{TxId: testTxId(1), Vout: 0, Value: 20000, Address: p2wpkh},
+			{TxId: testTxId(2), Vout: 1, Value: 50000, Address: p2tr, TapInternalKey: generatorX},
+			{TxId: testTxId(3), Vout: 2, Value: 1000, Address: p2wpkh},
+		},
+		Receiver:      p2tr,
+		Amount:        big.NewInt(60000),
+		ChangeAddress: p2wpkh,
+		FeeRate:       10,
+		Memo:          []byte("owlto"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Largest first: the P2TR utxo then the 20000 P2WPKH one
+	if len(transfer.Inputs) != 2 || transfer.Inputs[0].Value != 50000 || transfer.Inputs[1].Value != 20000 {
+		t.Fatalf("unexpected inputs %+v", transfer.Inputs)
+	}
+	// 11 overhead + 58 + 68 inputs + 43 receiver + 16 memo + 31 change
+	if transfer.VSize != 227 || transfer.Fee != 2270 || transfer.Change != 70000-60000-2270 {
+		t.Fatalf("unexpected vsize %d fee %d change %d", transfer.VSize, transfer.Fee, transfer.Change)
+	}
+
+	tx := transfer.Packet.UnsignedTx
+	if len(tx.TxOut) != 3 || tx.TxOut[0].Value != 60000 || tx.TxOut[2].Value != transfer.Change {
+		t.Fatalf("unexpected outputs %+v", tx.TxOut)
+	}
+	if txscript.GetScriptClass(tx.TxOut[1].PkScript) != txscript.NullDataTy {
+		t.Fatal("expected an OP_RETURN memo output")
+	}
+	if tx.TxIn[0].Sequence != wire.MaxTxInSequenceNum-2 {
+		t.Fatal("expected inputs to signal rbf")
+	}
+	if transfer.Packet.Inputs[0].WitnessUtxo.Value != 50000 || len(transfer.Packet.Inputs[0].TaprootInternalKey) != 32 {
+		t.Fatalf("unexpected psbt input %+v", transfer.Packet.Inputs[0])
+	}
+
+	encoded, err := transfer.B64Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := psbt.NewFromRawBytes(strings.NewReader(encoded), true); err != nil {
+		t.Fatalf("decode psbt: %v", err)
+	}
+}
+
+func TestBuildTransferPsbtChangeAndErrors(t *testing.T) {
+	net := NetParams(true)
+	p2wpkh, p2tr := testAddresses(t, net)
+	params := &TransferParams{
+		Net:           net,
+		Utxos:         []Utxo{{TxId: testTxId(4), Vout: 0, Value: 10000, Address: p2wpkh}},
+		Receiver:      p2tr,
+		Amount:        big.NewInt(8500),
+		ChangeAddress: p2wpkh,
+		FeeRate:       5,
+	}
+
+	// 11 + 68 + 43 + 31 change = 153 vB, 765 sat fee: the 735 sat change is over the 294 dust limit,
+	// at 10 sat/vB there is no room for change and the 1500 sat left go to the fee
+	transfer, err := BuildTransferPsbt(params)
+	if err != nil || transfer.Change != 10000-8500-765 || len(transfer.Packet.UnsignedTx.TxOut) != 2 {
+		t.Fatalf("unexpected transfer %+v %v", transfer, err)
+	}
+	params.FeeRate = 10
+	transfer, err = BuildTransferPsbt(params)
+	if err != nil || transfer.Change != 0 || transfer.Fee != 1500 || len(transfer.Packet.UnsignedTx.TxOut) != 1 {
+		t.Fatalf("unexpected transfer without change %+v %v", transfer, err)
+	}
+
+	params.Amount = big.NewInt(9900)
+	if _, err := BuildTransferPsbt(params); !errors.Is(err, ErrInsufficientFunds) {
+		t.Fatalf("expected insufficient funds, got %v", err)
+	}
+	params.Amount = big.NewInt(100)
+	if _, err := BuildTransferPsbt(params); !errors.Is(err, ErrDustAmount) {
+		t.Fatalf("expected dust amount, got %v", err)
+	}
+	mainnetP2wpkh, _ := testAddresses(t, NetParams(false))
+	params.Amount = big.NewInt(5000)
+	params.Receiver = mainnetP2wpkh
+	if _, err := BuildTransferPsbt(params); err == nil {
+		t.Fatal("expected a mainnet receiver to be rejected on testnet")
+	}
+}